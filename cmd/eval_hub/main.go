@@ -9,9 +9,13 @@ import (
 	"syscall"
 	"time"
 
+	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/callbacks"
 	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/config"
 	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/logging"
+	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/registry"
+	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/runtimes"
 	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/server"
+	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/storage"
 )
 
 func main() {
@@ -23,12 +27,46 @@ func main() {
 		log.Fatal("Failed to create service logger:", err)
 	}
 
-	serviceConfig, err := config.LoadConfig(nil)
+	serviceConfig, err := config.LoadConfig(logger)
 	if err != nil {
 		log.Fatal("Failed to create service config:", err)
 	}
 
-	srv, err := server.NewServer(logger, serviceConfig)
+	if (len(os.Args) > 1) && (os.Args[1] == "migrate") {
+		if err := runMigrate(logger, serviceConfig, os.Args[2:]); err != nil {
+			log.Fatal("Failed to migrate the database:", err)
+		}
+		return
+	}
+
+	store, err := storage.NewStorage(logger, serviceConfig.Database)
+	if err != nil {
+		log.Fatal("Failed to create storage:", err)
+	}
+	defer store.Close()
+
+	runtime, err := runtimes.NewRuntime(logger, serviceConfig.Runtime, store)
+	if err != nil {
+		log.Fatal("Failed to create runtime:", err)
+	}
+	if runtime != nil {
+		defer runtime.Close()
+	}
+
+	providers, err := registry.NewRegistry(logger, serviceConfig.Registry)
+	if err != nil {
+		log.Fatal("Failed to load the registry:", err)
+	}
+	defer providers.Close()
+
+	dispatcher, err := callbacks.NewDispatcher(logger, store, serviceConfig.Callbacks)
+	if err != nil {
+		log.Fatal("Failed to create the callback dispatcher:", err)
+	}
+	dispatcher.Start()
+	defer dispatcher.Close()
+
+	srv, err := server.NewServer(logger, serviceConfig, store, runtime, providers)
 	if err != nil {
 		log.Fatal("Failed to create server:", err)
 	}
@@ -0,0 +1,49 @@
+package abstractions
+
+import (
+	"errors"
+	"fmt"
+)
+
+const (
+	ResourceEvaluationJob = "evaluation job"
+	ResourceCollection    = "collection"
+	ResourceCallback      = "callback delivery"
+	ResourceBenchmark     = "benchmark"
+)
+
+// NotFoundError is returned by a Storage when a resource does not exist for the current tenant.
+type NotFoundError struct {
+	Resource string
+	ID       string
+}
+
+func (e *NotFoundError) Error() string {
+	return fmt.Sprintf("%s %s not found", e.Resource, e.ID)
+}
+
+// ConflictError is returned by a Storage when a write clashes with the stored state of a resource.
+type ConflictError struct {
+	Resource string
+	ID       string
+	Reason   string
+}
+
+func (e *ConflictError) Error() string {
+	if e.Reason == "" {
+		return fmt.Sprintf("%s %s already exists", e.Resource, e.ID)
+	}
+	return fmt.Sprintf("%s %s: %s", e.Resource, e.ID, e.Reason)
+}
+
+// IsNotFound reports whether any error in err's chain is a NotFoundError
+func IsNotFound(err error) bool {
+	var notFound *NotFoundError
+	return errors.As(err, &notFound)
+}
+
+// IsConflict reports whether any error in err's chain is a ConflictError
+func IsConflict(err error) bool {
+	var conflict *ConflictError
+	return errors.As(err, &conflict)
+}
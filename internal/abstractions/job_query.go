@@ -0,0 +1,195 @@
+package abstractions
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/state_machine"
+	"github.ibm.com/julpayne/eval-hub-backend-svc/pkg/api"
+)
+
+// JobQuery is the typed query of the evaluation job listings. The zero value lists all the jobs of
+// the tenant by creation time, every set field narrows the listing.
+type JobQuery struct {
+	// States matches the jobs in any of the states
+	States         []api.State
+	ModelName      string
+	ModelURL       string
+	BenchmarkID    string
+	CollectionID   string
+	ExperimentName string
+	// ExperimentTags matches the jobs whose experiment has all the tags, an empty value only
+	// requires the tag to be set
+	ExperimentTags map[string]string
+	// The time ranges include their start and exclude their end
+	CreatedFrom *time.Time
+	CreatedTo   *time.Time
+	UpdatedFrom *time.Time
+	UpdatedTo   *time.Time
+	Sort        JobSort
+	Page        PageQuery
+	// AllTenants lists the jobs of every tenant instead of those of the tenant of the storage, the
+	// callers only allow it for the principals with the list_all_tenants permission
+	AllTenants bool
+}
+
+// JobSortField is a field the evaluation job listings can be ordered by
+type JobSortField string
+
+const (
+	JobSortCreatedAt JobSortField = "created_at"
+	JobSortUpdatedAt JobSortField = "updated_at"
+	JobSortModelName JobSortField = "model_name"
+	JobSortState     JobSortField = "state"
+)
+
+// JobSortFields lists the supported sort fields
+var JobSortFields = []JobSortField{JobSortCreatedAt, JobSortUpdatedAt, JobSortModelName, JobSortState}
+
+// JobSort orders the jobs by the field and then by creation time and ID, all in the same direction.
+// The zero value orders them by creation time.
+type JobSort struct {
+	Field      JobSortField
+	Descending bool
+}
+
+// Validate checks the states, the time ranges and the sort field of the query
+func (q *JobQuery) Validate() error {
+	for _, state := range q.States {
+		if !state_machine.IsValid(state) {
+			return fmt.Errorf("invalid state %q", state)
+		}
+	}
+	if (q.CreatedFrom != nil) && (q.CreatedTo != nil) && !q.CreatedFrom.Before(*q.CreatedTo) {
+		return fmt.Errorf("the creation time range is empty")
+	}
+	if (q.UpdatedFrom != nil) && (q.UpdatedTo != nil) && !q.UpdatedFrom.Before(*q.UpdatedTo) {
+		return fmt.Errorf("the update time range is empty")
+	}
+	return q.Sort.Validate()
+}
+
+// Matches reports whether the job matches the filters of the query, the pagination is ignored
+func (q *JobQuery) Matches(job *api.EvaluationJobResource) bool {
+	if (len(q.States) > 0) && !slices.Contains(q.States, job.Status.State) {
+		return false
+	}
+	if ((q.ModelName != "") && (job.Model.Name != q.ModelName)) ||
+		((q.ModelURL != "") && (job.Model.URL != q.ModelURL)) ||
+		((q.CollectionID != "") && (job.Collection.ID != q.CollectionID)) ||
+		((q.ExperimentName != "") && (job.Experiment.Name != q.ExperimentName)) {
+		return false
+	}
+	if q.BenchmarkID != "" {
+		found := false
+		for _, benchmark := range job.Benchmarks {
+			found = found || (benchmark.ID == q.BenchmarkID)
+		}
+		if !found {
+			return false
+		}
+	}
+	for key, value := range q.ExperimentTags {
+		tag, ok := job.Experiment.Tags[key]
+		if !ok || ((value != "") && (tag != value)) {
+			return false
+		}
+	}
+	return inRange(job.CreatedAt, q.CreatedFrom, q.CreatedTo) && inRange(job.UpdatedAt, q.UpdatedFrom, q.UpdatedTo)
+}
+
+// ParseJobSort parses a sort field optionally prefixed with - for the descending order
+func ParseJobSort(value string) (JobSort, error) {
+	sort := JobSort{}
+	if field, found := strings.CutPrefix(value, "-"); found {
+		sort.Descending = true
+		value = field
+	}
+	sort.Field = JobSortField(value)
+	return sort, sort.Validate()
+}
+
+// Validate checks the sort field
+func (s JobSort) Validate() error {
+	if s.Field == "" {
+		return nil
+	}
+	if slices.Contains(JobSortFields, s.Field) {
+		return nil
+	}
+	return fmt.Errorf("invalid sort field %q", s.Field)
+}
+
+// Value returns the value of the sort field of the job, it is nil when the jobs are ordered by
+// creation time
+func (s JobSort) Value(job *api.EvaluationJobResource) any {
+	switch s.Field {
+	case JobSortUpdatedAt:
+		return job.UpdatedAt
+	case JobSortModelName:
+		return job.Model.Name
+	case JobSortState:
+		return string(job.Status.State)
+	}
+	return nil
+}
+
+// Compare orders two jobs in the order of the listing
+func (s JobSort) Compare(a, b *api.EvaluationJobResource) int {
+	c := 0
+	switch s.Field {
+	case JobSortUpdatedAt:
+		c = a.UpdatedAt.Compare(b.UpdatedAt)
+	case JobSortModelName:
+		c = strings.Compare(a.Model.Name, b.Model.Name)
+	case JobSortState:
+		c = strings.Compare(string(a.Status.State), string(b.Status.State))
+	}
+	if c == 0 {
+		c = a.CreatedAt.Compare(b.CreatedAt)
+	}
+	if c == 0 {
+		c = strings.Compare(a.ID, b.ID)
+	}
+	if s.Descending {
+		return -c
+	}
+	return c
+}
+
+// PositionAfter returns the QueryAfter value that lists the jobs after the given job, the position
+// carries the value of the sort field
+func (s JobSort) PositionAfter(job *api.EvaluationJobResource) string {
+	position := PositionAfter(&job.Resource)
+	switch value := s.Value(job).(type) {
+	case time.Time:
+		position += "/" + value.UTC().Format(time.RFC3339Nano)
+	case string:
+		position += "/" + value
+	}
+	return position
+}
+
+// PositionJob returns a job holding the sort fields of the position, Compare and Value apply to it
+func (s JobSort) PositionJob(position *Position) (*api.EvaluationJobResource, error) {
+	job := &api.EvaluationJobResource{Resource: api.Resource{ID: position.ID, CreatedAt: position.CreatedAt}}
+	switch s.Field {
+	case JobSortUpdatedAt:
+		updatedAt, err := time.Parse(time.RFC3339Nano, position.Value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s position %q", s.Field, position.Value)
+		}
+		job.UpdatedAt = updatedAt.UTC()
+	case JobSortModelName:
+		job.Model.Name = position.Value
+	case JobSortState:
+		job.Status.State = api.State(position.Value)
+	}
+	return job, nil
+}
+
+func inRange(t time.Time, from *time.Time, to *time.Time) bool {
+	return ((from == nil) || !t.Before(*from)) && ((to == nil) || t.Before(*to))
+}
@@ -0,0 +1,86 @@
+package abstractions
+
+import (
+	"fmt"
+	"math"
+	"slices"
+)
+
+// LeaderboardMode selects which score of a model is ranked when the model was evaluated more than once
+type LeaderboardMode string
+
+const (
+	// LeaderboardLatest ranks the score of the most recently completed evaluation of the model
+	LeaderboardLatest LeaderboardMode = "latest"
+	// LeaderboardBest ranks the best score of the model
+	LeaderboardBest LeaderboardMode = "best"
+)
+
+// LeaderboardTieBreak orders the models with the same score
+type LeaderboardTieBreak string
+
+const (
+	// LeaderboardEarliest ranks first the model that reached the score first
+	LeaderboardEarliest LeaderboardTieBreak = "earliest"
+	// LeaderboardMostRecent ranks first the model that reached the score last
+	LeaderboardMostRecent LeaderboardTieBreak = "latest"
+)
+
+// MaxLeaderboardLimit is the maximum number of models of a leaderboard
+const MaxLeaderboardLimit = 500
+
+// LeaderboardQuery ranks the models by a metric of the completed benchmarks of their evaluation
+// jobs. The score of a job is the mean of the metric over the benchmarks, which the job must all
+// have completed with the metric, and the models are identified by their name and URL. The ties
+// are broken by the completion time of the scores and then by the model name and URL.
+type LeaderboardQuery struct {
+	// Benchmarks holds the benchmark or the benchmarks of the collection that are scored
+	Benchmarks []string
+	// Metric is the name of the metric, nested metrics are named with dots
+	Metric string
+	Mode   LeaderboardMode
+	// Ascending ranks the lower scores first, for the metrics where lower is better
+	Ascending bool
+	TieBreak  LeaderboardTieBreak
+	// MinCompleteness is the minimum fraction of the benchmarks of a job that completed for the job
+	// to be scored, from 0 to 1
+	MinCompleteness float64
+	// Limit is the number of models ranked
+	Limit int
+}
+
+// Validate checks the query and fills in the default mode and tie break
+func (q *LeaderboardQuery) Validate() error {
+	if len(q.Benchmarks) == 0 {
+		return fmt.Errorf("a benchmark is required")
+	}
+	for i, benchmark := range q.Benchmarks {
+		if benchmark == "" || slices.Contains(q.Benchmarks[:i], benchmark) {
+			return fmt.Errorf("the benchmarks must be unique and not empty")
+		}
+	}
+	if q.Metric == "" {
+		return fmt.Errorf("a metric is required")
+	}
+	switch q.Mode {
+	case "":
+		q.Mode = LeaderboardLatest
+	case LeaderboardLatest, LeaderboardBest:
+	default:
+		return fmt.Errorf("invalid mode %q, expected %s or %s", q.Mode, LeaderboardLatest, LeaderboardBest)
+	}
+	switch q.TieBreak {
+	case "":
+		q.TieBreak = LeaderboardEarliest
+	case LeaderboardEarliest, LeaderboardMostRecent:
+	default:
+		return fmt.Errorf("invalid tie break %q, expected %s or %s", q.TieBreak, LeaderboardEarliest, LeaderboardMostRecent)
+	}
+	if math.IsNaN(q.MinCompleteness) || (q.MinCompleteness < 0) || (q.MinCompleteness > 1) {
+		return fmt.Errorf("the minimum completeness must be between 0 and 1")
+	}
+	if (q.Limit < 1) || (q.Limit > MaxLeaderboardLimit) {
+		return fmt.Errorf("the limit must be between 1 and %d", MaxLeaderboardLimit)
+	}
+	return nil
+}
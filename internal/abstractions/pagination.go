@@ -0,0 +1,88 @@
+package abstractions
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.ibm.com/julpayne/eval-hub-backend-svc/pkg/api"
+)
+
+// The listings are ordered by creation time and then by ID, these Query keys paginate them
+const (
+	// QueryLimit is the maximum number of resources to list
+	QueryLimit = "limit"
+	// QueryOffset is the number of resources to skip
+	QueryOffset = "offset"
+	// QueryAfter lists the resources after the position returned by PositionAfter, unlike an offset
+	// it is not affected by the resources created in the meantime
+	QueryAfter = "after"
+)
+
+// PageQuery holds the pagination keys of a Query
+type PageQuery struct {
+	// Limit is 0 when the listing is not limited
+	Limit  int
+	Offset int
+	// After is nil when the listing starts from the first resource
+	After *Position
+}
+
+// Position is the position of a resource in the listings
+type Position struct {
+	CreatedAt time.Time
+	ID        string
+	// Value is the value of the sort field of the listings that are not ordered by creation time
+	Value string
+}
+
+// PositionAfter returns the QueryAfter value that lists the resources after the given resource
+func PositionAfter(resource *api.Resource) string {
+	return resource.CreatedAt.UTC().Format(time.RFC3339Nano) + "/" + resource.ID
+}
+
+// Page parses the pagination keys of the query
+func (q Query) Page() (*PageQuery, error) {
+	page := &PageQuery{}
+	var err error
+	if value, ok := q[QueryLimit]; ok {
+		if page.Limit, err = strconv.Atoi(value); (err != nil) || (page.Limit < 1) {
+			return nil, fmt.Errorf("invalid %s %q, expected a positive integer", QueryLimit, value)
+		}
+	}
+	if value, ok := q[QueryOffset]; ok {
+		if page.Offset, err = strconv.Atoi(value); (err != nil) || (page.Offset < 0) {
+			return nil, fmt.Errorf("invalid %s %q, expected a non negative integer", QueryOffset, value)
+		}
+	}
+	if value, ok := q[QueryAfter]; ok {
+		createdAt, rest, found := strings.Cut(value, "/")
+		id, sortValue, _ := strings.Cut(rest, "/")
+		parsed, err := time.Parse(time.RFC3339Nano, createdAt)
+		if !found || (err != nil) || (id == "") {
+			return nil, fmt.Errorf("invalid %s %q", QueryAfter, value)
+		}
+		page.After = &Position{CreatedAt: parsed.UTC(), ID: id, Value: sortValue}
+	}
+	return page, nil
+}
+
+// Filters returns the query without its pagination keys
+func (q Query) Filters() Query {
+	filters := Query{}
+	for key, value := range q {
+		if (key != QueryLimit) && (key != QueryOffset) && (key != QueryAfter) {
+			filters[key] = value
+		}
+	}
+	return filters
+}
+
+// IsAfter reports whether the resource is after the position
+func (p *Position) IsAfter(resource *api.Resource) bool {
+	if c := resource.CreatedAt.Compare(p.CreatedAt); c != 0 {
+		return c > 0
+	}
+	return resource.ID > p.ID
+}
@@ -1,10 +1,30 @@
 package abstractions
 
-import "github.ibm.com/julpayne/eval-hub-backend-svc/pkg/api"
+import (
+	"errors"
+
+	"github.ibm.com/julpayne/eval-hub-backend-svc/pkg/api"
+)
+
+// ErrRuntimeBusy is returned by RunEvaluationJob when the runtime has no room for the job, the job
+// has not been started and may be submitted again later
+var ErrRuntimeBusy = errors.New("the runtime is busy")
 
 // Runtime interface defines the methods for running evaluation jobs. Concrete implemementation
 // hold the specific aspects of various runtimes (i.e. K8s, local, etc.). No other places in the code should
 // be pointing directly to K8s or other runtime specific details.
 type Runtime interface {
 	RunEvaluationJob(evaluation *api.EvaluationJobResource, storage *Storage) error
+	// CancelEvaluationJob stops the work of the evaluation job, the runtime must not update the
+	// states of the job once it returns. Jobs that the runtime does not know are not an error.
+	CancelEvaluationJob(evaluation *api.EvaluationJobResource, storage *Storage) error
+	// Close stops the runtime, it is called once when the service shuts down
+	Close() error
+}
+
+// BenchmarkLimiter is implemented by the runtimes that can not run the jobs that have more than a
+// number of benchmarks, those jobs are rejected when they are submitted
+type BenchmarkLimiter interface {
+	// MaxBenchmarks returns the maximum number of benchmarks of a job
+	MaxBenchmarks() int
 }
@@ -1,20 +1,71 @@
 package abstractions
 
-import "github.ibm.com/julpayne/eval-hub-backend-svc/pkg/api"
+import (
+	"time"
 
+	"github.ibm.com/julpayne/eval-hub-backend-svc/pkg/api"
+)
+
+// Query holds the filters to apply when listing resources. Keys are the Query... constants below,
+// a storage implementation returns an error for keys that it does not support. The evaluation jobs
+// are listed with the typed JobQuery instead.
 type Query map[string]string
 
+const (
+	// QueryState filters the callback deliveries by their state
+	QueryState = "state"
+	// QueryName filters collections by name
+	QueryName = "name"
+	// QueryJobID filters the callback deliveries by evaluation job
+	QueryJobID = "job_id"
+)
+
+// DefaultTenant is the tenant used when no tenant has been resolved for a request.
+const DefaultTenant api.Tenant = "default"
+
+// Storage interface defines the methods for persisting the REST resources. Every Storage is scoped
+// to a single tenant, resources that belong to other tenants are reported as not found.
+// The listings honour the pagination of the query, the TotalCount of the returned page counts
+// all the resources matching the filters.
 type Storage interface {
+	// WithTenant returns a view of the same underlying storage scoped to the given tenant.
+	WithTenant(tenant api.Tenant) Storage
+
 	CreateEvaluationJob(evaluation *api.EvaluationJobResource) error
 	GetEvaluationJob(id string) (*api.EvaluationJobResource, error)
-	GetEvaluationJobs(query Query) (*api.EvaluationJobResourceList, error)
+	GetEvaluationJobs(query *JobQuery) (*api.EvaluationJobResourceList, error)
 	DeleteEvaluationJob(id string) error
+	// UpdateBenchmarkStatusForJob and UpdateEvaluationJobStatus apply the state_machine rules: illegal
+	// transitions return a state_machine.TransitionError and the job state is derived from the
+	// states of its benchmarks.
 	UpdateBenchmarkStatusForJob(id string, status api.BenchmarkStatus) error
 	UpdateEvaluationJobStatus(id string, state api.EvaluationJobState) error
+	// RecordBenchmarkResult stores the result of a benchmark and moves the benchmark to the state of
+	// the result in the same update, see aggregation.RecordResult. It returns the job and whether the
+	// result was recorded, a result that was already recorded by the same attempt is not recorded again.
+	RecordBenchmarkResult(id string, result api.EvaluationJobBenchmarkResult) (*api.EvaluationJobResource, bool, error)
+	// GetLeaderboard ranks the models of the evaluation jobs following the query, see
+	// aggregation.Leaderboard for the reference ranking.
+	GetLeaderboard(query *LeaderboardQuery) ([]api.LeaderboardEntry, error)
+
+	// The callback deliveries are created by the storage, in the same update, whenever the state of an
+	// evaluation job with a callback URL changes. They are deleted with their evaluation job.
+	GetCallbackDeliveries(query Query) (*api.CallbackDeliveryResourceList, error)
+	UpdateCallbackDelivery(delivery *api.CallbackDeliveryResource) error
+	// ClaimCallbackDeliveries returns the pending deliveries of all the tenants that are due at the
+	// given time and postpones them until the lease expires so that they are not claimed twice.
+	ClaimCallbackDeliveries(now time.Time, lease time.Duration, limit int) ([]api.CallbackDeliveryResource, error)
 
 	CreateCollection(collection *api.CollectionResource) error
 	GetCollection(id string) (*api.CollectionResource, error)
 	GetCollections(query Query) (*api.CollectionResourceList, error)
 	UpdateCollection(collection *api.CollectionResource) error
+	// PatchCollection applies the patch to the configuration of the stored collection and stores the
+	// result in the same update, so that concurrent patches are not lost. Nothing is stored when the
+	// patch returns an error, the error is returned as it is.
+	PatchCollection(id string, patch func(config *api.CollectionConfig) error) (*api.CollectionResource, error)
 	DeleteCollection(id string) error
+
+	// Close releases the resources held by the storage, it is shared by all the tenant views.
+	Close() error
 }
@@ -0,0 +1,18 @@
+package abstractions
+
+import (
+	"context"
+
+	"github.ibm.com/julpayne/eval-hub-backend-svc/pkg/api"
+)
+
+// ExperimentTracker records the evaluation jobs in an experiment tracking service (i.e. MLflow).
+// No other places in the code should be pointing directly to the tracking service.
+type ExperimentTracker interface {
+	// StartExperiment creates or reuses the experiment named in the job configuration and returns
+	// the URL of the experiment
+	StartExperiment(ctx context.Context, evaluation *api.EvaluationJobResource) (string, error)
+	// LogBenchmarkResult records a run of the benchmark in the experiment of the job, with the
+	// metrics of the result and the parameters of the benchmark configuration
+	LogBenchmarkResult(ctx context.Context, evaluation *api.EvaluationJobResource, result *api.EvaluationJobBenchmarkResult) error
+}
@@ -0,0 +1,249 @@
+// Package aggregation rolls the per-benchmark results of an evaluation job up into job-level
+// counters and metric aggregates.
+package aggregation
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"slices"
+	"time"
+
+	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/abstractions"
+	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/state_machine"
+	"github.ibm.com/julpayne/eval-hub-backend-svc/pkg/api"
+)
+
+// SampleCountMetric is the metric holding the number of samples a benchmark was evaluated on. When
+// every benchmark reporting a metric also reports its sample count the mean of the metric is
+// weighted by it, the sample count itself is not aggregated.
+const SampleCountMetric = "num_samples"
+
+// FlattenMetrics returns the numeric metrics, nested metrics are flattened with dots, booleans are
+// converted to 0 or 1 and the other values are skipped
+func FlattenMetrics(metrics map[string]any) map[string]float64 {
+	flat := map[string]float64{}
+	var flatten func(prefix string, values map[string]any)
+	flatten = func(prefix string, values map[string]any) {
+		for key, value := range values {
+			if nested, ok := value.(map[string]any); ok {
+				flatten(prefix+key+".", nested)
+				continue
+			}
+			if number, ok := toFloat(value); ok {
+				flat[prefix+key] = number
+			}
+		}
+	}
+	flatten("", metrics)
+	return flat
+}
+
+// AggregateMetrics computes the mean, the minimum and the maximum of every metric reported by the
+// completed benchmarks
+func AggregateMetrics(results []api.EvaluationJobBenchmarkResult) map[string]api.MetricAggregate {
+	type accumulator struct {
+		aggregate   api.MetricAggregate
+		sum         float64
+		weightedSum float64
+		weighted    bool
+	}
+	accumulators := map[string]*accumulator{}
+	for _, result := range results {
+		if result.State != api.StateCompleted {
+			continue
+		}
+		metrics := FlattenMetrics(result.Metrics)
+		samples, hasSamples := metrics[SampleCountMetric]
+		hasSamples = hasSamples && (samples > 0)
+		for name, value := range metrics {
+			if (name == SampleCountMetric) || math.IsNaN(value) || math.IsInf(value, 0) {
+				continue
+			}
+			acc := accumulators[name]
+			if acc == nil {
+				acc = &accumulator{aggregate: api.MetricAggregate{Min: value, Max: value}, weighted: true}
+				accumulators[name] = acc
+			}
+			acc.aggregate.Benchmarks++
+			acc.aggregate.Min = min(acc.aggregate.Min, value)
+			acc.aggregate.Max = max(acc.aggregate.Max, value)
+			acc.sum += value
+			acc.weighted = acc.weighted && hasSamples
+			if hasSamples {
+				acc.weightedSum += value * samples
+				acc.aggregate.Samples += samples
+			}
+		}
+	}
+
+	aggregates := make(map[string]api.MetricAggregate, len(accumulators))
+	for name, acc := range accumulators {
+		aggregate := acc.aggregate
+		if acc.weighted {
+			aggregate.Mean = acc.weightedSum / aggregate.Samples
+		} else {
+			aggregate.Mean = acc.sum / float64(aggregate.Benchmarks)
+			aggregate.Samples = 0
+		}
+		aggregates[name] = aggregate
+	}
+	return aggregates
+}
+
+// Summarize builds the summary of the job from the states of its benchmarks and their results,
+// the durations are only reported for what has finished
+func Summarize(job *api.EvaluationJobResource) *api.EvaluationJobSummary {
+	summary := &api.EvaluationJobSummary{
+		ID:               job.ID,
+		Model:            job.Model,
+		State:            job.Status.State,
+		CreatedAt:        job.CreatedAt,
+		StartedAt:        job.Status.StartedAt,
+		CompletedAt:      job.Status.CompletedAt,
+		DurationSeconds:  duration(job.Status.StartedAt, job.Status.CompletedAt),
+		TotalEvaluations: len(job.Benchmarks),
+		Benchmarks:       []api.BenchmarkSummary{},
+	}
+
+	results := map[string]*api.EvaluationJobBenchmarkResult{}
+	if job.Results != nil {
+		for i := range job.Results.Benchmarks {
+			results[job.Results.Benchmarks[i].ID] = &job.Results.Benchmarks[i]
+		}
+	}
+	for _, status := range job.Status.Benchmarks {
+		benchmark := api.BenchmarkSummary{
+			ID:              status.Name,
+			State:           status.State,
+			StartedAt:       status.StartedAt,
+			CompletedAt:     status.CompletedAt,
+			DurationSeconds: duration(status.StartedAt, status.CompletedAt),
+		}
+		if result, found := results[status.Name]; found {
+			benchmark.Metrics = result.Metrics
+			benchmark.Error = result.Error
+		}
+		summary.Benchmarks = append(summary.Benchmarks, benchmark)
+	}
+	summary.CompletedEvaluations, summary.FailedEvaluations = countStates(job.Status.Benchmarks)
+	summary.TotalEvaluations = max(summary.TotalEvaluations, len(job.Status.Benchmarks))
+
+	summary.AggregatedMetrics = map[string]api.MetricAggregate{}
+	if job.Results != nil {
+		summary.AggregatedMetrics = AggregateMetrics(job.Results.Benchmarks)
+	}
+	return summary
+}
+
+// UpdateResults recomputes the counters and the aggregated metrics of the results of the job, the
+// benchmark results and the MLflow experiment URL are kept
+func UpdateResults(job *api.EvaluationJobResource) {
+	if job.Results == nil {
+		job.Results = &api.EvaluationJobResults{}
+	}
+	job.Results.TotalEvaluations = max(len(job.Benchmarks), len(job.Status.Benchmarks))
+	job.Results.CompletedEvaluations, job.Results.FailedEvaluations = countStates(job.Status.Benchmarks)
+	job.Results.AggregatedMetrics = nil
+	if aggregates := AggregateMetrics(job.Results.Benchmarks); len(aggregates) > 0 {
+		job.Results.AggregatedMetrics = make(map[string]any, len(aggregates))
+		for name, aggregate := range aggregates {
+			job.Results.AggregatedMetrics[name] = aggregate
+		}
+	}
+}
+
+// RecordResult stores the result of a benchmark with the job, moves the benchmark to the state of
+// the result and recomputes the results of the job. It returns false without changing the job when
+// the stored result of the benchmark was reported by the same attempt, a result reported by another
+// attempt is a conflict and a benchmark that is not part of the job is not found.
+func RecordResult(job *api.EvaluationJobResource, result api.EvaluationJobBenchmarkResult, now time.Time) (bool, error) {
+	if !slices.ContainsFunc(job.Benchmarks, func(benchmark api.BenchmarkConfig) bool { return benchmark.ID == result.ID }) {
+		return false, &abstractions.NotFoundError{Resource: abstractions.ResourceBenchmark, ID: job.ID + "/" + result.ID}
+	}
+	index := -1
+	if job.Results != nil {
+		index = slices.IndexFunc(job.Results.Benchmarks, func(stored api.EvaluationJobBenchmarkResult) bool { return stored.ID == result.ID })
+	}
+	if index >= 0 {
+		stored := job.Results.Benchmarks[index]
+		if stored.AttemptID == result.AttemptID {
+			return false, nil
+		}
+		return false, &abstractions.ConflictError{
+			Resource: abstractions.ResourceBenchmark,
+			ID:       job.ID + "/" + result.ID,
+			Reason:   fmt.Sprintf("the result was already reported by attempt %s", stored.AttemptID),
+		}
+	}
+
+	status := api.BenchmarkStatus{
+		Name:        result.ID,
+		State:       result.State,
+		StartedAt:   result.StartedAt,
+		CompletedAt: result.CompletedAt,
+		Message:     "Benchmark completed",
+	}
+	if result.Error != nil {
+		status.Message = *result.Error
+	}
+	if err := state_machine.ApplyBenchmarkStatus(job.ID, &job.Status, status, now); err != nil {
+		return false, err
+	}
+	// the result carries the timestamps of the status, which keeps the start reported by the runtime
+	for _, applied := range job.Status.Benchmarks {
+		if applied.Name == result.ID {
+			result.StartedAt, result.CompletedAt = applied.StartedAt, applied.CompletedAt
+		}
+	}
+
+	if job.Results == nil {
+		job.Results = &api.EvaluationJobResults{}
+	}
+	job.Results.Benchmarks = append(job.Results.Benchmarks, result)
+	UpdateResults(job)
+	return true, nil
+}
+
+func countStates(benchmarks []api.BenchmarkStatus) (completed int, failed int) {
+	for _, benchmark := range benchmarks {
+		switch benchmark.State {
+		case api.StateCompleted:
+			completed++
+		case api.StateFailed:
+			failed++
+		}
+	}
+	return completed, failed
+}
+
+func duration(startedAt *time.Time, completedAt *time.Time) *float64 {
+	if (startedAt == nil) || (completedAt == nil) {
+		return nil
+	}
+	seconds := completedAt.Sub(*startedAt).Seconds()
+	return &seconds
+}
+
+func toFloat(value any) (float64, bool) {
+	switch number := value.(type) {
+	case float64:
+		return number, true
+	case float32:
+		return float64(number), true
+	case int:
+		return float64(number), true
+	case int64:
+		return float64(number), true
+	case json.Number:
+		parsed, err := number.Float64()
+		return parsed, err == nil
+	case bool:
+		if number {
+			return 1, true
+		}
+		return 0, true
+	default:
+		return 0, false
+	}
+}
@@ -0,0 +1,156 @@
+package aggregation
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+	"time"
+
+	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/abstractions"
+	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/state_machine"
+	"github.ibm.com/julpayne/eval-hub-backend-svc/pkg/api"
+)
+
+func TestFlattenMetrics(t *testing.T) {
+	flat := FlattenMetrics(map[string]any{
+		"acc":      0.5,
+		"passed":   true,
+		"count":    json.Number("12"),
+		"label":    "ignored",
+		"subtasks": map[string]any{"algebra": map[string]any{"acc": 0.25}},
+	})
+	expected := map[string]float64{"acc": 0.5, "passed": 1, "count": 12, "subtasks.algebra.acc": 0.25}
+	if len(flat) != len(expected) {
+		t.Fatalf("Expected %v, got %v", expected, flat)
+	}
+	for key, value := range expected {
+		if flat[key] != value {
+			t.Errorf("Expected %s to be %v, got %v", key, value, flat[key])
+		}
+	}
+}
+
+func TestAggregateMetrics(t *testing.T) {
+	results := []api.EvaluationJobBenchmarkResult{
+		{ID: "mmlu", State: api.StateCompleted, Metrics: map[string]any{"acc": 0.8, "f1": 0.5, SampleCountMetric: 300.0}},
+		{ID: "arc", State: api.StateCompleted, Metrics: map[string]any{"acc": 0.4, "f1": 0.7, SampleCountMetric: 100.0}},
+		{ID: "gsm8k", State: api.StateCompleted, Metrics: map[string]any{"f1": 0.9}},
+		{ID: "failed", State: api.StateFailed, Metrics: map[string]any{"acc": 0.0}},
+		{ID: "nan", State: api.StateCompleted, Metrics: map[string]any{"loss": math.NaN()}},
+	}
+	aggregates := AggregateMetrics(results)
+
+	acc := aggregates["acc"]
+	if (math.Abs(acc.Mean-0.7) > 1e-9) || (acc.Min != 0.4) || (acc.Max != 0.8) || (acc.Benchmarks != 2) || (acc.Samples != 400) {
+		t.Errorf("Expected a mean weighted by the samples, got %+v", acc)
+	}
+	f1 := aggregates["f1"]
+	if (math.Abs(f1.Mean-0.7) > 1e-9) || (f1.Benchmarks != 3) || (f1.Samples != 0) {
+		t.Errorf("Expected a plain mean when a benchmark has no sample count, got %+v", f1)
+	}
+	if _, found := aggregates[SampleCountMetric]; found {
+		t.Error("Expected the sample count not to be aggregated")
+	}
+	if _, found := aggregates["loss"]; found {
+		t.Error("Expected the NaN values to be skipped")
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	at := func(seconds int) *time.Time {
+		value := start.Add(time.Duration(seconds) * time.Second)
+		return &value
+	}
+	failure := "out of memory"
+	job := &api.EvaluationJobResource{
+		Resource: api.Resource{ID: "job-1", CreatedAt: start},
+		EvaluationJobConfig: api.EvaluationJobConfig{
+			Benchmarks: []api.BenchmarkConfig{{Ref: api.Ref{ID: "mmlu"}}, {Ref: api.Ref{ID: "arc"}}, {Ref: api.Ref{ID: "gsm8k"}}},
+		},
+		Status: api.EvaluationJobStatus{
+			EvaluationJobState: api.EvaluationJobState{State: api.StateRunning},
+			StartedAt:          at(0),
+			Benchmarks: []api.BenchmarkStatus{
+				{Name: "mmlu", State: api.StateCompleted, StartedAt: at(0), CompletedAt: at(90)},
+				{Name: "arc", State: api.StateFailed, StartedAt: at(10), CompletedAt: at(20)},
+				{Name: "gsm8k", State: api.StateRunning, StartedAt: at(30)},
+			},
+		},
+		Results: &api.EvaluationJobResults{
+			Benchmarks: []api.EvaluationJobBenchmarkResult{
+				{ID: "mmlu", State: api.StateCompleted, Metrics: map[string]any{"acc": 0.8}},
+				{ID: "arc", State: api.StateFailed, Error: &failure},
+			},
+		},
+	}
+
+	summary := Summarize(job)
+	if (summary.TotalEvaluations != 3) || (summary.CompletedEvaluations != 1) || (summary.FailedEvaluations != 1) {
+		t.Errorf("Expected 1 completed and 1 failed of 3, got %+v", summary)
+	}
+	if summary.DurationSeconds != nil {
+		t.Errorf("Expected no duration for a running job, got %v", *summary.DurationSeconds)
+	}
+	if (len(summary.Benchmarks) != 3) || (*summary.Benchmarks[0].DurationSeconds != 90) || (summary.Benchmarks[0].Metrics["acc"] != 0.8) ||
+		(*summary.Benchmarks[1].Error != failure) || (summary.Benchmarks[2].DurationSeconds != nil) {
+		t.Errorf("Unexpected benchmark summaries %+v", summary.Benchmarks)
+	}
+	if summary.AggregatedMetrics["acc"].Mean != 0.8 {
+		t.Errorf("Expected the aggregated metrics, got %+v", summary.AggregatedMetrics)
+	}
+
+	UpdateResults(job)
+	if (job.Results.TotalEvaluations != 3) || (job.Results.CompletedEvaluations != 1) || (job.Results.FailedEvaluations != 1) || (len(job.Results.Benchmarks) != 2) {
+		t.Errorf("Expected the counters to be updated, got %+v", job.Results)
+	}
+	if aggregate, ok := job.Results.AggregatedMetrics["acc"].(api.MetricAggregate); !ok || (aggregate.Mean != 0.8) {
+		t.Errorf("Expected the aggregated metrics in the results, got %+v", job.Results.AggregatedMetrics)
+	}
+}
+
+func TestRecordResult(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	startedAt := now.Add(-time.Minute)
+	job := &api.EvaluationJobResource{
+		Resource: api.Resource{ID: "job-1"},
+		EvaluationJobConfig: api.EvaluationJobConfig{
+			Benchmarks: []api.BenchmarkConfig{{Ref: api.Ref{ID: "mmlu"}}, {Ref: api.Ref{ID: "arc"}}},
+		},
+		Status: api.EvaluationJobStatus{
+			EvaluationJobState: api.EvaluationJobState{State: api.StateRunning},
+			Benchmarks: []api.BenchmarkStatus{
+				{Name: "mmlu", State: api.StateRunning, StartedAt: &startedAt},
+				{Name: "arc", State: api.StateCancelled},
+			},
+		},
+	}
+
+	recorded, err := RecordResult(job, api.EvaluationJobBenchmarkResult{ID: "mmlu", State: api.StateCompleted, AttemptID: "a1", Metrics: map[string]any{"acc": 0.5}}, now)
+	if err != nil || !recorded {
+		t.Fatalf("Expected the result to be recorded, got %v", err)
+	}
+	result := job.Results.Benchmarks[0]
+	if !result.StartedAt.Equal(startedAt) || !result.CompletedAt.Equal(now) || (job.Status.Benchmarks[0].State != api.StateCompleted) {
+		t.Errorf("Expected the result to carry the timestamps of the benchmark, got %+v", result)
+	}
+	if (job.Status.State != api.StateCancelled) || (job.Results.CompletedEvaluations != 1) || (job.Results.AggregatedMetrics["acc"] == nil) {
+		t.Errorf("Expected the job results to be recomputed, got %+v", job.Results)
+	}
+
+	if recorded, err := RecordResult(job, api.EvaluationJobBenchmarkResult{ID: "mmlu", State: api.StateFailed, AttemptID: "a1"}, now); err != nil || recorded {
+		t.Errorf("Expected the same attempt to be ignored, got %v, %v", recorded, err)
+	}
+	if _, err := RecordResult(job, api.EvaluationJobBenchmarkResult{ID: "mmlu", State: api.StateCompleted, AttemptID: "a2"}, now); !abstractions.IsConflict(err) {
+		t.Errorf("Expected a conflict for another attempt, got %v", err)
+	}
+	if _, err := RecordResult(job, api.EvaluationJobBenchmarkResult{ID: "arc", State: api.StateCompleted, AttemptID: "b1"}, now); !state_machine.IsTransitionError(err) {
+		t.Errorf("Expected a transition error for a cancelled benchmark, got %v", err)
+	}
+	if _, err := RecordResult(job, api.EvaluationJobBenchmarkResult{ID: "gsm8k", State: api.StateCompleted, AttemptID: "c1"}, now); !abstractions.IsNotFound(err) {
+		t.Errorf("Expected a not found error for a benchmark of another job, got %v", err)
+	}
+	if len(job.Results.Benchmarks) != 1 {
+		t.Errorf("Expected only the first result to be recorded, got %+v", job.Results.Benchmarks)
+	}
+}
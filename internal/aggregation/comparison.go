@@ -0,0 +1,93 @@
+package aggregation
+
+import (
+	"math"
+	"slices"
+
+	"github.ibm.com/julpayne/eval-hub-backend-svc/pkg/api"
+)
+
+// Compare aligns the numeric metrics of the completed benchmarks of the jobs by benchmark ID and
+// metric name and computes the deltas against the baseline job. The benchmarks are listed in the
+// order the jobs define them and the metrics by name, a benchmark without a completed result in
+// some jobs is flagged as missing from them.
+func Compare(jobs []*api.EvaluationJobResource, baseline string) *api.EvaluationComparison {
+	comparison := &api.EvaluationComparison{
+		Baseline:   baseline,
+		Jobs:       make([]api.ComparedJob, 0, len(jobs)),
+		Benchmarks: []api.BenchmarkComparison{},
+	}
+
+	benchmarkIDs := []string{}
+	addBenchmark := func(id string) {
+		if !slices.Contains(benchmarkIDs, id) {
+			benchmarkIDs = append(benchmarkIDs, id)
+		}
+	}
+	// metrics holds the flattened metrics of the completed benchmarks by job and benchmark
+	metrics := make(map[string]map[string]map[string]float64, len(jobs))
+	for _, job := range jobs {
+		comparison.Jobs = append(comparison.Jobs, api.ComparedJob{ID: job.ID, Model: job.Model, State: job.Status.State, CreatedAt: job.CreatedAt})
+		for _, benchmark := range job.Benchmarks {
+			addBenchmark(benchmark.ID)
+		}
+		metrics[job.ID] = map[string]map[string]float64{}
+		if job.Results == nil {
+			continue
+		}
+		for _, result := range job.Results.Benchmarks {
+			addBenchmark(result.ID)
+			if result.State == api.StateCompleted {
+				metrics[job.ID][result.ID] = finiteMetrics(result.Metrics)
+			}
+		}
+	}
+
+	for _, benchmarkID := range benchmarkIDs {
+		benchmark := api.BenchmarkComparison{ID: benchmarkID, Metrics: []api.MetricComparison{}}
+		names := []string{}
+		for _, job := range jobs {
+			values, found := metrics[job.ID][benchmarkID]
+			if !found {
+				benchmark.MissingFrom = append(benchmark.MissingFrom, job.ID)
+				continue
+			}
+			for name := range values {
+				if !slices.Contains(names, name) {
+					names = append(names, name)
+				}
+			}
+		}
+		slices.Sort(names)
+
+		for _, name := range names {
+			metric := api.MetricComparison{Name: name, Values: map[string]float64{}}
+			for _, job := range jobs {
+				if value, found := metrics[job.ID][benchmarkID][name]; found {
+					metric.Values[job.ID] = value
+				}
+			}
+			if base, found := metric.Values[baseline]; found {
+				metric.Deltas = make(map[string]float64, len(metric.Values))
+				for jobID, value := range metric.Values {
+					metric.Deltas[jobID] = value - base
+				}
+			}
+			benchmark.Metrics = append(benchmark.Metrics, metric)
+		}
+		comparison.Benchmarks = append(comparison.Benchmarks, benchmark)
+	}
+	return comparison
+}
+
+// finiteMetrics returns the flattened numeric metrics without the NaN and infinite values, which
+// can not be compared
+func finiteMetrics(values map[string]any) map[string]float64 {
+	metrics := FlattenMetrics(values)
+	for name, value := range metrics {
+		if math.IsNaN(value) || math.IsInf(value, 0) {
+			delete(metrics, name)
+		}
+	}
+	return metrics
+}
@@ -0,0 +1,57 @@
+package aggregation
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.ibm.com/julpayne/eval-hub-backend-svc/pkg/api"
+)
+
+func TestCompare(t *testing.T) {
+	newJob := func(id string, benchmarks []string, results ...api.EvaluationJobBenchmarkResult) *api.EvaluationJobResource {
+		job := &api.EvaluationJobResource{
+			Resource:            api.Resource{ID: id},
+			EvaluationJobConfig: api.EvaluationJobConfig{Model: api.ModelRef{Name: "model-" + id}},
+			Results:             &api.EvaluationJobResults{Benchmarks: results},
+		}
+		for _, benchmark := range benchmarks {
+			job.Benchmarks = append(job.Benchmarks, api.BenchmarkConfig{Ref: api.Ref{ID: benchmark}})
+		}
+		return job
+	}
+	jobs := []*api.EvaluationJobResource{
+		newJob("a", []string{"mmlu", "arc"},
+			api.EvaluationJobBenchmarkResult{ID: "mmlu", State: api.StateCompleted, Metrics: map[string]any{"acc": 0.5, "f1": 0.25}},
+			api.EvaluationJobBenchmarkResult{ID: "arc", State: api.StateCompleted, Metrics: map[string]any{"acc": 0.5}}),
+		newJob("b", []string{"mmlu", "gsm8k"},
+			api.EvaluationJobBenchmarkResult{ID: "mmlu", State: api.StateCompleted, Metrics: map[string]any{"acc": 0.75, "loss": math.Inf(1)}},
+			api.EvaluationJobBenchmarkResult{ID: "gsm8k", State: api.StateFailed, Metrics: map[string]any{"acc": 0.1}}),
+	}
+
+	comparison := Compare(jobs, "b")
+	if (comparison.Baseline != "b") || (len(comparison.Jobs) != 2) || (comparison.Jobs[1].Model.Name != "model-b") {
+		t.Errorf("Unexpected compared jobs %+v", comparison.Jobs)
+	}
+	ids := []string{}
+	for _, benchmark := range comparison.Benchmarks {
+		ids = append(ids, benchmark.ID+":"+strings.Join(benchmark.MissingFrom, "+"))
+	}
+	if strings.Join(ids, ",") != "mmlu:,arc:b,gsm8k:a+b" {
+		t.Fatalf("Expected the benchmarks in order with the jobs missing them, got %v", ids)
+	}
+
+	mmlu := comparison.Benchmarks[0]
+	if (len(mmlu.Metrics) != 2) || (mmlu.Metrics[0].Name != "acc") || (mmlu.Metrics[1].Name != "f1") {
+		t.Fatalf("Expected the finite metrics sorted by name, got %+v", mmlu.Metrics)
+	}
+	if acc := mmlu.Metrics[0]; (acc.Values["a"] != 0.5) || (acc.Deltas["a"] != -0.25) || (acc.Deltas["b"] != 0) {
+		t.Errorf("Expected the deltas against the baseline, got %+v", acc)
+	}
+	if f1 := mmlu.Metrics[1]; (len(f1.Values) != 1) || (f1.Deltas != nil) {
+		t.Errorf("Expected no deltas when the baseline did not report the metric, got %+v", f1)
+	}
+	if arc := comparison.Benchmarks[1]; (len(arc.Metrics) != 1) || (arc.Metrics[0].Deltas != nil) {
+		t.Errorf("Expected no deltas for a benchmark missing from the baseline, got %+v", arc.Metrics)
+	}
+}
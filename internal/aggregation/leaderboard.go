@@ -0,0 +1,157 @@
+package aggregation
+
+import (
+	"cmp"
+	"encoding/json"
+	"slices"
+	"strings"
+	"time"
+
+	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/abstractions"
+	"github.ibm.com/julpayne/eval-hub-backend-svc/pkg/api"
+)
+
+// Leaderboard ranks the models of the jobs following the query, which must be valid. It is the
+// reference for the storage implementations that rank the models in their own query language.
+func Leaderboard(jobs []*api.EvaluationJobResource, query *abstractions.LeaderboardQuery) []api.LeaderboardEntry {
+	type model struct {
+		entry       api.LeaderboardEntry
+		evaluations int
+	}
+	models := map[api.ModelRef]*model{}
+	for _, job := range jobs {
+		entry, scored := leaderboardScore(job, query)
+		if !scored {
+			continue
+		}
+		current := models[job.Model]
+		if current == nil {
+			models[job.Model] = &model{entry: *entry, evaluations: 1}
+			continue
+		}
+		current.evaluations++
+		if compareScoresOfModel(entry, &current.entry, query) < 0 {
+			current.entry = *entry
+		}
+	}
+
+	entries := make([]api.LeaderboardEntry, 0, len(models))
+	for _, model := range models {
+		model.entry.Evaluations = model.evaluations
+		entries = append(entries, model.entry)
+	}
+	slices.SortFunc(entries, func(a, b api.LeaderboardEntry) int {
+		return compareRanks(&a, &b, query)
+	})
+	if len(entries) > query.Limit {
+		entries = entries[:query.Limit]
+	}
+	for i := range entries {
+		entries[i].Rank = i + 1
+	}
+	return entries
+}
+
+// MetricValue returns the numeric value of the metric, a nested metric is looked up by its dotted
+// name when no metric has that exact name
+func MetricValue(metrics map[string]any, name string) (float64, bool) {
+	if value, found := metrics[name]; found {
+		return toNumber(value)
+	}
+	var value any = metrics
+	for _, key := range strings.Split(name, ".") {
+		nested, ok := value.(map[string]any)
+		if !ok {
+			return 0, false
+		}
+		if value, ok = nested[key]; !ok {
+			return 0, false
+		}
+	}
+	return toNumber(value)
+}
+
+// leaderboardScore returns the score of the job, a job is only scored when it is complete enough
+// and it completed every benchmark of the query with the metric
+func leaderboardScore(job *api.EvaluationJobResource, query *abstractions.LeaderboardQuery) (*api.LeaderboardEntry, bool) {
+	if (job.Results == nil) || (job.Results.TotalEvaluations == 0) {
+		return nil, false
+	}
+	completeness := float64(job.Results.CompletedEvaluations) / float64(job.Results.TotalEvaluations)
+	if completeness < query.MinCompleteness {
+		return nil, false
+	}
+
+	sum := 0.0
+	var completedAt time.Time
+	for _, benchmark := range query.Benchmarks {
+		index := slices.IndexFunc(job.Results.Benchmarks, func(result api.EvaluationJobBenchmarkResult) bool {
+			return (result.ID == benchmark) && (result.State == api.StateCompleted) && (result.CompletedAt != nil)
+		})
+		if index < 0 {
+			return nil, false
+		}
+		result := job.Results.Benchmarks[index]
+		value, found := MetricValue(result.Metrics, query.Metric)
+		if !found {
+			return nil, false
+		}
+		sum += value
+		if result.CompletedAt.After(completedAt) {
+			completedAt = *result.CompletedAt
+		}
+	}
+	return &api.LeaderboardEntry{
+		Model:        job.Model,
+		Score:        sum / float64(len(query.Benchmarks)),
+		JobID:        job.ID,
+		CompletedAt:  completedAt.UTC(),
+		Completeness: completeness,
+	}, true
+}
+
+// compareScoresOfModel orders the scores of the same model, the first one is ranked: the latest or
+// the best score following the mode of the query, and then the lowest job ID
+func compareScoresOfModel(a, b *api.LeaderboardEntry, query *abstractions.LeaderboardQuery) int {
+	var order int
+	if query.Mode == abstractions.LeaderboardLatest {
+		order = cmp.Or(b.CompletedAt.Compare(a.CompletedAt), compareScores(a, b, query))
+	} else {
+		order = cmp.Or(compareScores(a, b, query), compareCompletion(a, b, query))
+	}
+	return cmp.Or(order, cmp.Compare(a.JobID, b.JobID))
+}
+
+// compareRanks orders the models by score, completion time and then by name and URL
+func compareRanks(a, b *api.LeaderboardEntry, query *abstractions.LeaderboardQuery) int {
+	return cmp.Or(
+		compareScores(a, b, query),
+		compareCompletion(a, b, query),
+		cmp.Compare(a.Model.Name, b.Model.Name),
+		cmp.Compare(a.Model.URL, b.Model.URL),
+	)
+}
+
+func compareScores(a, b *api.LeaderboardEntry, query *abstractions.LeaderboardQuery) int {
+	if query.Ascending {
+		return cmp.Compare(a.Score, b.Score)
+	}
+	return cmp.Compare(b.Score, a.Score)
+}
+
+func compareCompletion(a, b *api.LeaderboardEntry, query *abstractions.LeaderboardQuery) int {
+	if query.TieBreak == abstractions.LeaderboardMostRecent {
+		return b.CompletedAt.Compare(a.CompletedAt)
+	}
+	return a.CompletedAt.Compare(b.CompletedAt)
+}
+
+// toNumber converts the numbers of the decoded metrics, unlike toFloat it does not convert booleans
+func toNumber(value any) (float64, bool) {
+	switch value.(type) {
+	case float64, float32, int, int64, json.Number:
+		return toFloat(value)
+	default:
+		return 0, false
+	}
+}
@@ -0,0 +1,104 @@
+package aggregation
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/abstractions"
+	"github.ibm.com/julpayne/eval-hub-backend-svc/pkg/api"
+)
+
+func TestLeaderboard(t *testing.T) {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	// newJob scores the model on mmlu and arc, completing the benchmarks after the given minutes
+	newJob := func(id string, model string, minutes int, mmlu float64, arc any) *api.EvaluationJobResource {
+		completedAt := start.Add(time.Duration(minutes) * time.Minute)
+		job := &api.EvaluationJobResource{
+			Resource:            api.Resource{ID: id},
+			EvaluationJobConfig: api.EvaluationJobConfig{Model: api.ModelRef{Name: model, URL: "http://" + model}},
+			Results: &api.EvaluationJobResults{
+				TotalEvaluations: 2,
+				Benchmarks: []api.EvaluationJobBenchmarkResult{
+					{ID: "mmlu", State: api.StateCompleted, CompletedAt: &completedAt, Metrics: map[string]any{"acc": mmlu, "nested": map[string]any{"acc": mmlu}}},
+				},
+			},
+		}
+		job.Results.CompletedEvaluations = 1
+		if arc != nil {
+			job.Results.Benchmarks = append(job.Results.Benchmarks, api.EvaluationJobBenchmarkResult{ID: "arc", State: api.StateCompleted, CompletedAt: &completedAt, Metrics: map[string]any{"acc": arc}})
+			job.Results.CompletedEvaluations = 2
+		}
+		return job
+	}
+	jobs := []*api.EvaluationJobResource{
+		newJob("granite-1", "granite", 1, 0.9, 0.5),
+		newJob("granite-2", "granite", 5, 0.6, 0.6),
+		newJob("llama-1", "llama", 2, 0.7, nil),
+		newJob("mistral-1", "mistral", 3, 0.7, 0.9),
+		newJob("phi-1", "phi", 4, 0.5, "not a number"),
+	}
+	ranking := func(entries []api.LeaderboardEntry) string {
+		ranked := []string{}
+		for _, entry := range entries {
+			ranked = append(ranked, fmt.Sprintf("%d:%s=%g", entry.Rank, entry.JobID, entry.Score))
+		}
+		return strings.Join(ranked, ",")
+	}
+
+	testCases := []struct {
+		name     string
+		query    abstractions.LeaderboardQuery
+		expected string
+	}{
+		{"latest score of every model", abstractions.LeaderboardQuery{Benchmarks: []string{"mmlu"}, Metric: "acc"},
+			"1:llama-1=0.7,2:mistral-1=0.7,3:granite-2=0.6,4:phi-1=0.5"},
+		{"best score of every model", abstractions.LeaderboardQuery{Benchmarks: []string{"mmlu"}, Metric: "acc", Mode: abstractions.LeaderboardBest},
+			"1:granite-1=0.9,2:llama-1=0.7,3:mistral-1=0.7,4:phi-1=0.5"},
+		{"most recent first on ties", abstractions.LeaderboardQuery{Benchmarks: []string{"mmlu"}, Metric: "nested.acc", TieBreak: abstractions.LeaderboardMostRecent},
+			"1:mistral-1=0.7,2:llama-1=0.7,3:granite-2=0.6,4:phi-1=0.5"},
+		{"lower is better", abstractions.LeaderboardQuery{Benchmarks: []string{"mmlu"}, Metric: "acc", Mode: abstractions.LeaderboardBest, Ascending: true, Limit: 2},
+			"1:phi-1=0.5,2:granite-2=0.6"},
+		{"mean over a collection", abstractions.LeaderboardQuery{Benchmarks: []string{"mmlu", "arc"}, Metric: "acc"},
+			"1:mistral-1=0.8,2:granite-2=0.6"},
+		{"minimum completeness", abstractions.LeaderboardQuery{Benchmarks: []string{"mmlu"}, Metric: "acc", MinCompleteness: 1},
+			"1:mistral-1=0.7,2:granite-2=0.6,3:phi-1=0.5"},
+		{"unknown metric", abstractions.LeaderboardQuery{Benchmarks: []string{"mmlu"}, Metric: "f1"}, ""},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			query := tc.query
+			if query.Limit == 0 {
+				query.Limit = 10
+			}
+			if err := query.Validate(); err != nil {
+				t.Fatalf("Validate() returned error: %v", err)
+			}
+			if got := ranking(Leaderboard(jobs, &query)); got != tc.expected {
+				t.Errorf("Expected %s, got %s", tc.expected, got)
+			}
+		})
+	}
+
+	query := abstractions.LeaderboardQuery{Benchmarks: []string{"mmlu"}, Metric: "acc", Limit: 10}
+	query.Validate()
+	granite := Leaderboard(jobs, &query)[2]
+	if (granite.Evaluations != 2) || (granite.Completeness != 1) || !granite.CompletedAt.Equal(start.Add(5*time.Minute)) || (granite.Model.URL != "http://granite") {
+		t.Errorf("Unexpected entry %+v", granite)
+	}
+}
+
+func TestMetricValue(t *testing.T) {
+	metrics := map[string]any{"acc": 0.5, "a.b": 0.25, "a": map[string]any{"c": 0.75}, "passed": true}
+	for name, expected := range map[string]float64{"acc": 0.5, "a.b": 0.25, "a.c": 0.75} {
+		if value, found := MetricValue(metrics, name); !found || (value != expected) {
+			t.Errorf("Expected %s to be %v, got %v", name, expected, value)
+		}
+	}
+	for _, name := range []string{"passed", "a", "a.d", "acc.x", "missing"} {
+		if _, found := MetricValue(metrics, name); found {
+			t.Errorf("Expected %s not to be a numeric metric", name)
+		}
+	}
+}
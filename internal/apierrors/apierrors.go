@@ -0,0 +1,233 @@
+// Package apierrors defines the typed errors of the API and renders them as error responses. Every
+// error has a stable code that clients can rely on and that maps to its HTTP status, the responses
+// are api.Error documents, HTTPValidationError documents for the validation errors, or RFC 7807
+// problem details when the client accepts application/problem+json.
+package apierrors
+
+import (
+	"encoding/json"
+	"errors"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/abstractions"
+	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/logging"
+	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/state_machine"
+	"github.ibm.com/julpayne/eval-hub-backend-svc/pkg/api"
+)
+
+// Code identifies the kind of an error, the codes are part of the API and must not change
+type Code string
+
+const (
+	CodeBadRequest       Code = "bad_request"
+	CodeUnauthorized     Code = "unauthorized"
+	CodeForbidden        Code = "forbidden"
+	CodeNotFound         Code = "not_found"
+	CodeMethodNotAllowed Code = "method_not_allowed"
+	CodeNotAcceptable    Code = "not_acceptable"
+	CodeConflict         Code = "conflict"
+	CodeValidation       Code = "validation_error"
+	CodeInternal         Code = "internal_error"
+	CodeUnavailable      Code = "unavailable"
+)
+
+var codeStatus = map[Code]int{
+	CodeBadRequest:       http.StatusBadRequest,
+	CodeUnauthorized:     http.StatusUnauthorized,
+	CodeForbidden:        http.StatusForbidden,
+	CodeNotFound:         http.StatusNotFound,
+	CodeMethodNotAllowed: http.StatusMethodNotAllowed,
+	CodeNotAcceptable:    http.StatusNotAcceptable,
+	CodeConflict:         http.StatusConflict,
+	CodeValidation:       http.StatusUnprocessableEntity,
+	CodeInternal:         http.StatusInternalServerError,
+	CodeUnavailable:      http.StatusServiceUnavailable,
+}
+
+// ProblemContentType is the media type of the RFC 7807 problem details
+const ProblemContentType = "application/problem+json"
+
+// problemTypePrefix prefixes the code of an error to build the type URI of its problem details
+const problemTypePrefix = "urn:eval-hub:error:"
+
+// The types of the field errors
+const (
+	TypeMissing = "missing"
+	TypeInvalid = "value_error"
+	// TypeType is the error of a value of another JSON type
+	TypeType = "type_error"
+	// TypeExtra is the error of a field that is not defined
+	TypeExtra = "extra_forbidden"
+	TypeEnum  = "enum"
+)
+
+// Error is an error of the API with the code that selects its HTTP status
+type Error struct {
+	Code    Code
+	Message string
+	// Details are the field errors of a validation error
+	Details []api.ValidationError
+	// Err is the error that caused it, if any
+	Err error
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Status returns the HTTP status of the code of the error
+func (e *Error) Status() int {
+	if status, found := codeStatus[e.Code]; found {
+		return status
+	}
+	return http.StatusInternalServerError
+}
+
+// New returns an error with the code and the message
+func New(code Code, message string) *Error {
+	return &Error{Code: code, Message: message}
+}
+
+func BadRequest(message string) *Error {
+	return New(CodeBadRequest, message)
+}
+
+func Unauthorized(message string) *Error {
+	return New(CodeUnauthorized, message)
+}
+
+func Forbidden(message string) *Error {
+	return New(CodeForbidden, message)
+}
+
+func NotFound(message string) *Error {
+	return New(CodeNotFound, message)
+}
+
+// MethodNotAllowed returns the error of a method that the route does not support
+func MethodNotAllowed(method string) *Error {
+	return New(CodeMethodNotAllowed, "method "+method+" is not allowed")
+}
+
+func NotAcceptable(message string) *Error {
+	return New(CodeNotAcceptable, message)
+}
+
+func Conflict(message string) *Error {
+	return New(CodeConflict, message)
+}
+
+// Internal returns the error of an unexpected failure
+func Internal(err error) *Error {
+	return &Error{Code: CodeInternal, Message: err.Error(), Err: err}
+}
+
+// Unavailable returns the error of a dependency that can not be reached, the request may succeed
+// when it is retried
+func Unavailable(message string) *Error {
+	return New(CodeUnavailable, message)
+}
+
+// Invalid returns a validation error of the field at the location, the location starts with body,
+// path or query and is followed by the names of the fields and the indexes of the items
+func Invalid(message string, loc ...any) *Error {
+	return Validation(api.ValidationError{Loc: loc, Msg: message, Type: TypeInvalid})
+}
+
+// Validation returns a validation error with the field errors
+func Validation(details ...api.ValidationError) *Error {
+	message := "the request is not valid"
+	if len(details) == 1 {
+		message = details[0].Msg
+	} else if len(details) > 1 {
+		messages := []string{}
+		for _, detail := range details {
+			messages = append(messages, detail.Msg)
+		}
+		message = strings.Join(messages, "; ")
+	}
+	return &Error{Code: CodeValidation, Message: message, Details: details}
+}
+
+// AsValidation returns the error as a validation error, an error without field details is reported
+// for the whole body
+func AsValidation(err error) *Error {
+	var apiErr *Error
+	if errors.As(err, &apiErr) && (apiErr.Code == CodeValidation) {
+		return apiErr
+	}
+	return &Error{Code: CodeValidation, Message: err.Error(), Err: err,
+		Details: []api.ValidationError{{Loc: []any{"body"}, Msg: err.Error(), Type: TypeInvalid}}}
+}
+
+// From returns the API error of the error, the typed storage errors are mapped to not found and
+// conflict and the other errors are internal
+func From(err error) *Error {
+	var apiErr *Error
+	switch {
+	case errors.As(err, &apiErr):
+		return apiErr
+	case abstractions.IsNotFound(err):
+		return &Error{Code: CodeNotFound, Message: err.Error(), Err: err}
+	case abstractions.IsConflict(err), state_machine.IsTransitionError(err):
+		return &Error{Code: CodeConflict, Message: err.Error(), Err: err}
+	default:
+		return Internal(err)
+	}
+}
+
+// Write writes the error response of the error with the ID of the request, as problem details when
+// the client accepts them
+func Write(w http.ResponseWriter, r *http.Request, err error) {
+	apiErr := From(err)
+	status := apiErr.Status()
+	requestID := r.Header.Get(logging.RequestIDHeader)
+
+	var body any
+	contentType := "application/json"
+	switch {
+	case AcceptsProblem(r):
+		contentType = ProblemContentType
+		body = api.ProblemError{
+			Type:      problemTypePrefix + string(apiErr.Code),
+			Title:     http.StatusText(status),
+			Status:    status,
+			Detail:    apiErr.Message,
+			Instance:  r.URL.Path,
+			Code:      string(apiErr.Code),
+			RequestID: requestID,
+			Errors:    apiErr.Details,
+		}
+	case apiErr.Code == CodeValidation:
+		body = api.HTTPValidationError{Detail: apiErr.Details, Code: string(apiErr.Code), RequestID: requestID}
+	default:
+		body = api.Error{Detail: apiErr.Message, Code: string(apiErr.Code), RequestID: requestID}
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+// AcceptsProblem reports whether the Accept header of the request lists the problem details
+func AcceptsProblem(r *http.Request) bool {
+	for _, mediaRange := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(mediaRange))
+		if (err != nil) || (mediaType != ProblemContentType) {
+			continue
+		}
+		if q, found := params["q"]; found {
+			if quality, err := strconv.ParseFloat(q, 64); (err != nil) || (quality <= 0) {
+				continue
+			}
+		}
+		return true
+	}
+	return false
+}
@@ -0,0 +1,122 @@
+package apierrors
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/abstractions"
+	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/logging"
+	"github.ibm.com/julpayne/eval-hub-backend-svc/pkg/api"
+)
+
+func TestFrom(t *testing.T) {
+	testCases := []struct {
+		name   string
+		err    error
+		code   Code
+		status int
+	}{
+		{"typed error", Forbidden("no"), CodeForbidden, http.StatusForbidden},
+		{"wrapped typed error", fmt.Errorf("reading: %w", Unavailable("down")), CodeUnavailable, http.StatusServiceUnavailable},
+		{"storage not found", &abstractions.NotFoundError{Resource: abstractions.ResourceEvaluationJob, ID: "1"}, CodeNotFound, http.StatusNotFound},
+		{"storage conflict", &abstractions.ConflictError{Resource: abstractions.ResourceEvaluationJob, ID: "1"}, CodeConflict, http.StatusConflict},
+		{"validation", Invalid("is required", "body", "name"), CodeValidation, http.StatusUnprocessableEntity},
+		{"untyped error", fmt.Errorf("boom"), CodeInternal, http.StatusInternalServerError},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			apiErr := From(tc.err)
+			if (apiErr.Code != tc.code) || (apiErr.Status() != tc.status) {
+				t.Errorf("Expected %s with status %d, got %s with status %d", tc.code, tc.status, apiErr.Code, apiErr.Status())
+			}
+		})
+	}
+}
+
+func TestAsValidation(t *testing.T) {
+	apiErr := AsValidation(fmt.Errorf("name is required"))
+	if (len(apiErr.Details) != 1) || (apiErr.Details[0].Loc[0] != "body") || (apiErr.Details[0].Msg != "name is required") {
+		t.Errorf("Expected the error to be reported for the body, got %+v", apiErr.Details)
+	}
+	invalid := Invalid("must be at least 1", "body", "benchmarks", 0, "limit")
+	if AsValidation(invalid) != invalid {
+		t.Error("Expected a validation error to keep its details")
+	}
+}
+
+func TestWrite(t *testing.T) {
+	write := func(accept string, err error) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/evaluations/jobs", nil)
+		req.Header.Set(logging.RequestIDHeader, "req-1")
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		w := httptest.NewRecorder()
+		Write(w, req, err)
+		return w
+	}
+
+	t.Run("api.Error", func(t *testing.T) {
+		w := write("", NotFound("evaluation job 1 not found"))
+		var response api.Error
+		json.Unmarshal(w.Body.Bytes(), &response)
+		if (w.Code != http.StatusNotFound) || (w.Header().Get("Content-Type") != "application/json") {
+			t.Fatalf("Expected a JSON 404, got %d %s", w.Code, w.Header().Get("Content-Type"))
+		}
+		if (response.Detail != "evaluation job 1 not found") || (response.Code != "not_found") || (response.RequestID != "req-1") {
+			t.Errorf("Unexpected error response %s", w.Body.String())
+		}
+	})
+
+	t.Run("HTTPValidationError", func(t *testing.T) {
+		w := write("application/json", Invalid("benchmarks[0].limit must be at least 1", "body", "benchmarks", 0, "limit"))
+		var response api.HTTPValidationError
+		json.Unmarshal(w.Body.Bytes(), &response)
+		if (w.Code != http.StatusUnprocessableEntity) || (len(response.Detail) != 1) || (response.RequestID != "req-1") {
+			t.Fatalf("Expected a validation error, got %d: %s", w.Code, w.Body.String())
+		}
+		if detail := response.Detail[0]; (len(detail.Loc) != 4) || (detail.Loc[2] != float64(0)) || (detail.Type != TypeInvalid) {
+			t.Errorf("Expected the location of the field, got %+v", detail)
+		}
+	})
+
+	t.Run("problem details", func(t *testing.T) {
+		w := write("application/problem+json, application/json;q=0.5", Invalid("name is required", "body", "name"))
+		var response api.ProblemError
+		json.Unmarshal(w.Body.Bytes(), &response)
+		if (w.Code != http.StatusUnprocessableEntity) || (w.Header().Get("Content-Type") != ProblemContentType) {
+			t.Fatalf("Expected a problem details 422, got %d %s", w.Code, w.Header().Get("Content-Type"))
+		}
+		if (response.Status != w.Code) || (response.Type != "urn:eval-hub:error:validation_error") || (response.Instance != "/api/v1/evaluations/jobs") ||
+			(response.RequestID != "req-1") || (len(response.Errors) != 1) {
+			t.Errorf("Unexpected problem details %s", w.Body.String())
+		}
+	})
+
+	t.Run("internal errors", func(t *testing.T) {
+		if w := write("", fmt.Errorf("boom")); w.Code != http.StatusInternalServerError {
+			t.Errorf("Expected status %d, got %d", http.StatusInternalServerError, w.Code)
+		}
+	})
+}
+
+func TestAcceptsProblem(t *testing.T) {
+	testCases := map[string]bool{
+		"":                         false,
+		"application/json":         false,
+		"*/*":                      false,
+		"application/problem+json": true,
+		"application/json, application/problem+json;q=0.1": true,
+		"application/problem+json;q=0":                     false,
+	}
+	for accept, expected := range testCases {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Accept", accept)
+		if AcceptsProblem(req) != expected {
+			t.Errorf("Expected AcceptsProblem(%q) to be %v", accept, expected)
+		}
+	}
+}
@@ -0,0 +1,188 @@
+// Package auth authenticates the requests with a bearer JWT validated against a JWKS, an API key
+// or the runner token, and stores the authenticated principal in the context of the request.
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/apierrors"
+	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/config"
+
+	"sigs.k8s.io/yaml"
+)
+
+// The methods a principal is authenticated with
+const (
+	MethodJWT         = "jwt"
+	MethodAPIKey      = "api_key"
+	MethodRunnerToken = "runner_token"
+)
+
+// APIKeyHeader holds the API key, an API key can also be sent as a bearer token
+const APIKeyHeader = "X-API-Key"
+
+// RunnerSubject is the subject of the principal authenticated with the runner token
+const RunnerSubject = "runner"
+
+// Principal is the authenticated caller of a request
+type Principal struct {
+	// Subject is the sub claim of a JWT or the name of an API key
+	Subject string
+	Method  string
+	// Claims are the claims of the JWT or of the API key, the tenant and the roles are read from them
+	Claims map[string]any
+}
+
+type contextKey struct{}
+
+// Authenticator authenticates the requests that are not exempt, a request is authenticated by the
+// first of the runner token, a JWT or an API key that it carries
+type Authenticator struct {
+	logger      *slog.Logger
+	exempt      []string
+	jwtConfig   *config.JWTConfig
+	keys        *keySet
+	apiKeys     map[string]config.APIKeyConfig
+	runnerToken string
+	now         func() time.Time
+}
+
+// NewAuthenticator creates the authenticator of the configuration, it returns nil when the
+// authentication is not enabled
+func NewAuthenticator(logger *slog.Logger, authConfig *config.AuthConfig, runnersConfig *config.RunnersConfig) (*Authenticator, error) {
+	if (authConfig == nil) || !authConfig.Enabled {
+		logger.Warn("Authentication is not enabled, every request is anonymous")
+		return nil, nil
+	}
+	a := &Authenticator{
+		logger:  logger,
+		exempt:  authConfig.Exempt,
+		apiKeys: map[string]config.APIKeyConfig{},
+		now:     time.Now,
+	}
+	if runnersConfig != nil {
+		a.runnerToken = runnersConfig.Token
+	}
+
+	apiKeys := authConfig.APIKeys
+	if authConfig.APIKeysSecret != "" {
+		secret := []config.APIKeyConfig{}
+		if err := yaml.Unmarshal([]byte(authConfig.APIKeysSecret), &secret); err != nil {
+			return nil, fmt.Errorf("invalid API keys secret: %w", err)
+		}
+		apiKeys = append(apiKeys, secret...)
+	}
+	for _, apiKey := range apiKeys {
+		hash, err := hex.DecodeString(apiKey.SHA256)
+		if (apiKey.Name == "") || (err != nil) || (len(hash) != sha256.Size) {
+			return nil, fmt.Errorf("API key %q must have a name and the hex encoded SHA-256 hash of the key", apiKey.Name)
+		}
+		a.apiKeys[hex.EncodeToString(hash)] = apiKey
+	}
+
+	if (authConfig.JWT != nil) && ((authConfig.JWT.JWKSFile != "") || (authConfig.JWT.JWKSURL != "")) {
+		keys, err := newKeySet(authConfig.JWT)
+		if err != nil {
+			return nil, err
+		}
+		if err := keys.load(a.now()); err != nil {
+			// a JWKS URL may be temporarily unavailable, the keys are loaded again on the first token
+			if authConfig.JWT.JWKSURL == "" {
+				return nil, err
+			}
+			logger.Warn("Failed to load the JWKS, the keys are loaded on the first token", "url", authConfig.JWT.JWKSURL, "error", err.Error())
+		}
+		a.jwtConfig, a.keys = authConfig.JWT, keys
+	}
+
+	if (a.keys == nil) && (len(a.apiKeys) == 0) && (a.runnerToken == "") {
+		return nil, fmt.Errorf("authentication is enabled but no JWKS, API keys or runner token are configured")
+	}
+	return a, nil
+}
+
+// Middleware rejects the requests that are not exempt and can not be authenticated with 401, the
+// principal of the other requests is stored in their context. A nil authenticator lets every
+// request through.
+func (a *Authenticator) Middleware(next http.Handler) http.Handler {
+	if a == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if a.isExempt(r.URL.Path) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		principal, err := a.Authenticate(r)
+		if err != nil {
+			a.logger.Info("Rejected an unauthenticated request", "uri", r.URL.Path, "error", err.Error())
+			w.Header().Set("WWW-Authenticate", `Bearer realm="eval-hub"`)
+			apierrors.Write(w, r, apierrors.Unauthorized("authentication is required"))
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(WithPrincipal(r.Context(), principal)))
+	})
+}
+
+// Authenticate returns the principal of the runner token, the JWT or the API key of the request
+func (a *Authenticator) Authenticate(r *http.Request) (*Principal, error) {
+	if key := r.Header.Get(APIKeyHeader); key != "" {
+		return a.authenticateAPIKey(key)
+	}
+	token, found := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !found || (token == "") {
+		return nil, fmt.Errorf("no credentials")
+	}
+	if (a.runnerToken != "") && (subtle.ConstantTimeCompare([]byte(token), []byte(a.runnerToken)) == 1) {
+		return &Principal{Subject: RunnerSubject, Method: MethodRunnerToken}, nil
+	}
+	if (a.keys != nil) && isJWT(token) {
+		claims, err := verifyJWT(token, a.keys, a.jwtConfig, a.now())
+		if err != nil {
+			return nil, fmt.Errorf("invalid JWT: %w", err)
+		}
+		subject, _ := claims["sub"].(string)
+		if subject == "" {
+			return nil, fmt.Errorf("invalid JWT: the sub claim is required")
+		}
+		return &Principal{Subject: subject, Method: MethodJWT, Claims: claims}, nil
+	}
+	return a.authenticateAPIKey(token)
+}
+
+func (a *Authenticator) authenticateAPIKey(key string) (*Principal, error) {
+	hash := sha256.Sum256([]byte(key))
+	apiKey, found := a.apiKeys[hex.EncodeToString(hash[:])]
+	if !found {
+		return nil, fmt.Errorf("unknown API key")
+	}
+	return &Principal{Subject: apiKey.Name, Method: MethodAPIKey, Claims: apiKey.Claims}, nil
+}
+
+func (a *Authenticator) isExempt(path string) bool {
+	for _, exempt := range a.exempt {
+		if (path == exempt) || (strings.HasSuffix(exempt, "/") && strings.HasPrefix(path, exempt)) {
+			return true
+		}
+	}
+	return false
+}
+
+// WithPrincipal returns a copy of the context holding the principal
+func WithPrincipal(ctx context.Context, principal *Principal) context.Context {
+	return context.WithValue(ctx, contextKey{}, principal)
+}
+
+// FromContext returns the principal stored in the context, nil for an anonymous request
+func FromContext(ctx context.Context) *Principal {
+	principal, _ := ctx.Value(contextKey{}).(*Principal)
+	return principal
+}
@@ -0,0 +1,130 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/config"
+	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/logging"
+)
+
+func hashKey(key string) string {
+	hash := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(hash[:])
+}
+
+func TestNewAuthenticator(t *testing.T) {
+	logger, _ := logging.NewLogger()
+
+	if a, err := NewAuthenticator(logger, &config.AuthConfig{}, nil); (a != nil) || (err != nil) {
+		t.Errorf("Expected no authenticator when the authentication is not enabled, got %v, %v", a, err)
+	}
+	if _, err := NewAuthenticator(logger, &config.AuthConfig{Enabled: true}, nil); err == nil {
+		t.Error("Expected an error without any credentials")
+	}
+	if _, err := NewAuthenticator(logger, &config.AuthConfig{Enabled: true, APIKeys: []config.APIKeyConfig{{Name: "ci", SHA256: "secret"}}}, nil); err == nil {
+		t.Error("Expected an error for an API key that is not hashed")
+	}
+	if _, err := NewAuthenticator(logger, &config.AuthConfig{Enabled: true, APIKeysSecret: "not: [a list"}, nil); err == nil {
+		t.Error("Expected an error for an invalid API keys secret")
+	}
+	if _, err := NewAuthenticator(logger, &config.AuthConfig{Enabled: true, JWT: &config.JWTConfig{JWKSFile: "/does/not/exist"}}, nil); err == nil {
+		t.Error("Expected an error for a missing JWKS file")
+	}
+	if _, err := NewAuthenticator(logger, &config.AuthConfig{Enabled: true, JWT: &config.JWTConfig{JWKSURL: "http://127.0.0.1:1/jwks"}}, nil); err != nil {
+		t.Errorf("Expected an unavailable JWKS URL to be loaded later, got %v", err)
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	logger, _ := logging.NewLogger()
+	keys := newTestKeys(t)
+	authConfig := &config.AuthConfig{
+		Enabled:       true,
+		Exempt:        []string{"/api/v1/health", "/docs/"},
+		JWT:           &config.JWTConfig{JWKSFile: keys.writeJWKS(t), Audience: "eval-hub"},
+		APIKeys:       []config.APIKeyConfig{{Name: "ci", SHA256: hashKey("ci-key"), Claims: map[string]any{"tenant": "acme"}}},
+		APIKeysSecret: "- name: nightly\n  sha256: " + hashKey("nightly-key") + "\n",
+	}
+	authenticator, err := NewAuthenticator(logger, authConfig, &config.RunnersConfig{Token: "runner-token"})
+	if err != nil {
+		t.Fatalf("NewAuthenticator() returned error: %v", err)
+	}
+
+	var principal *Principal
+	handler := authenticator.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		principal = FromContext(r.Context())
+	}))
+	token := keys.sign(t, "ES256", "ec-1", map[string]any{"sub": "alice", "aud": "eval-hub", "exp": time.Now().Add(time.Hour).Unix()})
+	foreign := keys.sign(t, "ES256", "ec-1", map[string]any{"sub": "alice", "aud": "other", "exp": time.Now().Add(time.Hour).Unix()})
+
+	testCases := []struct {
+		name    string
+		path    string
+		headers map[string]string
+		status  int
+		subject string
+		method  string
+	}{
+		{name: "JWT", path: "/api/v1/evaluations/jobs", headers: map[string]string{"Authorization": "Bearer " + token}, status: http.StatusOK, subject: "alice", method: MethodJWT},
+		{name: "JWT for another audience", path: "/api/v1/evaluations/jobs", headers: map[string]string{"Authorization": "Bearer " + foreign}, status: http.StatusUnauthorized},
+		{name: "API key header", path: "/api/v1/evaluations/jobs", headers: map[string]string{APIKeyHeader: "ci-key"}, status: http.StatusOK, subject: "ci", method: MethodAPIKey},
+		{name: "API key as a bearer token", path: "/api/v1/evaluations/jobs", headers: map[string]string{"Authorization": "Bearer ci-key"}, status: http.StatusOK, subject: "ci", method: MethodAPIKey},
+		{name: "API key of the secret", path: "/api/v1/evaluations/jobs", headers: map[string]string{APIKeyHeader: "nightly-key"}, status: http.StatusOK, subject: "nightly", method: MethodAPIKey},
+		{name: "unknown API key", path: "/api/v1/evaluations/jobs", headers: map[string]string{APIKeyHeader: "guess"}, status: http.StatusUnauthorized},
+		{name: "runner token", path: "/api/v1/evaluations/jobs/1/benchmarks/mmlu/results", headers: map[string]string{"Authorization": "Bearer runner-token"}, status: http.StatusOK, subject: RunnerSubject, method: MethodRunnerToken},
+		{name: "spoofed remote user", path: "/api/v1/evaluations/jobs", headers: map[string]string{"Remote-User": "admin"}, status: http.StatusUnauthorized},
+		{name: "exempt path", path: "/api/v1/health", status: http.StatusOK},
+		{name: "below an exempt prefix", path: "/docs/index.html", status: http.StatusOK},
+		{name: "not below an exempt path", path: "/api/v1/health/details", status: http.StatusUnauthorized},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			principal = nil
+			req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+			for name, value := range tc.headers {
+				req.Header.Set(name, value)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			if w.Code != tc.status {
+				t.Fatalf("Expected status %d, got %d: %s", tc.status, w.Code, w.Body.String())
+			}
+			if (w.Code == http.StatusUnauthorized) && (w.Header().Get("WWW-Authenticate") == "") {
+				t.Error("Expected the WWW-Authenticate header")
+			}
+			if tc.subject == "" {
+				if principal != nil {
+					t.Errorf("Expected no principal, got %+v", principal)
+				}
+				return
+			}
+			if (principal == nil) || (principal.Subject != tc.subject) || (principal.Method != tc.method) {
+				t.Errorf("Expected the principal %s authenticated with %s, got %+v", tc.subject, tc.method, principal)
+			}
+		})
+	}
+
+	t.Run("the claims of an API key are those of its principal", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/evaluations/jobs", nil)
+		req.Header.Set(APIKeyHeader, "ci-key")
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+		if (principal == nil) || (principal.Claims["tenant"] != "acme") {
+			t.Errorf("Expected the claims of the API key, got %+v", principal)
+		}
+	})
+
+	t.Run("a nil authenticator lets every request through", func(t *testing.T) {
+		var nilAuthenticator *Authenticator
+		w := httptest.NewRecorder()
+		nilAuthenticator.Middleware(handler).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/health", nil))
+		if w.Code != http.StatusOK {
+			t.Errorf("Expected status %d, got %d", http.StatusOK, w.Code)
+		}
+	})
+}
@@ -0,0 +1,223 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/config"
+
+	"github.com/go-jose/go-jose/v4"
+	"github.com/go-jose/go-jose/v4/jwt"
+	"golang.org/x/sync/singleflight"
+)
+
+// defaultRefreshInterval is the minimum delay between two reloads of the signing keys when no
+// refresh interval is configured
+const defaultRefreshInterval = time.Minute
+
+// minRetryInterval is the delay before the reload that follows a failed load of the signing keys,
+// it doubles with every consecutive failure up to the refresh interval
+const minRetryInterval = 5 * time.Second
+
+// algorithms are the supported JWS signature algorithms, the symmetric algorithms and none are rejected
+var algorithms = []jose.SignatureAlgorithm{
+	jose.RS256, jose.RS384, jose.RS512,
+	jose.PS256, jose.PS384, jose.PS512,
+	jose.ES256, jose.ES384, jose.ES512,
+}
+
+// keySet holds the signing keys of the JWKS by key ID. The keys are reloaded when a token is signed
+// by an unknown key, at most once per refresh interval after a successful load and with a growing
+// backoff after a failed one. The keys are swapped atomically and the concurrent reloads are shared,
+// so that the lookups of the known keys never wait for the JWKS to be read.
+type keySet struct {
+	keys            atomic.Pointer[map[string]crypto.PublicKey]
+	loads           singleflight.Group
+	refreshInterval time.Duration
+	read            func() ([]byte, error)
+
+	// lock guards the time of the last load attempt and the number of consecutive failures
+	lock        sync.Mutex
+	attemptedAt time.Time
+	failures    int
+}
+
+// newKeySet creates the key set of the JWKS file or URL of the configuration
+func newKeySet(jwtConfig *config.JWTConfig) (*keySet, error) {
+	keys := &keySet{refreshInterval: jwtConfig.RefreshInterval}
+	if keys.refreshInterval <= 0 {
+		keys.refreshInterval = defaultRefreshInterval
+	}
+	keys.keys.Store(&map[string]crypto.PublicKey{})
+	switch {
+	case jwtConfig.JWKSFile != "":
+		keys.read = func() ([]byte, error) {
+			return os.ReadFile(jwtConfig.JWKSFile)
+		}
+	case jwtConfig.JWKSURL != "":
+		client := &http.Client{Timeout: 10 * time.Second}
+		keys.read = func() ([]byte, error) {
+			return fetchJWKS(client, jwtConfig.JWKSURL)
+		}
+	default:
+		return nil, fmt.Errorf("a JWKS file or URL is required to validate the JWTs")
+	}
+	return keys, nil
+}
+
+// load reads and parses the JWKS, the previous keys are kept when it fails
+func (k *keySet) load(now time.Time) error {
+	k.lock.Lock()
+	k.attemptedAt = now
+	k.lock.Unlock()
+
+	keys, err := k.parse()
+
+	k.lock.Lock()
+	defer k.lock.Unlock()
+	if err != nil {
+		k.failures++
+		return err
+	}
+	k.failures = 0
+	k.keys.Store(&keys)
+	return nil
+}
+
+func (k *keySet) parse() (map[string]crypto.PublicKey, error) {
+	data, err := k.read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the JWKS: %w", err)
+	}
+	return parseJWKS(data)
+}
+
+// reloadDue reports whether the keys may be reloaded, the delay since the last attempt is the
+// refresh interval after a success and the backoff of the consecutive failures after a failure
+func (k *keySet) reloadDue(now time.Time) bool {
+	k.lock.Lock()
+	defer k.lock.Unlock()
+
+	delay := k.refreshInterval
+	if k.failures > 0 {
+		delay = minRetryInterval
+		for i := 1; (i < k.failures) && (delay < k.refreshInterval); i++ {
+			delay *= 2
+		}
+		delay = min(delay, k.refreshInterval)
+	}
+	return now.Sub(k.attemptedAt) >= delay
+}
+
+// lookup returns the key with the ID, a token without a key ID is verified with the only key of
+// the set
+func (k *keySet) lookup(id string) crypto.PublicKey {
+	keys := *k.keys.Load()
+	if (id == "") && (len(keys) == 1) {
+		for _, key := range keys {
+			return key
+		}
+	}
+	return keys[id]
+}
+
+// key returns the key with the ID, the keys are reloaded when the key is not found and a reload is
+// due. The tokens that are signed by unknown keys while the keys are reloaded share the reload.
+func (k *keySet) key(id string, now time.Time) (crypto.PublicKey, error) {
+	if key := k.lookup(id); key != nil {
+		return key, nil
+	}
+	if k.reloadDue(now) {
+		_, err, _ := k.loads.Do("jwks", func() (any, error) {
+			// another token may have reloaded the keys since the check
+			if !k.reloadDue(now) {
+				return nil, nil
+			}
+			return nil, k.load(now)
+		})
+		if err != nil {
+			return nil, err
+		}
+		if key := k.lookup(id); key != nil {
+			return key, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown signing key %q", id)
+}
+
+func fetchJWKS(client *http.Client, url string) ([]byte, error) {
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s returned %s", url, resp.Status)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+}
+
+// parseJWKS returns the RSA and EC signing keys of the JWKS by key ID, the other keys are skipped
+func parseJWKS(data []byte) (map[string]crypto.PublicKey, error) {
+	set := jose.JSONWebKeySet{}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("invalid JWKS: %w", err)
+	}
+	keys := map[string]crypto.PublicKey{}
+	for _, key := range set.Keys {
+		if (key.Use != "") && (key.Use != "sig") {
+			continue
+		}
+		switch key.Key.(type) {
+		case *rsa.PublicKey, *ecdsa.PublicKey:
+			keys[key.KeyID] = key.Key
+		}
+	}
+	return keys, nil
+}
+
+// isJWT reports whether the token has the three parts of a compact JWS
+func isJWT(token string) bool {
+	return strings.Count(token, ".") == 2
+}
+
+// verifyJWT checks the signature of the token with the key set and its exp, nbf, iat, iss and aud
+// claims, and returns its claims
+func verifyJWT(token string, keys *keySet, jwtConfig *config.JWTConfig, now time.Time) (map[string]any, error) {
+	parsed, err := jwt.ParseSigned(token, algorithms)
+	if err != nil {
+		return nil, fmt.Errorf("invalid token: %w", err)
+	}
+	key, err := keys.key(parsed.Headers[0].KeyID, now)
+	if err != nil {
+		return nil, err
+	}
+	registered := jwt.Claims{}
+	claims := map[string]any{}
+	if err := parsed.Claims(key, &registered, &claims); err != nil {
+		return nil, fmt.Errorf("invalid token: %w", err)
+	}
+
+	// the tokens must expire, a token without exp would be valid forever
+	if registered.Expiry == nil {
+		return nil, fmt.Errorf("the exp claim is required")
+	}
+	expected := jwt.Expected{Issuer: jwtConfig.Issuer, Time: now}
+	if jwtConfig.Audience != "" {
+		expected.AnyAudience = jwt.Audience{jwtConfig.Audience}
+	}
+	if err := registered.ValidateWithLeeway(expected, jwtConfig.Leeway); err != nil {
+		return nil, fmt.Errorf("invalid token: %w", err)
+	}
+	return claims, nil
+}
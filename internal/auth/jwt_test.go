@@ -0,0 +1,267 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/config"
+
+	"github.com/go-jose/go-jose/v4"
+	"github.com/go-jose/go-jose/v4/jwt"
+)
+
+// testKeys are the signing keys of the tests
+type testKeys struct {
+	rsa *rsa.PrivateKey
+	ec  *ecdsa.PrivateKey
+}
+
+func newTestKeys(t *testing.T) *testKeys {
+	t.Helper()
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Failed to generate the RSA key: %v", err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate the EC key: %v", err)
+	}
+	return &testKeys{rsa: rsaKey, ec: ecKey}
+}
+
+// jwks returns the JWKS of the public keys, the RSA key is rsa-1 and the EC key is ec-1
+func (k *testKeys) jwks() []byte {
+	data, _ := json.Marshal(jose.JSONWebKeySet{Keys: []jose.JSONWebKey{
+		{Key: k.rsa.Public(), KeyID: "rsa-1", Use: "sig"},
+		{Key: k.ec.Public(), KeyID: "ec-1"},
+		{Key: []byte("a secret of at least thirty-two bytes"), KeyID: "hmac"},
+	}})
+	return data
+}
+
+// writeJWKS writes the JWKS to a file and returns its path
+func (k *testKeys) writeJWKS(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(path, k.jwks(), 0o600); err != nil {
+		t.Fatalf("Failed to write the JWKS: %v", err)
+	}
+	return path
+}
+
+// sign returns the token of the claims signed with the algorithm and the key ID, a token with the
+// none algorithm is not signed
+func (k *testKeys) sign(t *testing.T, alg string, kid string, claims map[string]any) string {
+	t.Helper()
+	if alg == "none" {
+		header, _ := json.Marshal(map[string]string{"alg": alg, "kid": kid, "typ": "JWT"})
+		payload, _ := json.Marshal(claims)
+		return base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload) + "."
+	}
+	var key any
+	switch alg[:2] {
+	case "RS", "PS":
+		key = k.rsa
+	case "ES":
+		key = k.ec
+	default:
+		key = []byte("a secret of at least thirty-two bytes")
+	}
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.SignatureAlgorithm(alg), Key: jose.JSONWebKey{Key: key, KeyID: kid}},
+		(&jose.SignerOptions{}).WithType("JWT"))
+	if err != nil {
+		t.Fatalf("Failed to create the signer: %v", err)
+	}
+	token, err := jwt.Signed(signer).Claims(claims).Serialize()
+	if err != nil {
+		t.Fatalf("Failed to sign the token: %v", err)
+	}
+	return token
+}
+
+func TestVerifyJWT(t *testing.T) {
+	keys := newTestKeys(t)
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	jwtConfig := &config.JWTConfig{JWKSFile: keys.writeJWKS(t), Issuer: "https://issuer", Audience: "eval-hub", Leeway: time.Minute}
+	set, err := newKeySet(jwtConfig)
+	if err != nil {
+		t.Fatalf("newKeySet() returned error: %v", err)
+	}
+	if err := set.load(now); err != nil {
+		t.Fatalf("load() returned error: %v", err)
+	}
+	claims := func(changes map[string]any) map[string]any {
+		claims := map[string]any{"sub": "alice", "iss": "https://issuer", "aud": []string{"other", "eval-hub"}, "exp": now.Add(time.Hour).Unix(), "iat": now.Unix()}
+		for name, value := range changes {
+			if value == nil {
+				delete(claims, name)
+			} else {
+				claims[name] = value
+			}
+		}
+		return claims
+	}
+
+	testCases := []struct {
+		name  string
+		token string
+		valid bool
+	}{
+		{"RS256", keys.sign(t, "RS256", "rsa-1", claims(nil)), true},
+		{"PS256", keys.sign(t, "PS256", "rsa-1", claims(nil)), true},
+		{"PS384", keys.sign(t, "PS384", "rsa-1", claims(nil)), true},
+		{"ES256", keys.sign(t, "ES256", "ec-1", claims(nil)), true},
+		{"audience as a string", keys.sign(t, "RS256", "rsa-1", claims(map[string]any{"aud": "eval-hub"})), true},
+		{"expired within the leeway", keys.sign(t, "RS256", "rsa-1", claims(map[string]any{"exp": now.Add(-30 * time.Second).Unix()})), true},
+		{"expired", keys.sign(t, "RS256", "rsa-1", claims(map[string]any{"exp": now.Add(-time.Hour).Unix()})), false},
+		{"without expiry", keys.sign(t, "RS256", "rsa-1", claims(map[string]any{"exp": nil})), false},
+		{"not valid yet", keys.sign(t, "RS256", "rsa-1", claims(map[string]any{"nbf": now.Add(time.Hour).Unix()})), false},
+		{"wrong issuer", keys.sign(t, "RS256", "rsa-1", claims(map[string]any{"iss": "https://other"})), false},
+		{"wrong audience", keys.sign(t, "RS256", "rsa-1", claims(map[string]any{"aud": "other"})), false},
+		{"unknown key", keys.sign(t, "RS256", "rsa-2", claims(nil)), false},
+		{"key of another type", keys.sign(t, "RS256", "ec-1", claims(nil)), false},
+		{"symmetric algorithm", keys.sign(t, "HS256", "hmac", claims(nil)), false},
+		{"no algorithm", keys.sign(t, "none", "rsa-1", claims(nil)), false},
+		{"malformed", "a.b", false},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			verified, err := verifyJWT(tc.token, set, jwtConfig, now)
+			if tc.valid && ((err != nil) || (verified["sub"] != "alice")) {
+				t.Errorf("Expected a valid token, got %v", err)
+			}
+			if !tc.valid && (err == nil) {
+				t.Error("Expected the token to be rejected")
+			}
+		})
+	}
+
+	t.Run("tampered claims", func(t *testing.T) {
+		parts := strings.Split(keys.sign(t, "RS256", "rsa-1", claims(nil)), ".")
+		payload, _ := json.Marshal(claims(map[string]any{"sub": "mallory"}))
+		parts[1] = base64.RawURLEncoding.EncodeToString(payload)
+		if _, err := verifyJWT(strings.Join(parts, "."), set, jwtConfig, now); err == nil {
+			t.Error("Expected a tampered token to be rejected")
+		}
+	})
+}
+
+func TestKeySetReload(t *testing.T) {
+	keys := newTestKeys(t)
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	os.WriteFile(path, []byte(`{"keys": []}`), 0o600)
+	set, err := newKeySet(&config.JWTConfig{JWKSFile: path, RefreshInterval: time.Minute})
+	if err != nil {
+		t.Fatalf("newKeySet() returned error: %v", err)
+	}
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	set.load(now)
+
+	// the rotated keys are only loaded once the refresh interval has passed
+	os.WriteFile(path, keys.jwks(), 0o600)
+	if _, err := set.key("rsa-1", now.Add(time.Second)); err == nil {
+		t.Error("Expected the keys not to be reloaded within the refresh interval")
+	}
+	if _, err := set.key("rsa-1", now.Add(time.Minute)); err != nil {
+		t.Errorf("Expected the rotated key to be loaded, got %v", err)
+	}
+}
+
+func TestKeySetLoadFailure(t *testing.T) {
+	keys := newTestKeys(t)
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	set, err := newKeySet(&config.JWTConfig{JWKSFile: path, RefreshInterval: time.Hour})
+	if err != nil {
+		t.Fatalf("newKeySet() returned error: %v", err)
+	}
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	if err := set.load(now); err == nil {
+		t.Fatal("Expected the missing JWKS to fail to load")
+	}
+
+	// the reloads after a failure back off, without waiting for the refresh interval
+	os.WriteFile(path, keys.jwks(), 0o600)
+	if _, err := set.key("rsa-1", now.Add(time.Second)); err == nil {
+		t.Error("Expected the keys not to be reloaded right after the failure")
+	}
+	if _, err := set.key("rsa-1", now.Add(minRetryInterval)); err != nil {
+		t.Errorf("Expected the keys to be loaded after the backoff, got %v", err)
+	}
+}
+
+func TestKeySetConcurrentReload(t *testing.T) {
+	keys := newTestKeys(t)
+	set, err := newKeySet(&config.JWTConfig{JWKSFile: keys.writeJWKS(t), RefreshInterval: time.Hour})
+	if err != nil {
+		t.Fatalf("newKeySet() returned error: %v", err)
+	}
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	if err := set.load(now); err != nil {
+		t.Fatalf("load() returned error: %v", err)
+	}
+
+	// the next read blocks until it is released
+	reads := atomic.Int32{}
+	started, release := make(chan struct{}), make(chan struct{})
+	set.read = func() ([]byte, error) {
+		if reads.Add(1) == 1 {
+			close(started)
+		}
+		<-release
+		return keys.jwks(), nil
+	}
+	later := now.Add(time.Hour)
+	unknown := sync.WaitGroup{}
+	for range 5 {
+		unknown.Add(1)
+		go func() {
+			defer unknown.Done()
+			set.key("rsa-2", later)
+		}()
+	}
+	<-started
+
+	// the known keys are found while the keys are reloaded
+	done := make(chan error, 1)
+	go func() {
+		_, err := set.key("rsa-1", later)
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Expected the known key to be found, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Error("Expected the lookup of a known key not to wait for the reload")
+	}
+	close(release)
+	unknown.Wait()
+	if count := reads.Load(); count != 1 {
+		t.Errorf("Expected the concurrent reloads to share one read, got %d reads", count)
+	}
+}
+
+func TestParseJWKS(t *testing.T) {
+	if _, err := parseJWKS([]byte(`{"keys": [{"kty": "EC", "kid": "bad", "crv": "P-256", "x": "AQ", "y": "AQ"}]}`)); err == nil {
+		t.Error("Expected a point that is not on the curve to be rejected")
+	}
+	keys, err := parseJWKS([]byte(`{"keys": [{"kty": "RSA", "kid": "enc", "use": "enc", "n": "AQ", "e": "AQAB"}]}`))
+	if (err != nil) || (len(keys) != 0) {
+		t.Errorf("Expected the encryption keys to be skipped, got %v, %v", keys, err)
+	}
+	if _, err := parseJWKS([]byte(`not json`)); err == nil {
+		t.Error("Expected an invalid JWKS to be rejected")
+	}
+}
@@ -0,0 +1,87 @@
+// Package callbacks delivers the state changes of the evaluation jobs to their callback URLs.
+//
+// The deliveries are created by the storage in the same update that changes the state of a job, so
+// that no event is lost when the service stops, and are sent by a Dispatcher that retries the failed
+// deliveries with an exponential backoff before moving them to the dead-letter list.
+package callbacks
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/state_machine"
+	"github.ibm.com/julpayne/eval-hub-backend-svc/pkg/api"
+)
+
+const (
+	HeaderEvent     = "X-Eval-Hub-Event"
+	HeaderDelivery  = "X-Eval-Hub-Delivery"
+	HeaderTimestamp = "X-Eval-Hub-Timestamp"
+	// HeaderSignature holds "sha256=" followed by the hex encoded HMAC-SHA256 of the timestamp header,
+	// a dot and the request body
+	HeaderSignature = "X-Eval-Hub-Signature-256"
+
+	signaturePrefix = "sha256="
+)
+
+// NewDelivery returns the delivery for a job whose state changed from the previous state, the job
+// is the snapshot sent in the payload. It returns nil when the job has no callback URL or when the
+// state did not change. Terminal states are sent as the completed event.
+func NewDelivery(job *api.EvaluationJobResource, previous api.State, now time.Time) *api.CallbackDeliveryResource {
+	if (job.CallbackURL == nil) || (*job.CallbackURL == "") || (job.Status.State == previous) {
+		return nil
+	}
+
+	event := api.CallbackEventStateChanged
+	if state_machine.IsTerminal(job.Status.State) {
+		event = api.CallbackEventCompleted
+	}
+	id := uuid.NewString()
+	return &api.CallbackDeliveryResource{
+		Resource:      api.Resource{ID: id, Tenant: job.Tenant, CreatedAt: now, UpdatedAt: now},
+		JobID:         job.ID,
+		Event:         event,
+		URL:           *job.CallbackURL,
+		State:         api.CallbackDeliveryPending,
+		NextAttemptAt: &now,
+		Payload: api.CallbackPayload{
+			DeliveryID:    id,
+			Event:         event,
+			Timestamp:     now,
+			PreviousState: previous,
+			Job:           *job,
+		},
+	}
+}
+
+// Sign returns the value of the signature header for a payload sent at the given timestamp
+func Sign(secret []byte, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return signaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks the signature header of a received payload, receivers should also reject
+// timestamps that are too old to prevent replays
+func Verify(secret []byte, timestamp string, body []byte, signature string) bool {
+	if !strings.HasPrefix(signature, signaturePrefix) {
+		return false
+	}
+	return hmac.Equal([]byte(Sign(secret, timestamp, body)), []byte(signature))
+}
+
+// Backoff returns the delay before the next attempt after the given number of failed attempts
+func Backoff(attempts int, initial, maximum time.Duration) time.Duration {
+	delay := initial
+	for i := 1; (i < attempts) && (delay < maximum); i++ {
+		delay *= 2
+	}
+	return min(delay, maximum)
+}
@@ -0,0 +1,248 @@
+package callbacks_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/abstractions"
+	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/callbacks"
+	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/config"
+	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/logging"
+	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/storage/memory"
+	"github.ibm.com/julpayne/eval-hub-backend-svc/pkg/api"
+)
+
+const secret = "top-secret"
+
+// receiver records the requests posted to the callback URL and answers with the next status code
+type receiver struct {
+	lock     sync.Mutex
+	statuses []int
+	requests []*http.Request
+	bodies   [][]byte
+}
+
+func (r *receiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	body, _ := io.ReadAll(req.Body)
+	r.requests = append(r.requests, req)
+	r.bodies = append(r.bodies, body)
+	status := http.StatusNoContent
+	if len(r.statuses) > 0 {
+		status, r.statuses = r.statuses[0], r.statuses[1:]
+	}
+	w.WriteHeader(status)
+}
+
+func newDispatcher(t *testing.T, storage abstractions.Storage, maxAttempts int) *callbacks.Dispatcher {
+	t.Helper()
+	logger, err := logging.NewLogger()
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	dispatcher, err := callbacks.NewDispatcher(logger, storage, &config.CallbacksConfig{
+		Secret:         secret,
+		MaxAttempts:    maxAttempts,
+		InitialBackoff: time.Minute,
+		MaxBackoff:     time.Hour,
+		Timeout:        5 * time.Second,
+		// the receivers of the tests listen on the loopback address
+		AllowedHosts: []string{"127.0.0.1/32"},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create the dispatcher: %v", err)
+	}
+	return dispatcher
+}
+
+// newJob stores a pending job with a callback URL and a single benchmark
+func newJob(t *testing.T, storage abstractions.Storage, url string) *api.EvaluationJobResource {
+	t.Helper()
+	job := &api.EvaluationJobResource{
+		Resource: api.Resource{ID: "job-1"},
+		EvaluationJobConfig: api.EvaluationJobConfig{
+			Model:       api.ModelRef{URL: "http://model", Name: "granite"},
+			Benchmarks:  []api.BenchmarkConfig{{Ref: api.Ref{ID: "mmlu"}}},
+			CallbackURL: &url,
+		},
+		Status: api.EvaluationJobStatus{
+			EvaluationJobState: api.EvaluationJobState{State: api.StatePending},
+			Benchmarks:         []api.BenchmarkStatus{{Name: "mmlu", State: api.StatePending}},
+		},
+	}
+	if err := storage.CreateEvaluationJob(job); err != nil {
+		t.Fatalf("CreateEvaluationJob() returned error: %v", err)
+	}
+	return job
+}
+
+func getDeliveries(t *testing.T, storage abstractions.Storage) []api.CallbackDeliveryResource {
+	t.Helper()
+	list, err := storage.GetCallbackDeliveries(abstractions.Query{abstractions.QueryJobID: "job-1"})
+	if err != nil {
+		t.Fatalf("GetCallbackDeliveries() returned error: %v", err)
+	}
+	return list.Items
+}
+
+func TestDispatchDue(t *testing.T) {
+	t.Run("state changes are delivered signed", func(t *testing.T) {
+		received := &receiver{}
+		server := httptest.NewServer(received)
+		defer server.Close()
+		storage := memory.NewStorage()
+		newJob(t, storage, server.URL)
+
+		storage.UpdateBenchmarkStatusForJob("job-1", api.BenchmarkStatus{Name: "mmlu", State: api.StateRunning})
+		storage.UpdateBenchmarkStatusForJob("job-1", api.BenchmarkStatus{Name: "mmlu", State: api.StateCompleted})
+		delivered, err := newDispatcher(t, storage, 3).DispatchDue(context.Background(), time.Now().UTC())
+
+		if err != nil || delivered != 2 {
+			t.Fatalf("Expected 2 deliveries, got %d and error %v", delivered, err)
+		}
+		events := []api.CallbackEvent{api.CallbackEventStateChanged, api.CallbackEventCompleted}
+		states := []api.State{api.StateRunning, api.StateCompleted}
+		for i, request := range received.requests {
+			timestamp := request.Header.Get(callbacks.HeaderTimestamp)
+			if !callbacks.Verify([]byte(secret), timestamp, received.bodies[i], request.Header.Get(callbacks.HeaderSignature)) {
+				t.Errorf("Expected a valid signature, got %q", request.Header.Get(callbacks.HeaderSignature))
+			}
+			var payload api.CallbackPayload
+			if err := json.Unmarshal(received.bodies[i], &payload); err != nil {
+				t.Fatalf("Failed to unmarshal the payload: %v", err)
+			}
+			if (payload.Event != events[i]) || (payload.Job.Status.State != states[i]) || (request.Header.Get(callbacks.HeaderEvent) != string(events[i])) {
+				t.Errorf("Expected the %s event for the %s state, got %+v", events[i], states[i], payload)
+			}
+			if request.Header.Get(callbacks.HeaderDelivery) != payload.DeliveryID {
+				t.Errorf("Expected the delivery header to match the payload, got %q", request.Header.Get(callbacks.HeaderDelivery))
+			}
+		}
+		for _, delivery := range getDeliveries(t, storage) {
+			if (delivery.State != api.CallbackDeliveryDelivered) || (len(delivery.Attempts) != 1) || (delivery.Attempts[0].StatusCode != http.StatusNoContent) {
+				t.Errorf("Expected a delivered callback with one attempt, got %+v", delivery)
+			}
+		}
+	})
+
+	t.Run("failed deliveries back off and are dead-lettered", func(t *testing.T) {
+		received := &receiver{statuses: []int{http.StatusInternalServerError, http.StatusBadGateway}}
+		server := httptest.NewServer(received)
+		defer server.Close()
+		storage := memory.NewStorage()
+		newJob(t, storage, server.URL)
+		storage.UpdateEvaluationJobStatus("job-1", api.EvaluationJobState{State: api.StateCancelled})
+		dispatcher := newDispatcher(t, storage, 2)
+		now := time.Now().UTC()
+
+		dispatcher.DispatchDue(context.Background(), now)
+		delivery := getDeliveries(t, storage)[0]
+		if (delivery.State != api.CallbackDeliveryPending) || !delivery.NextAttemptAt.Equal(now.Add(time.Minute)) {
+			t.Fatalf("Expected a retry after the initial backoff, got %+v", delivery)
+		}
+		if delivered, _ := dispatcher.DispatchDue(context.Background(), now.Add(time.Second)); (delivered != 0) || (len(received.requests) != 1) {
+			t.Fatalf("Expected the delivery not to be retried before the backoff")
+		}
+
+		dispatcher.DispatchDue(context.Background(), now.Add(time.Minute))
+		delivery = getDeliveries(t, storage)[0]
+		if (delivery.State != api.CallbackDeliveryDeadLetter) || (len(delivery.Attempts) != 2) || (delivery.Attempts[1].StatusCode != http.StatusBadGateway) {
+			t.Errorf("Expected the delivery to be dead-lettered after 2 attempts, got %+v", delivery)
+		}
+		deadLetters, _ := storage.GetCallbackDeliveries(abstractions.Query{abstractions.QueryState: string(api.CallbackDeliveryDeadLetter)})
+		if len(deadLetters.Items) != 1 {
+			t.Errorf("Expected 1 dead letter, got %d", len(deadLetters.Items))
+		}
+	})
+
+	t.Run("private addresses are refused when they are not allowed", func(t *testing.T) {
+		received := &receiver{}
+		server := httptest.NewServer(received)
+		defer server.Close()
+		storage := memory.NewStorage()
+		newJob(t, storage, server.URL)
+		storage.UpdateEvaluationJobStatus("job-1", api.EvaluationJobState{State: api.StateCancelled})
+		logger, err := logging.NewLogger()
+		if err != nil {
+			t.Fatalf("Failed to create logger: %v", err)
+		}
+		dispatcher, err := callbacks.NewDispatcher(logger, storage, &config.CallbacksConfig{MaxAttempts: 1})
+		if err != nil {
+			t.Fatalf("Failed to create the dispatcher: %v", err)
+		}
+
+		dispatcher.DispatchDue(context.Background(), time.Now().UTC())
+		delivery := getDeliveries(t, storage)[0]
+		if (delivery.State != api.CallbackDeliveryDeadLetter) || (len(received.requests) != 0) {
+			t.Errorf("Expected the delivery to the loopback address to fail without a request, got %+v", delivery)
+		}
+	})
+}
+
+func TestPolicy(t *testing.T) {
+	open, err := callbacks.NewPolicy(nil)
+	if err != nil {
+		t.Fatalf("Failed to create the policy: %v", err)
+	}
+	restricted, err := callbacks.NewPolicy(&config.CallbacksConfig{AllowedHosts: []string{"Hooks.internal", "10.20.0.0/16"}})
+	if err != nil {
+		t.Fatalf("Failed to create the policy: %v", err)
+	}
+	tests := []struct {
+		policy  *callbacks.Policy
+		url     string
+		allowed bool
+	}{
+		{open, "https://hooks.example.com/events", true},
+		{open, "http://93.184.216.34/hook", true},
+		{open, "http://169.254.169.254/latest/meta-data", false},
+		{open, "http://10.0.0.1:8080/hook", false},
+		{open, "http://[::1]/hook", false},
+		{open, "http://100.64.0.1/hook", false},
+		{open, "http://localhost:8080/hook", false},
+		{restricted, "http://hooks.internal/hook", true},
+		{restricted, "http://10.20.1.2/hook", true},
+		{restricted, "http://10.21.1.2/hook", false},
+		{restricted, "http://93.184.216.34/hook", false},
+	}
+	for _, test := range tests {
+		if err := test.policy.CheckURL(test.url); (err == nil) != test.allowed {
+			t.Errorf("Expected %s to be allowed %t, got %v", test.url, test.allowed, err)
+		}
+	}
+	if _, err := callbacks.NewPolicy(&config.CallbacksConfig{AllowedHosts: []string{"10.0.0.0/33"}}); err == nil {
+		t.Error("Expected an error for an invalid CIDR")
+	}
+}
+
+func TestNewDelivery(t *testing.T) {
+	url := "http://receiver"
+	job := &api.EvaluationJobResource{EvaluationJobConfig: api.EvaluationJobConfig{CallbackURL: &url}}
+	job.Status.State = api.StateRunning
+
+	if callbacks.NewDelivery(job, api.StateRunning, time.Now()) != nil {
+		t.Error("Expected no delivery when the state did not change")
+	}
+	if delivery := callbacks.NewDelivery(job, api.StatePending, time.Now()); (delivery == nil) || (delivery.Event != api.CallbackEventStateChanged) {
+		t.Errorf("Expected a state changed delivery, got %+v", delivery)
+	}
+	job.CallbackURL = nil
+	if callbacks.NewDelivery(job, api.StatePending, time.Now()) != nil {
+		t.Error("Expected no delivery without a callback URL")
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	for attempts, expected := range map[int]time.Duration{1: time.Second, 2: 2 * time.Second, 4: 8 * time.Second, 10: 30 * time.Second} {
+		if got := callbacks.Backoff(attempts, time.Second, 30*time.Second); got != expected {
+			t.Errorf("Expected a backoff of %v after %d attempts, got %v", expected, attempts, got)
+		}
+	}
+}
@@ -0,0 +1,204 @@
+package callbacks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/abstractions"
+	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/config"
+	"github.ibm.com/julpayne/eval-hub-backend-svc/pkg/api"
+)
+
+const (
+	defaultMaxAttempts    = 8
+	defaultInitialBackoff = 10 * time.Second
+	defaultMaxBackoff     = time.Hour
+	defaultPollInterval   = 5 * time.Second
+	defaultTimeout        = 10 * time.Second
+	// batchSize is the number of deliveries claimed at once
+	batchSize = 20
+	// maxErrorLength limits the response body recorded for a failed attempt
+	maxErrorLength = 512
+)
+
+// Dispatcher sends the pending callback deliveries of all the tenants
+type Dispatcher struct {
+	logger         *slog.Logger
+	storage        abstractions.Storage
+	client         *http.Client
+	secret         []byte
+	maxAttempts    int
+	initialBackoff time.Duration
+	maxBackoff     time.Duration
+	pollInterval   time.Duration
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewDispatcher creates a dispatcher, the unset configuration values use the defaults
+func NewDispatcher(logger *slog.Logger, storage abstractions.Storage, callbacksConfig *config.CallbacksConfig) (*Dispatcher, error) {
+	if callbacksConfig == nil {
+		callbacksConfig = &config.CallbacksConfig{}
+	}
+	policy, err := NewPolicy(callbacksConfig)
+	if err != nil {
+		return nil, err
+	}
+	dispatcher := &Dispatcher{
+		logger:         logger,
+		storage:        storage,
+		client:         policy.Client(orDefault(callbacksConfig.Timeout, defaultTimeout)),
+		secret:         []byte(callbacksConfig.Secret),
+		maxAttempts:    callbacksConfig.MaxAttempts,
+		initialBackoff: orDefault(callbacksConfig.InitialBackoff, defaultInitialBackoff),
+		maxBackoff:     orDefault(callbacksConfig.MaxBackoff, defaultMaxBackoff),
+		pollInterval:   orDefault(callbacksConfig.PollInterval, defaultPollInterval),
+	}
+	if dispatcher.maxAttempts <= 0 {
+		dispatcher.maxAttempts = defaultMaxAttempts
+	}
+	if len(dispatcher.secret) == 0 {
+		logger.Warn("No callback secret is configured, the callback payloads are not signed")
+	}
+	return dispatcher, nil
+}
+
+// Start polls the storage for due deliveries until the dispatcher is closed
+func (d *Dispatcher) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	d.cancel = cancel
+	d.wg.Add(1)
+	go func() {
+		defer d.wg.Done()
+		ticker := time.NewTicker(d.pollInterval)
+		defer ticker.Stop()
+		for {
+			if _, err := d.DispatchDue(ctx, time.Now().UTC()); err != nil {
+				d.logger.Error("Failed to dispatch the callback deliveries", "error", err.Error())
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Close stops the polling and waits for the deliveries in flight
+func (d *Dispatcher) Close() error {
+	if d.cancel != nil {
+		d.cancel()
+	}
+	d.wg.Wait()
+	return nil
+}
+
+// DispatchDue sends the deliveries that are due at the given time and returns how many were sent
+// successfully
+func (d *Dispatcher) DispatchDue(ctx context.Context, now time.Time) (int, error) {
+	// the lease covers a whole batch of requests that time out so that a delivery in flight is not
+	// claimed again by another replica
+	lease := time.Duration(batchSize+1) * d.client.Timeout
+	deliveries, err := d.storage.ClaimCallbackDeliveries(now, lease, batchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	delivered := 0
+	for i := range deliveries {
+		if ctx.Err() != nil {
+			// the claimed deliveries are retried when the lease expires
+			break
+		}
+		delivery := &deliveries[i]
+		if d.deliver(ctx, delivery, now) {
+			delivered++
+		}
+		if err := d.storage.WithTenant(delivery.Tenant).UpdateCallbackDelivery(delivery); err != nil {
+			if abstractions.IsNotFound(err) {
+				// the job has been deleted in the meantime
+				continue
+			}
+			return delivered, err
+		}
+	}
+	return delivered, nil
+}
+
+// deliver makes one attempt and records its outcome on the delivery
+func (d *Dispatcher) deliver(ctx context.Context, delivery *api.CallbackDeliveryResource, now time.Time) bool {
+	attempt := api.CallbackAttempt{AttemptedAt: now}
+	statusCode, err := d.send(ctx, delivery, now)
+	attempt.StatusCode = statusCode
+	if err != nil {
+		attempt.Error = err.Error()
+	}
+	delivery.Attempts = append(delivery.Attempts, attempt)
+	delivery.UpdatedAt = now
+
+	logger := d.logger.With("delivery_id", delivery.ID, "job_id", delivery.JobID, "tenant", delivery.Tenant, "attempt", len(delivery.Attempts))
+	switch {
+	case err == nil:
+		delivery.State = api.CallbackDeliveryDelivered
+		delivery.NextAttemptAt = nil
+		logger.Info("Delivered the callback", "event", delivery.Event)
+		return true
+	case len(delivery.Attempts) >= d.maxAttempts:
+		delivery.State = api.CallbackDeliveryDeadLetter
+		delivery.NextAttemptAt = nil
+		logger.Error("Moved the callback to the dead-letter list", "error", attempt.Error)
+	default:
+		next := now.Add(Backoff(len(delivery.Attempts), d.initialBackoff, d.maxBackoff))
+		delivery.NextAttemptAt = &next
+		logger.Warn("Failed to deliver the callback", "error", attempt.Error, "next_attempt_at", next)
+	}
+	return false
+}
+
+// send posts the signed payload, any status code other than 2xx is a failure
+func (d *Dispatcher) send(ctx context.Context, delivery *api.CallbackDeliveryResource, now time.Time) (int, error) {
+	body, err := json.Marshal(delivery.Payload)
+	if err != nil {
+		return 0, err
+	}
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set(HeaderEvent, string(delivery.Event))
+	request.Header.Set(HeaderDelivery, delivery.ID)
+	request.Header.Set(HeaderTimestamp, timestamp)
+	if len(d.secret) > 0 {
+		request.Header.Set(HeaderSignature, Sign(d.secret, timestamp, body))
+	}
+
+	response, err := d.client.Do(request)
+	if err != nil {
+		return 0, err
+	}
+	defer response.Body.Close()
+	if (response.StatusCode < 200) || (response.StatusCode >= 300) {
+		detail, _ := io.ReadAll(io.LimitReader(response.Body, maxErrorLength))
+		return response.StatusCode, fmt.Errorf("the callback URL returned %s: %s", response.Status, bytes.TrimSpace(detail))
+	}
+	return response.StatusCode, nil
+}
+
+func orDefault(value, fallback time.Duration) time.Duration {
+	if value <= 0 {
+		return fallback
+	}
+	return value
+}
@@ -0,0 +1,138 @@
+package callbacks
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/config"
+)
+
+// sharedAddressSpace is the carrier-grade NAT range of RFC 6598, which net.IP does not report as private
+var sharedAddressSpace = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+// Policy decides which hosts the callbacks may be sent to, so that the callback URLs can not be used
+// to reach the internal services of the deployment. Without allowed hosts any host with a public
+// address is allowed. With allowed hosts only the listed host names and the addresses in the listed
+// CIDRs are allowed, and they may be private. The URLs are checked when the jobs are submitted and
+// the addresses again when the callbacks are sent, so that host names that resolve to a denied
+// address are refused too.
+type Policy struct {
+	hosts    map[string]bool
+	networks []*net.IPNet
+}
+
+// NewPolicy returns the policy of the allowed hosts of the configuration
+func NewPolicy(callbacksConfig *config.CallbacksConfig) (*Policy, error) {
+	policy := &Policy{hosts: map[string]bool{}}
+	if callbacksConfig == nil {
+		return policy, nil
+	}
+	for _, host := range callbacksConfig.AllowedHosts {
+		host = strings.ToLower(strings.TrimSpace(host))
+		if !strings.Contains(host, "/") {
+			if host == "" {
+				return nil, fmt.Errorf("invalid callback allowed host %q", host)
+			}
+			policy.hosts[host] = true
+			continue
+		}
+		_, network, err := net.ParseCIDR(host)
+		if err != nil {
+			return nil, fmt.Errorf("invalid callback allowed network %q: %w", host, err)
+		}
+		policy.networks = append(policy.networks, network)
+	}
+	return policy, nil
+}
+
+// CheckURL returns an error when the host of the callback URL is not allowed, the addresses of the
+// host names that are not listed are checked when the callbacks are sent
+func (p *Policy) CheckURL(value string) error {
+	parsed, err := url.Parse(value)
+	if err != nil {
+		return fmt.Errorf("is not a valid URL: %w", err)
+	}
+	host := strings.ToLower(parsed.Hostname())
+	if p.hosts[host] {
+		return nil
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		if !p.allowsIP(ip) {
+			return fmt.Errorf("must not target the address %s", ip)
+		}
+		return nil
+	}
+	// the host names that resolve into the allowed networks are only known when the callbacks are sent
+	if p.restricted() && (len(p.networks) == 0) {
+		return fmt.Errorf("must target one of the allowed callback hosts")
+	}
+	if (host == "localhost") || strings.HasSuffix(host, ".localhost") {
+		return fmt.Errorf("must not target the host %s", host)
+	}
+	return nil
+}
+
+// Client returns an HTTP client that only connects to the allowed addresses, the proxies of the
+// environment are not used so that the address that is checked is the one that is connected to
+func (p *Policy) Client(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{Timeout: timeout}
+	checked := &net.Dialer{
+		Timeout: timeout,
+		Control: func(network string, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); (ip == nil) || !p.allowsIP(ip) {
+				return fmt.Errorf("the callback address %s is not allowed", host)
+			}
+			return nil
+		},
+	}
+	transport := &http.Transport{
+		DialContext: func(ctx context.Context, network string, address string) (net.Conn, error) {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return nil, err
+			}
+			if p.hosts[strings.ToLower(host)] {
+				return dialer.DialContext(ctx, network, address)
+			}
+			return checked.DialContext(ctx, network, address)
+		},
+		ForceAttemptHTTP2:   true,
+		TLSHandshakeTimeout: timeout,
+	}
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+		// the redirects could lead to a host that is not allowed, they are failed deliveries
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+func (p *Policy) restricted() bool {
+	return (len(p.hosts) > 0) || (len(p.networks) > 0)
+}
+
+// allowsIP reports whether the address is in an allowed network, or is public when no hosts are listed
+func (p *Policy) allowsIP(ip net.IP) bool {
+	for _, network := range p.networks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	if p.restricted() {
+		return false
+	}
+	return !(ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified() || sharedAddressSpace.Contains(ip))
+}
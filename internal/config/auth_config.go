@@ -0,0 +1,59 @@
+package config
+
+import "time"
+
+// AuthConfig configures the authentication of the requests, every request is anonymous when it is
+// not enabled
+type AuthConfig struct {
+	Enabled bool `mapstructure:"enabled,omitempty"`
+	// Exempt lists the paths that do not require authentication, a path ending in / exempts the
+	// paths below it
+	Exempt []string   `mapstructure:"exempt,omitempty"`
+	JWT    *JWTConfig `mapstructure:"jwt,omitempty"`
+	// APIKeys are checked when the request has no JWT
+	APIKeys []APIKeyConfig `mapstructure:"api_keys,omitempty"`
+	// APIKeysSecret is a YAML list of API keys in the format of APIKeys, it is read from the secrets
+	// directory and added to the configured API keys
+	APIKeysSecret string       `mapstructure:"api_keys_secret,omitempty"`
+	Roles         *RolesConfig `mapstructure:"roles,omitempty"`
+}
+
+// RolesConfig maps the claims of the principals to the roles, a claim value that is the name of a
+// role grants it directly
+type RolesConfig struct {
+	// Claim holds the roles or the groups of the principal, a string or a list of strings, dots
+	// select nested claims
+	Claim string `mapstructure:"claim,omitempty"`
+	// Mappings lists the claim values that grant each role
+	Mappings map[string][]string `mapstructure:"mappings,omitempty"`
+	// Default is the role of the principals that are granted no role, they are denied everything
+	// when it is not set
+	Default string `mapstructure:"default,omitempty"`
+}
+
+// JWTConfig configures the validation of the bearer JWTs, the signing keys are read from a local
+// JWKS file or from a JWKS URL
+type JWTConfig struct {
+	JWKSFile string `mapstructure:"jwks_file,omitempty"`
+	JWKSURL  string `mapstructure:"jwks_url,omitempty"`
+	// Issuer and Audience are checked against the iss and aud claims when they are set
+	Issuer   string `mapstructure:"issuer,omitempty"`
+	Audience string `mapstructure:"audience,omitempty"`
+	// Leeway tolerates the clock skew when checking the exp, nbf and iat claims
+	Leeway time.Duration `mapstructure:"leeway,omitempty"`
+	// RefreshInterval is the minimum delay between two reloads of the JWKS URL, the keys are
+	// reloaded when a token is signed by an unknown key. The reloads after a failed load back off
+	// up to this delay.
+	RefreshInterval time.Duration `mapstructure:"refresh_interval,omitempty"`
+}
+
+// APIKeyConfig is an API key identified by the SHA-256 hash of the key, the keys themselves are
+// never configured
+type APIKeyConfig struct {
+	// Name identifies the principal of the key
+	Name string `mapstructure:"name" json:"name"`
+	// SHA256 is the hex encoded SHA-256 hash of the key
+	SHA256 string `mapstructure:"sha256" json:"sha256"`
+	// Claims are the claims of the principal, such as the tenant
+	Claims map[string]any `mapstructure:"claims,omitempty" json:"claims,omitempty"`
+}
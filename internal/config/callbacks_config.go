@@ -0,0 +1,21 @@
+package config
+
+import "time"
+
+// CallbacksConfig configures the delivery of evaluation job events to the callback URLs
+type CallbacksConfig struct {
+	// Secret is the key of the HMAC signature of the payloads, the payloads are not signed without it
+	Secret string `mapstructure:"secret,omitempty"`
+	// MaxAttempts is the number of attempts after which a delivery is moved to the dead-letter list
+	MaxAttempts int `mapstructure:"max_attempts,omitempty"`
+	// InitialBackoff is the delay before the first retry, it doubles on every retry up to MaxBackoff
+	InitialBackoff time.Duration `mapstructure:"initial_backoff,omitempty"`
+	MaxBackoff     time.Duration `mapstructure:"max_backoff,omitempty"`
+	// PollInterval is how often the pending deliveries are looked up
+	PollInterval time.Duration `mapstructure:"poll_interval,omitempty"`
+	// Timeout is the timeout of a single delivery request
+	Timeout time.Duration `mapstructure:"timeout,omitempty"`
+	// AllowedHosts are the host names and the CIDRs the callbacks may be sent to, any public address
+	// is allowed and the private, loopback and link-local addresses are denied when none is set
+	AllowedHosts []string `mapstructure:"allowed_hosts,omitempty"`
+}
@@ -1,6 +1,14 @@
 package config
 
 type Config struct {
-	Service  *ServiceConfig  `json:"service"`
-	Database *DatabaseConfig `json:"database"`
+	Service    *ServiceConfig    `json:"service"`
+	Database   *DatabaseConfig   `json:"database"`
+	Runtime    *RuntimeConfig    `json:"runtime"`
+	Callbacks  *CallbacksConfig  `json:"callbacks"`
+	MLflow     *MLflowConfig     `json:"mlflow"`
+	Registry   *RegistryConfig   `json:"registry"`
+	Runners    *RunnersConfig    `json:"runners"`
+	Tenancy    *TenancyConfig    `json:"tenancy"`
+	Auth       *AuthConfig       `json:"auth"`
+	Validation *ValidationConfig `json:"validation"`
 }
@@ -1,6 +1,17 @@
 package config
 
+import (
+	"net"
+	"net/url"
+)
+
+const (
+	DatabaseDriverMemory   = "memory"
+	DatabaseDriverPostgres = "postgres"
+)
+
 type DatabaseConfig struct {
+	Driver   string `mapstructure:"driver,omitempty"`
 	URL      string `mapstructure:"url,omitempty"`
 	User     string `mapstructure:"user,omitempty"`
 	Password string `mapstructure:"password,omitempty"`
@@ -9,4 +20,35 @@ type DatabaseConfig struct {
 	Host     string `mapstructure:"host,omitempty"`
 	Schema   string `mapstructure:"schema,omitempty"`
 	SSLMode  string `mapstructure:"ssl_mode,omitempty"`
+	// AutoMigrate applies the pending schema migrations when the service starts
+	AutoMigrate bool `mapstructure:"auto_migrate,omitempty"`
+}
+
+// ConnectionURL returns the URL used to connect to the database. An explicit URL takes precedence,
+// otherwise the URL is built from the discrete fields. The schema is not part of the URL, it is
+// applied by the storage when the connection is established.
+func (c *DatabaseConfig) ConnectionURL() string {
+	if c.URL != "" {
+		return c.URL
+	}
+
+	connectionURL := url.URL{
+		Scheme: "postgres",
+		Host:   c.Host,
+		Path:   "/" + c.Name,
+	}
+	if c.Port != "" {
+		connectionURL.Host = net.JoinHostPort(c.Host, c.Port)
+	}
+	if c.User != "" {
+		if c.Password != "" {
+			connectionURL.User = url.UserPassword(c.User, c.Password)
+		} else {
+			connectionURL.User = url.User(c.User)
+		}
+	}
+	if c.SSLMode != "" {
+		connectionURL.RawQuery = url.Values{"sslmode": []string{c.SSLMode}}.Encode()
+	}
+	return connectionURL.String()
 }
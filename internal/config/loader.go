@@ -1,6 +1,7 @@
 package config
 
 import (
+	"errors"
 	"fmt"
 	"log/slog"
 	"os"
@@ -46,23 +47,26 @@ func LoadConfig(logger *slog.Logger) (*Config, error) {
 	// now load the cluster config if found
 	configValues, err := readConfig(logger, defaultConfigValues, "config", "yaml", ".", "..")
 	if err != nil {
-		return nil, err
+		var notFound viper.ConfigFileNotFoundError
+		if !errors.As(err, &notFound) {
+			return nil, err
+		}
 	}
 	// set up the secrets from the secrets directory
 	secretsDir := configValues.GetString("secrets.dir")
 	if secretsDir != "" {
-		mappings := configValues.GetStringMap("secrets.mappings")
+		mappings := flattenMappings("", configValues.GetStringMap("secrets.mappings"))
 		for fieldName, value := range mappings {
-			secret := getSecret(secretsDir, value.(string))
+			secret := getSecret(secretsDir, value)
 			if secret != "" {
 				configValues.Set(fieldName, secret)
 			}
 		}
 	}
 	// set up the environment variable mappings
-	envMappings := configValues.GetStringMap("env.mappings")
+	envMappings := flattenMappings("", configValues.GetStringMap("env.mappings"))
 	for fieldName, value := range envMappings {
-		envNames := strings.Split(value.(string), ",")
+		envNames := strings.Split(value, ",")
 		elems := make([]string, 0, len(envNames)+1)
 		elems = append(elems, fieldName)
 		elems = append(elems, envNames...)
@@ -76,10 +80,31 @@ func LoadConfig(logger *slog.Logger) (*Config, error) {
 	return &conf, nil
 }
 
+// flattenMappings converts the nested maps that viper creates for dotted keys such as service.port
+// back into a map from the dotted field name to the mapped value
+func flattenMappings(prefix string, mappings map[string]interface{}) map[string]string {
+	flattened := make(map[string]string)
+	for key, value := range mappings {
+		fieldName := key
+		if prefix != "" {
+			fieldName = prefix + "." + key
+		}
+		switch value := value.(type) {
+		case map[string]interface{}:
+			for nestedName, nestedValue := range flattenMappings(fieldName, value) {
+				flattened[nestedName] = nestedValue
+			}
+		default:
+			flattened[fieldName] = fmt.Sprintf("%v", value)
+		}
+	}
+	return flattened
+}
+
 func getSecret(secretsDir string, secretName string) string {
 	secret, err := os.ReadFile(fmt.Sprintf("%s/%s", secretsDir, secretName))
 	if err != nil {
 		return ""
 	}
-	return string(secret)
+	return strings.TrimSpace(string(secret))
 }
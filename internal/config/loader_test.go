@@ -0,0 +1,29 @@
+package config
+
+import "testing"
+
+func TestFlattenMappings(t *testing.T) {
+	mappings := map[string]interface{}{
+		"service": map[string]interface{}{"port": "PORT"},
+		"database": map[string]interface{}{
+			"url":    "DATABASE_URL",
+			"driver": "DATABASE_DRIVER",
+		},
+	}
+
+	flattened := flattenMappings("", mappings)
+
+	expected := map[string]string{
+		"service.port":    "PORT",
+		"database.url":    "DATABASE_URL",
+		"database.driver": "DATABASE_DRIVER",
+	}
+	if len(flattened) != len(expected) {
+		t.Fatalf("Expected %d mappings, got %v", len(expected), flattened)
+	}
+	for key, value := range expected {
+		if flattened[key] != value {
+			t.Errorf("Expected %s to map to %s, got %s", key, value, flattened[key])
+		}
+	}
+}
@@ -0,0 +1,11 @@
+package config
+
+import "time"
+
+// MLflowConfig configures the MLflow tracking server, experiments are not tracked without a URI
+type MLflowConfig struct {
+	TrackingURI string `mapstructure:"tracking_uri,omitempty"`
+	// Token is sent as a bearer token when set
+	Token   string        `mapstructure:"token,omitempty"`
+	Timeout time.Duration `mapstructure:"timeout,omitempty"`
+}
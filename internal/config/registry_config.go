@@ -0,0 +1,9 @@
+package config
+
+// RegistryConfig configures the registry of the providers and the benchmarks
+type RegistryConfig struct {
+	// Dir holds the YAML files defining the providers and the benchmarks, the registry is empty without it
+	Dir string `mapstructure:"dir,omitempty"`
+	// Watch reloads the registry when the files of the directory change
+	Watch bool `mapstructure:"watch,omitempty"`
+}
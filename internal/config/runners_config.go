@@ -0,0 +1,8 @@
+package config
+
+// RunnersConfig configures how the runtimes and the external runners authenticate when they report
+// the results of the benchmarks, the results are rejected when no token is set
+type RunnersConfig struct {
+	// Token is expected as a bearer token on the results endpoints
+	Token string `mapstructure:"token,omitempty"`
+}
@@ -0,0 +1,42 @@
+package config
+
+import "time"
+
+const (
+	RuntimeTypeLocal      = "local"
+	RuntimeTypeKubernetes = "kubernetes"
+)
+
+type RuntimeConfig struct {
+	// Type selects the runtime that runs the evaluation jobs, jobs stay pending when it is not set
+	Type       string                   `mapstructure:"type,omitempty"`
+	Local      *LocalRuntimeConfig      `mapstructure:"local,omitempty"`
+	Kubernetes *KubernetesRuntimeConfig `mapstructure:"kubernetes,omitempty"`
+}
+
+// LocalRuntimeConfig configures the runtime that runs each benchmark as a local process
+type LocalRuntimeConfig struct {
+	// Command is the command line of a benchmark run, every element is a Go text/template
+	Command []string `mapstructure:"command,omitempty"`
+	// Workers is the number of benchmarks that can run at the same time
+	Workers int `mapstructure:"workers,omitempty"`
+	// QueueSize is the number of benchmarks that can wait for a worker
+	QueueSize int `mapstructure:"queue_size,omitempty"`
+	// WorkDir holds the log file and the output directory of every benchmark run
+	WorkDir string `mapstructure:"work_dir,omitempty"`
+	// RetryDelay is the delay before the first retry of a failed benchmark, it doubles on every retry
+	RetryDelay time.Duration `mapstructure:"retry_delay,omitempty"`
+}
+
+// KubernetesRuntimeConfig configures the runtime that runs each benchmark as a Kubernetes Job
+type KubernetesRuntimeConfig struct {
+	// Kubeconfig is the path of the kubeconfig file, the in-cluster configuration is used when it is empty
+	Kubeconfig string `mapstructure:"kubeconfig,omitempty"`
+	// Namespace is the namespace of the Jobs
+	Namespace string `mapstructure:"namespace,omitempty"`
+	// JobTemplate is the path of a batch/v1 Job manifest that is rendered as a Go text/template for
+	// every benchmark, a Job running Image is used when it is empty
+	JobTemplate string `mapstructure:"job_template,omitempty"`
+	// Image is the evaluation image used by the default Job template
+	Image string `mapstructure:"image,omitempty"`
+}
@@ -2,4 +2,7 @@ package config
 
 type ServiceConfig struct {
 	Port int `mapstructure:"port,omitempty"`
+	// CursorSecret signs the pagination cursors, a random secret is used when it is not set, in which
+	// case the cursors are only valid for the running instance
+	CursorSecret string `mapstructure:"cursor_secret,omitempty"`
 }
@@ -0,0 +1,16 @@
+package config
+
+// TenancyConfig configures how the tenant of a request is resolved, the resources of a tenant are
+// not visible to the other tenants
+type TenancyConfig struct {
+	// Header holds the tenant of the anonymous requests
+	Header string `mapstructure:"header,omitempty"`
+	// Claim is the claim of the authenticated principal that holds the tenant, dots select nested
+	// claims
+	Claim string `mapstructure:"claim,omitempty"`
+	// TrustHeader lets the authenticated principals choose their tenant with the header when no
+	// claim is set, otherwise they use the default tenant
+	TrustHeader bool `mapstructure:"trust_header,omitempty"`
+	// Required rejects the requests without a tenant instead of using the default tenant
+	Required bool `mapstructure:"required,omitempty"`
+}
@@ -0,0 +1,12 @@
+package config
+
+// ValidationConfig configures the validation of the requests against the OpenAPI document
+type ValidationConfig struct {
+	// Enabled rejects the requests whose parameters or body do not match their operation with 422
+	Enabled bool `mapstructure:"enabled,omitempty"`
+	// SpecFile is the OpenAPI document, a relative path is also looked up next to the executable
+	SpecFile string `mapstructure:"spec_file,omitempty"`
+	// Strict also validates the JSON responses and replaces those that do not match the document
+	// with 500, it catches the drift between the API structures and the document in the tests
+	Strict bool `mapstructure:"strict,omitempty"`
+}
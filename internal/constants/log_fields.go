@@ -14,4 +14,5 @@ const (
   LOG_REFERER    = "referer"
   LOG_USER_AGENT = "user_agent"
   LOG_ELAPSED    = "elapsed"
+  LOG_TENANT     = "tenant"
 )
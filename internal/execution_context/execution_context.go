@@ -5,14 +5,28 @@ import (
 	"net/http"
 	"time"
 
+	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/abstractions"
+	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/auth"
 	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/config"
+	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/constants"
 	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/logging"
+	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/rbac"
+	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/tenancy"
+	"github.ibm.com/julpayne/eval-hub-backend-svc/pkg/api"
 )
 
 // ExecutionContext contains execution context for API operations
 type ExecutionContext struct {
-	Logger       *slog.Logger
-	Config       *config.Config
+	Logger *slog.Logger
+	Config *config.Config
+	// Principal is the authenticated caller, it is nil when the authentication is not enabled or the
+	// route is exempt
+	Principal *auth.Principal
+	// Authorization holds the roles of the principal, it is nil and allows everything when the
+	// request is not authorized
+	Authorization *rbac.Authorization
+	// Tenant scopes every storage call of the request
+	Tenant       api.Tenant
 	EvaluationID string
 	ModelURL     string
 	ModelName    string
@@ -22,18 +36,28 @@ type ExecutionContext struct {
 	RetryAttempts  int
 	StartedAt      *time.Time
 	Metadata       map[string]interface{}
-	MLflowClient   interface{}
+	// MLflowClient tracks the experiments of the evaluation jobs, it is nil when no tracking is configured
+	MLflowClient   abstractions.ExperimentTracker
 	ExperimentName *string
 }
 
 // NewExecutionContext creates a new ExecutionContext with default values
 func NewExecutionContext(r *http.Request, logger *slog.Logger, serviceConfig *config.Config) *ExecutionContext {
 	// Enhance logger with request-specific fields
-	enhancedLogger := logging.LoggerWithRequest(logger, r)
+	// The tenant is resolved by the tenancy middleware, requests that did not go through it use the default tenant
+	tenant := tenancy.FromContext(r.Context())
+	enhancedLogger := logging.LoggerWithRequest(logger, r).With(constants.LOG_TENANT, string(tenant))
+	principal := auth.FromContext(r.Context())
+	if principal != nil {
+		enhancedLogger = enhancedLogger.With(constants.LOG_USER, principal.Subject)
+	}
 
 	return &ExecutionContext{
 		Logger:         enhancedLogger,
 		Config:         serviceConfig,
+		Principal:      principal,
+		Authorization:  rbac.FromContext(r.Context()),
+		Tenant:         tenant,
 		TimeoutMinutes: 60,
 		RetryAttempts:  3,
 		Metadata:       make(map[string]interface{}),
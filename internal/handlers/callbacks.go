@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/abstractions"
+	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/apierrors"
+	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/execution_context"
+	"github.ibm.com/julpayne/eval-hub-backend-svc/pkg/api"
+)
+
+// HandleListEvaluationCallbacks handles GET /api/v1/evaluations/jobs/{id}/callbacks, it lists the
+// deliveries of the job events to its callback URL together with their attempts
+func (h *Handlers) HandleListEvaluationCallbacks(ctx *execution_context.ExecutionContext, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(ctx, w, r, apierrors.MethodNotAllowed(r.Method))
+		return
+	}
+
+	id := parentPathID(r)
+	if _, err := h.tenantStorage(ctx).GetEvaluationJob(id); err != nil {
+		writeError(ctx, w, r, err)
+		return
+	}
+	query, err := callbackQuery(r)
+	if err != nil {
+		writeError(ctx, w, r, apierrors.BadRequest(err.Error()))
+		return
+	}
+	query[abstractions.QueryJobID] = id
+	h.listCallbacks(ctx, w, r, query)
+}
+
+// HandleListCallbacks handles GET /api/v1/evaluations/callbacks, ?state=dead_letter lists the
+// deliveries that have exhausted their attempts
+func (h *Handlers) HandleListCallbacks(ctx *execution_context.ExecutionContext, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(ctx, w, r, apierrors.MethodNotAllowed(r.Method))
+		return
+	}
+
+	query, err := callbackQuery(r)
+	if err != nil {
+		writeError(ctx, w, r, apierrors.BadRequest(err.Error()))
+		return
+	}
+	if jobID := r.URL.Query().Get("job_id"); jobID != "" {
+		query[abstractions.QueryJobID] = jobID
+	}
+	h.listCallbacks(ctx, w, r, query)
+}
+
+func (h *Handlers) listCallbacks(ctx *execution_context.ExecutionContext, w http.ResponseWriter, r *http.Request, query abstractions.Query) {
+	page, err := parsePageRequest(ctx, r, query)
+	if err != nil {
+		writeError(ctx, w, r, apierrors.BadRequest(err.Error()))
+		return
+	}
+	list, err := h.tenantStorage(ctx).GetCallbackDeliveries(query)
+	if err != nil {
+		writeError(ctx, w, r, err)
+		return
+	}
+	list.Items = paginate(ctx, page, &list.Page, list.Items, func(delivery *api.CallbackDeliveryResource) string {
+		return abstractions.PositionAfter(&delivery.Resource)
+	})
+	writeJSON(ctx, w, http.StatusOK, list)
+}
+
+// callbackQuery validates the state filter of the callback listings
+func callbackQuery(r *http.Request) (abstractions.Query, error) {
+	query := abstractions.Query{}
+	if state := r.URL.Query().Get("state"); state != "" {
+		switch api.CallbackDeliveryState(state) {
+		case api.CallbackDeliveryPending, api.CallbackDeliveryDelivered, api.CallbackDeliveryDeadLetter:
+			query[abstractions.QueryState] = state
+		default:
+			return nil, fmt.Errorf("invalid value %q for the state parameter", state)
+		}
+	}
+	return query, nil
+}
+
+// parentPathID returns the segment before the last one, which is the ID for the sub-resource endpoints
+func parentPathID(r *http.Request) string {
+	pathParts := strings.Split(strings.TrimSuffix(r.URL.Path, "/"), "/")
+	if len(pathParts) < 2 {
+		return ""
+	}
+	return pathParts[len(pathParts)-2]
+}
@@ -0,0 +1,259 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/abstractions"
+	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/apierrors"
+	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/execution_context"
+	"github.ibm.com/julpayne/eval-hub-backend-svc/pkg/api"
+
+	"github.com/google/uuid"
+)
+
+// collectionsPath is the collection path of the collections, a collection is located at collectionsPath/{id}
+const collectionsPath = "/api/v1/evaluations/collections"
+
+// HandleListCollections handles GET /api/v1/evaluations/collections, the collections are listed by
+// creation time and the next page is linked with a cursor
+func (h *Handlers) HandleListCollections(ctx *execution_context.ExecutionContext, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(ctx, w, r, apierrors.MethodNotAllowed(r.Method))
+		return
+	}
+
+	query := abstractions.Query{}
+	page, err := parsePageRequest(ctx, r, query)
+	if err != nil {
+		writeError(ctx, w, r, apierrors.BadRequest(err.Error()))
+		return
+	}
+
+	list, err := h.tenantStorage(ctx).GetCollections(query)
+	if err != nil {
+		writeError(ctx, w, r, err)
+		return
+	}
+	list.Items = paginate(ctx, page, &list.Page, list.Items, func(collection *api.CollectionResource) string {
+		return abstractions.PositionAfter(&collection.Resource)
+	})
+	writeJSON(ctx, w, http.StatusOK, list)
+}
+
+// HandleCreateCollection handles POST /api/v1/evaluations/collections
+func (h *Handlers) HandleCreateCollection(ctx *execution_context.ExecutionContext, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(ctx, w, r, apierrors.MethodNotAllowed(r.Method))
+		return
+	}
+
+	config := api.CollectionConfig{}
+	if err := decodeJSON(r, w, &config); err != nil {
+		writeError(ctx, w, r, apierrors.BadRequest(err.Error()))
+		return
+	}
+	if err := h.validateCollectionConfig(&config); err != nil {
+		writeError(ctx, w, r, apierrors.AsValidation(err))
+		return
+	}
+
+	collection := &api.CollectionResource{
+		Resource:         api.Resource{ID: uuid.New().String()},
+		CollectionConfig: config,
+	}
+	if err := h.tenantStorage(ctx).CreateCollection(collection); err != nil {
+		writeError(ctx, w, r, err)
+		return
+	}
+	ctx.Logger.Info("Created collection", "collection_id", collection.ID, "benchmarks", len(config.Benchmarks))
+
+	w.Header().Set("Location", collectionsPath+"/"+collection.ID)
+	writeJSON(ctx, w, http.StatusCreated, collection)
+}
+
+// HandleGetCollection handles GET /api/v1/evaluations/collections/{collection_id}
+func (h *Handlers) HandleGetCollection(ctx *execution_context.ExecutionContext, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(ctx, w, r, apierrors.MethodNotAllowed(r.Method))
+		return
+	}
+
+	collection, err := h.tenantStorage(ctx).GetCollection(pathID(r))
+	if err != nil {
+		writeError(ctx, w, r, err)
+		return
+	}
+	writeJSON(ctx, w, http.StatusOK, collection)
+}
+
+// HandleUpdateCollection handles PUT /api/v1/evaluations/collections/{collection_id}, the body
+// replaces the whole configuration of the collection
+func (h *Handlers) HandleUpdateCollection(ctx *execution_context.ExecutionContext, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		writeError(ctx, w, r, apierrors.MethodNotAllowed(r.Method))
+		return
+	}
+
+	config := api.CollectionConfig{}
+	if err := decodeJSON(r, w, &config); err != nil {
+		writeError(ctx, w, r, apierrors.BadRequest(err.Error()))
+		return
+	}
+	if err := h.validateCollectionConfig(&config); err != nil {
+		writeError(ctx, w, r, apierrors.AsValidation(err))
+		return
+	}
+
+	collection := &api.CollectionResource{
+		Resource:         api.Resource{ID: pathID(r)},
+		CollectionConfig: config,
+	}
+	if err := h.tenantStorage(ctx).UpdateCollection(collection); err != nil {
+		writeError(ctx, w, r, err)
+		return
+	}
+	ctx.Logger.Info("Updated collection", "collection_id", collection.ID)
+	writeJSON(ctx, w, http.StatusOK, collection)
+}
+
+// HandlePatchCollection handles PATCH /api/v1/evaluations/collections/{collection_id}. The
+// operations are applied in order to a copy of the collection, which is only stored when all of
+// them succeed and the result is a valid collection.
+func (h *Handlers) HandlePatchCollection(ctx *execution_context.ExecutionContext, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPatch {
+		writeError(ctx, w, r, apierrors.MethodNotAllowed(r.Method))
+		return
+	}
+
+	patch := api.Patch{}
+	if err := decodeJSON(r, w, &patch); err != nil {
+		writeError(ctx, w, r, apierrors.BadRequest(err.Error()))
+		return
+	}
+
+	// the patch is applied by the storage to the stored collection so that concurrent patches are not lost
+	collection, err := h.tenantStorage(ctx).PatchCollection(pathID(r), func(config *api.CollectionConfig) error {
+		if err := applyCollectionPatch(config, patch); err != nil {
+			return apierrors.AsValidation(err)
+		}
+		if err := h.validateCollectionConfig(config); err != nil {
+			return apierrors.AsValidation(err)
+		}
+		return nil
+	})
+	if err != nil {
+		writeError(ctx, w, r, err)
+		return
+	}
+	ctx.Logger.Info("Patched collection", "collection_id", collection.ID, "operations", len(patch))
+	writeJSON(ctx, w, http.StatusOK, collection)
+}
+
+// HandleDeleteCollection handles DELETE /api/v1/evaluations/collections/{collection_id}
+func (h *Handlers) HandleDeleteCollection(ctx *execution_context.ExecutionContext, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		writeError(ctx, w, r, apierrors.MethodNotAllowed(r.Method))
+		return
+	}
+
+	id := pathID(r)
+	if err := h.tenantStorage(ctx).DeleteCollection(id); err != nil {
+		writeError(ctx, w, r, err)
+		return
+	}
+	ctx.Logger.Info("Deleted collection", "collection_id", id)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// validateCollectionConfig checks that the collection is named and that its benchmarks are unique
+// and defined in the registry
+func (h *Handlers) validateCollectionConfig(config *api.CollectionConfig) error {
+	if strings.TrimSpace(config.Name) == "" {
+		return apierrors.Invalid("name is required", "body", "name")
+	}
+	if len(config.Benchmarks) == 0 {
+		return apierrors.Invalid("at least one benchmark is required", "body", "benchmarks")
+	}
+	for i, id := range config.Benchmarks {
+		if _, found := h.registry.Benchmark(id); !found {
+			return apierrors.Invalid(fmt.Sprintf("benchmarks[%d] %q is not a known benchmark", i, id), "body", "benchmarks", i)
+		}
+		if slices.Contains(config.Benchmarks[:i], id) {
+			return apierrors.Invalid(fmt.Sprintf("benchmarks[%d] %q is listed more than once", i, id), "body", "benchmarks", i)
+		}
+	}
+	return nil
+}
+
+// applyCollectionPatch applies the operations to the configuration. The supported paths are name,
+// description, benchmarks/- (add only) and benchmarks/{index}, with or without a leading slash.
+func applyCollectionPatch(config *api.CollectionConfig, patch api.Patch) error {
+	for i, operation := range patch {
+		if err := applyCollectionOperation(config, operation); err != nil {
+			return fmt.Errorf("operation %d (%s %s): %w", i, operation.Op, operation.Path, err)
+		}
+	}
+	return nil
+}
+
+func applyCollectionOperation(config *api.CollectionConfig, operation api.PatchOperation) error {
+	switch operation.Op {
+	case api.PatchOpAdd, api.PatchOpReplace, api.PatchOpRemove:
+	default:
+		return fmt.Errorf("unsupported operation, expected add, replace or remove")
+	}
+	value, isString := operation.Value.(string)
+	if (operation.Op != api.PatchOpRemove) && !isString {
+		return fmt.Errorf("the value must be a string")
+	}
+
+	path := strings.TrimPrefix(operation.Path, "/")
+	switch path {
+	case "name":
+		if operation.Op == api.PatchOpRemove {
+			return fmt.Errorf("the name can not be removed")
+		}
+		config.Name = value
+		return nil
+	case "description":
+		if operation.Op == api.PatchOpRemove {
+			config.Description = nil
+		} else {
+			config.Description = &value
+		}
+		return nil
+	case "benchmarks/-":
+		if operation.Op != api.PatchOpAdd {
+			return fmt.Errorf("only add is supported at the end of the benchmarks")
+		}
+		config.Benchmarks = append(config.Benchmarks, value)
+		return nil
+	}
+
+	index, found := strings.CutPrefix(path, "benchmarks/")
+	position, err := strconv.Atoi(index)
+	if !found || (err != nil) || (position < 0) || (strconv.Itoa(position) != index) {
+		return fmt.Errorf("unsupported path, expected name, description, benchmarks/- or benchmarks/{index}")
+	}
+	// an element can be added right after the last one
+	last := len(config.Benchmarks) - 1
+	if operation.Op == api.PatchOpAdd {
+		last++
+	}
+	if position > last {
+		return fmt.Errorf("the index is out of range, the collection has %d benchmarks", len(config.Benchmarks))
+	}
+	switch operation.Op {
+	case api.PatchOpAdd:
+		config.Benchmarks = slices.Insert(config.Benchmarks, position, value)
+	case api.PatchOpReplace:
+		config.Benchmarks[position] = value
+	case api.PatchOpRemove:
+		config.Benchmarks = slices.Delete(config.Benchmarks, position, position+1)
+	}
+	return nil
+}
@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/storage/memory"
+	"github.ibm.com/julpayne/eval-hub-backend-svc/pkg/api"
+)
+
+func callCollections(t *testing.T, h *Handlers, method string, target string, body string) (*httptest.ResponseRecorder, api.CollectionResource) {
+	t.Helper()
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	w := httptest.NewRecorder()
+	ctx := newTestContext(t, req)
+	switch method {
+	case http.MethodPost:
+		h.HandleCreateCollection(ctx, w, req)
+	case http.MethodGet:
+		h.HandleGetCollection(ctx, w, req)
+	case http.MethodPut:
+		h.HandleUpdateCollection(ctx, w, req)
+	case http.MethodPatch:
+		h.HandlePatchCollection(ctx, w, req)
+	case http.MethodDelete:
+		h.HandleDeleteCollection(ctx, w, req)
+	}
+	var collection api.CollectionResource
+	json.Unmarshal(w.Body.Bytes(), &collection)
+	return w, collection
+}
+
+func TestCollections(t *testing.T) {
+	h := New(memory.NewStorage(), nil, newTestRegistry(t, testRegistry))
+
+	w, created := callCollections(t, h, http.MethodPost, collectionsPath, `{"name": "knowledge", "benchmarks": ["mmlu"]}`)
+	if (w.Code != http.StatusCreated) || (created.ID == "") || (w.Header().Get("Location") != collectionsPath+"/"+created.ID) {
+		t.Fatalf("Expected the collection to be created, got %d: %s", w.Code, w.Body.String())
+	}
+	path := collectionsPath + "/" + created.ID
+
+	t.Run("get returns the collection", func(t *testing.T) {
+		w, got := callCollections(t, h, http.MethodGet, path, "")
+		if (w.Code != http.StatusOK) || (got.Name != "knowledge") || (len(got.Benchmarks) != 1) {
+			t.Errorf("Expected the created collection, got %d: %s", w.Code, w.Body.String())
+		}
+	})
+
+	t.Run("put replaces the configuration", func(t *testing.T) {
+		w, got := callCollections(t, h, http.MethodPut, path, `{"name": "reasoning", "description": "all", "benchmarks": ["arc", "mmlu"]}`)
+		if (w.Code != http.StatusOK) || (got.Name != "reasoning") || (*got.Description != "all") || (got.CreatedAt != created.CreatedAt) {
+			t.Errorf("Expected the collection to be replaced, got %d: %s", w.Code, w.Body.String())
+		}
+	})
+
+	t.Run("patch applies all the operations", func(t *testing.T) {
+		w, got := callCollections(t, h, http.MethodPatch, path, `[
+		  {"op": "replace", "path": "/name", "value": "patched"},
+		  {"op": "remove", "path": "description"},
+		  {"op": "remove", "path": "/benchmarks/0"},
+		  {"op": "add", "path": "/benchmarks/0", "value": "arc"},
+		  {"op": "replace", "path": "/benchmarks/1", "value": "mmlu"}
+		]`)
+		if (w.Code != http.StatusOK) || (got.Name != "patched") || (got.Description != nil) || (strings.Join(got.Benchmarks, ",") != "arc,mmlu") {
+			t.Errorf("Expected the patched collection, got %d: %s", w.Code, w.Body.String())
+		}
+	})
+
+	t.Run("a failing patch changes nothing", func(t *testing.T) {
+		testCases := []struct {
+			name  string
+			patch string
+		}{
+			{"unknown path", `[{"op": "replace", "path": "/name", "value": "x"}, {"op": "replace", "path": "/id", "value": "x"}]`},
+			{"unknown benchmark", `[{"op": "add", "path": "/benchmarks/-", "value": "hellaswag"}]`},
+			{"duplicate benchmark", `[{"op": "add", "path": "/benchmarks/-", "value": "arc"}]`},
+			{"index out of range", `[{"op": "remove", "path": "/benchmarks/2"}]`},
+			{"invalid index", `[{"op": "replace", "path": "/benchmarks/01", "value": "arc"}]`},
+			{"replace at the end", `[{"op": "replace", "path": "/benchmarks/-", "value": "arc"}]`},
+			{"no benchmarks left", `[{"op": "remove", "path": "/benchmarks/0"}, {"op": "remove", "path": "/benchmarks/0"}]`},
+			{"non string value", `[{"op": "replace", "path": "/name", "value": 1}]`},
+			{"unsupported operation", `[{"op": "move", "path": "/name"}]`},
+			{"name removed", `[{"op": "remove", "path": "/name"}]`},
+		}
+		for _, tc := range testCases {
+			if w, _ := callCollections(t, h, http.MethodPatch, path, tc.patch); w.Code != http.StatusUnprocessableEntity {
+				t.Errorf("%s: expected status code %d, got %d: %s", tc.name, http.StatusUnprocessableEntity, w.Code, w.Body.String())
+			}
+		}
+		_, got := callCollections(t, h, http.MethodGet, path, "")
+		if (got.Name != "patched") || (strings.Join(got.Benchmarks, ",") != "arc,mmlu") {
+			t.Errorf("Expected the collection to be unchanged, got %+v", got.CollectionConfig)
+		}
+	})
+
+	t.Run("invalid collections are rejected", func(t *testing.T) {
+		testCases := []struct {
+			name   string
+			body   string
+			status int
+		}{
+			{"empty body", ``, http.StatusBadRequest},
+			{"unknown field", `{"name": "x", "benchmarks": ["mmlu"], "id": "x"}`, http.StatusBadRequest},
+			{"missing name", `{"benchmarks": ["mmlu"]}`, http.StatusUnprocessableEntity},
+			{"no benchmarks", `{"name": "x", "benchmarks": []}`, http.StatusUnprocessableEntity},
+			{"unknown benchmark", `{"name": "x", "benchmarks": ["mmlu", "hellaswag"]}`, http.StatusUnprocessableEntity},
+		}
+		for _, tc := range testCases {
+			if w, _ := callCollections(t, h, http.MethodPost, collectionsPath, tc.body); w.Code != tc.status {
+				t.Errorf("%s: expected status code %d, got %d: %s", tc.name, tc.status, w.Code, w.Body.String())
+			}
+		}
+	})
+
+	t.Run("delete removes the collection", func(t *testing.T) {
+		if w, _ := callCollections(t, h, http.MethodDelete, path, ""); w.Code != http.StatusNoContent {
+			t.Errorf("Expected status code %d, got %d", http.StatusNoContent, w.Code)
+		}
+		for _, method := range []string{http.MethodGet, http.MethodDelete, http.MethodPatch} {
+			if w, _ := callCollections(t, h, method, path, `[]`); w.Code != http.StatusNotFound {
+				t.Errorf("Expected status code %d for %s, got %d", http.StatusNotFound, method, w.Code)
+			}
+		}
+		if w, _ := callCollections(t, h, http.MethodPut, path, `{"name": "x", "benchmarks": ["mmlu"]}`); w.Code != http.StatusNotFound {
+			t.Errorf("Expected status code %d for PUT, got %d", http.StatusNotFound, w.Code)
+		}
+	})
+}
@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/aggregation"
+	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/apierrors"
+	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/execution_context"
+	"github.ibm.com/julpayne/eval-hub-backend-svc/pkg/api"
+)
+
+const (
+	// maxComparedJobs limits the number of jobs that are compared at once
+	maxComparedJobs = 20
+
+	contentTypeJSON = "application/json"
+	contentTypeCSV  = "text/csv"
+)
+
+// comparisonCSVHeader is the header of the CSV comparisons, which have a row per benchmark, metric
+// and job. A benchmark missing from a job has a single row for the job with an empty metric.
+var comparisonCSVHeader = []string{"benchmark_id", "metric", "job_id", "model_name", "value", "delta", "missing"}
+
+// HandleCompareEvaluations handles GET /api/v1/evaluations/compare?jobs=a,b,c. The metrics of the
+// jobs are aligned by benchmark and metric with their deltas against the baseline job, which is the
+// first job unless ?baseline= names another one. The comparison is returned as JSON or, when the
+// Accept header prefers it, as CSV.
+func (h *Handlers) HandleCompareEvaluations(ctx *execution_context.ExecutionContext, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(ctx, w, r, apierrors.MethodNotAllowed(r.Method))
+		return
+	}
+
+	contentType := negotiateContentType(r, contentTypeJSON, contentTypeCSV)
+	if contentType == "" {
+		writeError(ctx, w, r, apierrors.NotAcceptable(fmt.Sprintf("the comparison is available as %s or %s", contentTypeJSON, contentTypeCSV)))
+		return
+	}
+	ids, baseline, err := parseComparisonQuery(r)
+	if err != nil {
+		writeError(ctx, w, r, apierrors.BadRequest(err.Error()))
+		return
+	}
+
+	jobs := make([]*api.EvaluationJobResource, 0, len(ids))
+	for _, id := range ids {
+		job, err := h.tenantStorage(ctx).GetEvaluationJob(id)
+		if err != nil {
+			writeError(ctx, w, r, err)
+			return
+		}
+		jobs = append(jobs, job)
+	}
+	comparison := aggregation.Compare(jobs, baseline)
+
+	if contentType == contentTypeCSV {
+		writeComparisonCSV(ctx, w, comparison)
+		return
+	}
+	writeJSON(ctx, w, http.StatusOK, comparison)
+}
+
+// parseComparisonQuery returns the IDs of the compared jobs, in order, and the baseline job
+func parseComparisonQuery(r *http.Request) ([]string, string, error) {
+	ids := []string{}
+	for _, value := range r.URL.Query()["jobs"] {
+		for _, id := range strings.Split(value, ",") {
+			id = strings.TrimSpace(id)
+			if id == "" {
+				continue
+			}
+			if slices.Contains(ids, id) {
+				return nil, "", fmt.Errorf("job %s is listed more than once", id)
+			}
+			ids = append(ids, id)
+		}
+	}
+	if (len(ids) < 2) || (len(ids) > maxComparedJobs) {
+		return nil, "", fmt.Errorf("the jobs parameter must list between 2 and %d job IDs, got %d", maxComparedJobs, len(ids))
+	}
+
+	baseline := r.URL.Query().Get("baseline")
+	if baseline == "" {
+		baseline = ids[0]
+	} else if !slices.Contains(ids, baseline) {
+		return nil, "", fmt.Errorf("the baseline %s must be one of the compared jobs", baseline)
+	}
+	return ids, baseline, nil
+}
+
+// writeComparisonCSV writes the comparison with a row per benchmark, metric and job
+func writeComparisonCSV(ctx *execution_context.ExecutionContext, w http.ResponseWriter, comparison *api.EvaluationComparison) {
+	w.Header().Set("Content-Type", contentTypeCSV+"; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+
+	formatFloat := func(value float64) string {
+		return strconv.FormatFloat(value, 'g', -1, 64)
+	}
+	writer := csv.NewWriter(w)
+	writer.Write(comparisonCSVHeader)
+	for _, benchmark := range comparison.Benchmarks {
+		for _, job := range comparison.Jobs {
+			if slices.Contains(benchmark.MissingFrom, job.ID) {
+				writer.Write([]string{benchmark.ID, "", job.ID, job.Model.Name, "", "", "true"})
+			}
+		}
+		for _, metric := range benchmark.Metrics {
+			for _, job := range comparison.Jobs {
+				if slices.Contains(benchmark.MissingFrom, job.ID) {
+					continue
+				}
+				value, delta := "", ""
+				if number, found := metric.Values[job.ID]; found {
+					value = formatFloat(number)
+				}
+				if number, found := metric.Deltas[job.ID]; found {
+					delta = formatFloat(number)
+				}
+				writer.Write([]string{benchmark.ID, metric.Name, job.ID, job.Model.Name, value, delta, "false"})
+			}
+		}
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		ctx.Logger.Error("Failed to write the response", "error", err.Error())
+	}
+}
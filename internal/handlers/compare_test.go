@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/storage/memory"
+	"github.ibm.com/julpayne/eval-hub-backend-svc/pkg/api"
+)
+
+func TestHandleCompareEvaluations(t *testing.T) {
+	storage := memory.NewStorage()
+	h := New(storage, nil, nil)
+	ids := []string{}
+	for _, acc := range []float64{0.5, 0.75} {
+		var job api.EvaluationJobResource
+		json.Unmarshal(createJob(t, h, validJob).Body.Bytes(), &job)
+		storage.RecordBenchmarkResult(job.ID, api.EvaluationJobBenchmarkResult{ID: "mmlu", State: api.StateCompleted, AttemptID: "1", Metrics: map[string]any{"acc": acc}})
+		ids = append(ids, job.ID)
+	}
+	compare := func(target string, accept string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		w := httptest.NewRecorder()
+		h.HandleCompareEvaluations(newTestContext(t, req), w, req)
+		return w
+	}
+	target := "/api/v1/evaluations/compare?jobs=" + strings.Join(ids, ",")
+
+	t.Run("the comparison is returned as JSON by default", func(t *testing.T) {
+		for _, accept := range []string{"", "application/json", "*/*", "text/csv;q=0.5, application/*"} {
+			w := compare(target+"&baseline="+ids[1], accept)
+			var comparison api.EvaluationComparison
+			json.Unmarshal(w.Body.Bytes(), &comparison)
+			if (w.Code != http.StatusOK) || (w.Header().Get("Content-Type") != "application/json") || (comparison.Baseline != ids[1]) {
+				t.Fatalf("Expected a JSON comparison for %q, got %d: %s", accept, w.Code, w.Body.String())
+			}
+			if (len(comparison.Benchmarks) != 2) || (comparison.Benchmarks[0].Metrics[0].Deltas[ids[0]] != -0.25) ||
+				(len(comparison.Benchmarks[1].MissingFrom) != 2) {
+				t.Errorf("Unexpected comparison %s", w.Body.String())
+			}
+		}
+	})
+
+	t.Run("the comparison is returned as CSV when preferred", func(t *testing.T) {
+		w := compare(target, "application/json;q=0.5, text/csv")
+		if (w.Code != http.StatusOK) || !strings.HasPrefix(w.Header().Get("Content-Type"), "text/csv") {
+			t.Fatalf("Expected a CSV comparison, got %d: %s", w.Code, w.Body.String())
+		}
+		records, err := csv.NewReader(w.Body).ReadAll()
+		if err != nil {
+			t.Fatalf("Failed to read the CSV: %v", err)
+		}
+		expected := [][]string{
+			comparisonCSVHeader,
+			{"mmlu", "acc", ids[0], "granite", "0.5", "0", "false"},
+			{"mmlu", "acc", ids[1], "granite", "0.75", "0.25", "false"},
+			{"arc", "", ids[0], "granite", "", "", "true"},
+			{"arc", "", ids[1], "granite", "", "", "true"},
+		}
+		if len(records) != len(expected) {
+			t.Fatalf("Expected %d records, got %v", len(expected), records)
+		}
+		for i := range expected {
+			if strings.Join(records[i], ",") != strings.Join(expected[i], ",") {
+				t.Errorf("Expected record %d to be %v, got %v", i, expected[i], records[i])
+			}
+		}
+	})
+
+	t.Run("invalid comparisons are rejected", func(t *testing.T) {
+		testCases := []struct {
+			name   string
+			target string
+			accept string
+			status int
+		}{
+			{"a single job", "/api/v1/evaluations/compare?jobs=" + ids[0], "", http.StatusBadRequest},
+			{"duplicate jobs", "/api/v1/evaluations/compare?jobs=" + ids[0] + "," + ids[0], "", http.StatusBadRequest},
+			{"unknown baseline", target + "&baseline=other", "", http.StatusBadRequest},
+			{"missing job", target + ",missing", "", http.StatusNotFound},
+			{"unsupported media type", target, "application/xml", http.StatusNotAcceptable},
+		}
+		for _, tc := range testCases {
+			if w := compare(tc.target, tc.accept); w.Code != tc.status {
+				t.Errorf("%s: expected status code %d, got %d: %s", tc.name, tc.status, w.Code, w.Body.String())
+			}
+		}
+	})
+}
@@ -2,10 +2,24 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"net/url"
+	"strconv"
 	"strings"
 
+	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/abstractions"
+	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/aggregation"
+	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/apierrors"
+	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/callbacks"
 	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/execution_context"
+	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/rbac"
+	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/registry"
+	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/state_machine"
+	"github.ibm.com/julpayne/eval-hub-backend-svc/pkg/api"
+
+	"github.com/google/uuid"
 )
 
 // BackendSpec represents the backend specification
@@ -21,220 +35,513 @@ type BenchmarkSpec struct {
 	Config      map[string]interface{} `json:"config,omitempty"`
 }
 
+const (
+	maxTimeoutMinutes = 24 * 60
+	maxRetryAttempts  = 10
+	// runtimeBusyRetryAfter is the Retry-After delay in seconds of the jobs that a busy runtime
+	// has no room for
+	runtimeBusyRetryAfter = 30
+)
+
+// evaluationJobsPath is the collection path of the evaluation jobs, a job is located at evaluationJobsPath/{id}
+const evaluationJobsPath = "/api/v1/evaluations/jobs"
+
 // HandleCreateEvaluation handles POST /api/v1/evaluations/jobs
 func (h *Handlers) HandleCreateEvaluation(ctx *execution_context.ExecutionContext, w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		writeError(ctx, w, r, apierrors.MethodNotAllowed(r.Method))
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusAccepted)
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"message": "Evaluation creation not yet implemented",
-	})
+	config := api.EvaluationJobConfig{}
+	if err := decodeJSON(r, w, &config); err != nil {
+		writeError(ctx, w, r, apierrors.BadRequest(err.Error()))
+		return
+	}
+	if config.TimeoutMinutes == nil {
+		config.TimeoutMinutes = &ctx.TimeoutMinutes
+	}
+	if config.RetryAttempts == nil {
+		config.RetryAttempts = &ctx.RetryAttempts
+	}
+	if err := h.validateEvaluationJobConfig(ctx, &config); err != nil {
+		writeError(ctx, w, r, apierrors.AsValidation(err))
+		return
+	}
+	if err := h.resolveBenchmarks(ctx, &config); err != nil {
+		if abstractions.IsNotFound(err) {
+			writeError(ctx, w, r, apierrors.Invalid(fmt.Sprintf("collection %s does not exist", config.Collection.ID), "body", "collection", "id"))
+		} else {
+			writeError(ctx, w, r, err)
+		}
+		return
+	}
+	if limiter, ok := h.runtime.(abstractions.BenchmarkLimiter); ok && (len(config.Benchmarks) > limiter.MaxBenchmarks()) {
+		message := fmt.Sprintf("the job has %d benchmarks, the runtime can not run more than %d benchmarks per job", len(config.Benchmarks), limiter.MaxBenchmarks())
+		writeError(ctx, w, r, apierrors.Invalid(message, "body", "benchmarks"))
+		return
+	}
+
+	evaluation := &api.EvaluationJobResource{
+		Resource:            api.Resource{ID: uuid.New().String()},
+		EvaluationJobConfig: config,
+		Status: api.EvaluationJobStatus{
+			EvaluationJobState: api.EvaluationJobState{
+				State:   api.StatePending,
+				Message: "Evaluation job created",
+			},
+		},
+	}
+	if ctx.Principal != nil {
+		evaluation.Owner = ctx.Principal.Subject
+	}
+	for _, benchmark := range config.Benchmarks {
+		evaluation.Status.Benchmarks = append(evaluation.Status.Benchmarks, api.BenchmarkStatus{
+			Name:  benchmark.ID,
+			State: api.StatePending,
+		})
+	}
+
+	ctx.EvaluationID = evaluation.ID
+	ctx.ModelURL = config.Model.URL
+	ctx.ModelName = config.Model.Name
+	ctx.TimeoutMinutes = *config.TimeoutMinutes
+	ctx.RetryAttempts = *config.RetryAttempts
+	if config.Experiment.Name != "" {
+		ctx.ExperimentName = &config.Experiment.Name
+	}
+	ctx.Logger = ctx.Logger.With("evaluation_id", evaluation.ID)
+
+	// experiment tracking is best effort, an unavailable tracking server does not block the job
+	if (ctx.MLflowClient != nil) && (config.Experiment.Name != "") {
+		if experimentURL, err := ctx.MLflowClient.StartExperiment(r.Context(), evaluation); err != nil {
+			ctx.Logger.Warn("Failed to start the MLflow experiment", "experiment", config.Experiment.Name, "error", err.Error())
+		} else {
+			evaluation.Results = &api.EvaluationJobResults{
+				TotalEvaluations:    len(config.Benchmarks),
+				MLFlowExperimentURL: &experimentURL,
+			}
+		}
+	}
+
+	storage := h.tenantStorage(ctx)
+	if err := storage.CreateEvaluationJob(evaluation); err != nil {
+		writeError(ctx, w, r, err)
+		return
+	}
+	ctx.Logger.Info("Created evaluation job", "model", config.Model.Name, "benchmarks", len(config.Benchmarks))
+
+	if h.runtime == nil {
+		ctx.Logger.Warn("No runtime is configured, the evaluation job will stay pending")
+	} else if err := h.runtime.RunEvaluationJob(evaluation, &storage); errors.Is(err, abstractions.ErrRuntimeBusy) {
+		// the job was not started, it is removed so that the client can submit it again later
+		ctx.Logger.Warn("The runtime has no room for the evaluation job", "error", err.Error())
+		if err := storage.DeleteEvaluationJob(evaluation.ID); err != nil {
+			ctx.Logger.Error("Failed to delete the evaluation job", "error", err.Error())
+		}
+		w.Header().Set("Retry-After", strconv.Itoa(runtimeBusyRetryAfter))
+		writeError(ctx, w, r, apierrors.Unavailable("the runtime has no room for the evaluation job, retry later"))
+		return
+	} else if err != nil {
+		state := api.EvaluationJobState{
+			State:   api.StateFailed,
+			Message: fmt.Sprintf("Failed to start the evaluation job: %s", err.Error()),
+		}
+		if err := storage.UpdateEvaluationJobStatus(evaluation.ID, state); err != nil {
+			ctx.Logger.Error("Failed to update the evaluation job status", "error", err.Error())
+		}
+		writeError(ctx, w, r, apierrors.Internal(errors.New(state.Message)))
+		return
+	}
+
+	// return the stored resource so that the response reflects any update made by the runtime
+	if stored, err := storage.GetEvaluationJob(evaluation.ID); err == nil {
+		evaluation = stored
+	}
+
+	w.Header().Set("Location", evaluationJobsPath+"/"+evaluation.ID)
+	writeJSON(ctx, w, http.StatusAccepted, evaluation)
 }
 
-// HandleListEvaluations handles GET /api/v1/evaluations/jobs
+// validateEvaluationJobConfig checks the parts of the request that the runtimes rely on, the
+// validation error locates the invalid field
+func (h *Handlers) validateEvaluationJobConfig(ctx *execution_context.ExecutionContext, config *api.EvaluationJobConfig) error {
+	if strings.TrimSpace(config.Model.Name) == "" {
+		return apierrors.Invalid("model.name is required", "body", "model", "name")
+	}
+	if err := validateURL(config.Model.URL); err != nil {
+		return apierrors.Invalid("model.url "+err.Error(), "body", "model", "url")
+	}
+	if (len(config.Benchmarks) == 0) && (config.Collection.ID == "") {
+		return apierrors.Invalid("at least one benchmark or a collection is required", "body", "benchmarks")
+	}
+	for i, benchmark := range config.Benchmarks {
+		if strings.TrimSpace(benchmark.ID) == "" {
+			return apierrors.Invalid(fmt.Sprintf("benchmarks[%d].id is required", i), "body", "benchmarks", i, "id")
+		}
+		if (benchmark.Limit != nil) && (*benchmark.Limit < 1) {
+			return apierrors.Invalid(fmt.Sprintf("benchmarks[%d].limit must be at least 1", i), "body", "benchmarks", i, "limit")
+		}
+	}
+	if (*config.TimeoutMinutes < 1) || (*config.TimeoutMinutes > maxTimeoutMinutes) {
+		return apierrors.Invalid(fmt.Sprintf("timeout_minutes must be between 1 and %d", maxTimeoutMinutes), "body", "timeout_minutes")
+	}
+	if (*config.RetryAttempts < 0) || (*config.RetryAttempts > maxRetryAttempts) {
+		return apierrors.Invalid(fmt.Sprintf("retry_attempts must be between 0 and %d", maxRetryAttempts), "body", "retry_attempts")
+	}
+	if config.CallbackURL != nil {
+		if err := validateURL(*config.CallbackURL); err != nil {
+			return apierrors.Invalid("callback_url "+err.Error(), "body", "callback_url")
+		}
+		if err := callbackPolicy(ctx).CheckURL(*config.CallbackURL); err != nil {
+			return apierrors.Invalid("callback_url "+err.Error(), "body", "callback_url")
+		}
+	}
+	return nil
+}
+
+// callbackPolicy returns the policy of the callback hosts, the configuration was already checked
+// when the dispatcher was created so the default policy is only used without a configuration
+func callbackPolicy(ctx *execution_context.ExecutionContext) *callbacks.Policy {
+	if ctx.Config != nil {
+		if policy, err := callbacks.NewPolicy(ctx.Config.Callbacks); err == nil {
+			return policy
+		}
+	}
+	policy, _ := callbacks.NewPolicy(nil)
+	return policy
+}
+
+// resolveBenchmarks expands the collection of the job into its benchmarks, in the order of the
+// collection and followed by the inline benchmarks that are not part of it. The inline limit and
+// parameters of a benchmark override those of earlier entries with the same ID, so every
+// benchmark is listed once. The resolved list is stored with the job, later changes to the
+// collection do not affect it. The storage error is returned when the collection can not be read.
+func (h *Handlers) resolveBenchmarks(ctx *execution_context.ExecutionContext, config *api.EvaluationJobConfig) error {
+	ids := []string{}
+	if config.Collection.ID != "" {
+		collection, err := h.tenantStorage(ctx).GetCollection(config.Collection.ID)
+		if err != nil {
+			return err
+		}
+		ids = append(ids, collection.Benchmarks...)
+	}
+	for _, benchmark := range config.Benchmarks {
+		ids = append(ids, benchmark.ID)
+	}
+
+	resolved := []api.BenchmarkConfig{}
+	index := map[string]int{}
+	for _, id := range ids {
+		if _, found := index[id]; !found {
+			index[id] = len(resolved)
+			resolved = append(resolved, api.BenchmarkConfig{Ref: api.Ref{ID: id}})
+		}
+	}
+	for _, benchmark := range config.Benchmarks {
+		merged := &resolved[index[benchmark.ID]]
+		if benchmark.Limit != nil {
+			merged.Limit = benchmark.Limit
+		}
+		for key, value := range benchmark.Parameters {
+			if merged.Parameters == nil {
+				merged.Parameters = map[string]any{}
+			}
+			merged.Parameters[key] = value
+		}
+	}
+	config.Benchmarks = resolved
+	return nil
+}
+
+// validateURL checks that the value is an absolute http or https URL
+func validateURL(value string) error {
+	if value == "" {
+		return fmt.Errorf("is required")
+	}
+	parsed, err := url.Parse(value)
+	if err != nil {
+		return fmt.Errorf("is not a valid URL: %w", err)
+	}
+	if ((parsed.Scheme != "http") && (parsed.Scheme != "https")) || (parsed.Host == "") {
+		return fmt.Errorf("must be an absolute http or https URL")
+	}
+	return nil
+}
+
+// HandleListEvaluations handles GET /api/v1/evaluations/jobs, the jobs are filtered and sorted by
+// the parameters of parseJobQuery and the next page is linked with a cursor. With ?summary=true the
+// jobs are listed as summaries without the details of their benchmarks.
 func (h *Handlers) HandleListEvaluations(ctx *execution_context.ExecutionContext, w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		writeError(ctx, w, r, apierrors.MethodNotAllowed(r.Method))
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"items":       []interface{}{},
-		"total_count": 0,
-		"limit":       50,
-		"first":       map[string]string{"href": ""},
-		"next":        nil,
-	})
+	query, err := parseJobQuery(r.URL.Query())
+	if err != nil {
+		writeError(ctx, w, r, err)
+		return
+	}
+	// the unauthorized requests are not allowed to list all the tenants, their tenant is not authenticated
+	if query.AllTenants && ((ctx.Authorization == nil) || !ctx.Authorization.Allows(rbac.PermissionListAllTenants)) {
+		writeError(ctx, w, r, apierrors.Forbidden(fmt.Sprintf("the %s permission is required to list the evaluation jobs of all the tenants", rbac.PermissionListAllTenants)))
+		return
+	}
+	summary := false
+	if value := r.URL.Query().Get("summary"); value != "" {
+		if summary, err = strconv.ParseBool(value); err != nil {
+			writeError(ctx, w, r, apierrors.BadRequest(fmt.Sprintf("invalid value %q for the summary parameter", value)))
+			return
+		}
+	}
+	pageKeys := abstractions.Query{}
+	page, err := parsePageRequest(ctx, r, pageKeys)
+	if err != nil {
+		writeError(ctx, w, r, apierrors.BadRequest(err.Error()))
+		return
+	}
+	pageQuery, err := pageKeys.Page()
+	if err != nil {
+		writeError(ctx, w, r, apierrors.BadRequest(err.Error()))
+		return
+	}
+	query.Page = *pageQuery
+
+	list, err := h.tenantStorage(ctx).GetEvaluationJobs(query)
+	if err != nil {
+		writeError(ctx, w, r, err)
+		return
+	}
+	list.Items = paginate(ctx, page, &list.Page, list.Items, query.Sort.PositionAfter)
+	if !summary {
+		writeJSON(ctx, w, http.StatusOK, list)
+		return
+	}
+
+	// the summary view leaves out the details of the benchmarks
+	summaries := &api.EvaluationJobSummaryList{Page: list.Page, Items: make([]api.EvaluationJobSummary, 0, len(list.Items))}
+	for i := range list.Items {
+		item := aggregation.Summarize(&list.Items[i])
+		item.Benchmarks = nil
+		summaries.Items = append(summaries.Items, *item)
+	}
+	writeJSON(ctx, w, http.StatusOK, summaries)
 }
 
 // HandleGetEvaluation handles GET /api/v1/evaluations/jobs/{id}
 func (h *Handlers) HandleGetEvaluation(ctx *execution_context.ExecutionContext, w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		writeError(ctx, w, r, apierrors.MethodNotAllowed(r.Method))
 		return
 	}
 
-	// Extract ID from path
-	pathParts := strings.Split(r.URL.Path, "/")
-	id := pathParts[len(pathParts)-1]
+	evaluation, err := h.tenantStorage(ctx).GetEvaluationJob(pathID(r))
+	if err != nil {
+		writeError(ctx, w, r, err)
+		return
+	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"message": "Evaluation retrieval not yet implemented",
-		"id":      id,
-	})
+	writeJSON(ctx, w, http.StatusOK, evaluation)
 }
 
-// HandleCancelEvaluation handles DELETE /api/v1/evaluations/jobs/{id}
+// HandleCancelEvaluation handles DELETE /api/v1/evaluations/jobs/{id}. Pending and running jobs
+// are stopped and moved to cancelled together with their unfinished benchmarks, cancelling a job
+// that has already finished is a conflict. With ?hard=true the job is also deleted, which is the
+// only way to remove a job that has finished.
 func (h *Handlers) HandleCancelEvaluation(ctx *execution_context.ExecutionContext, w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodDelete {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		writeError(ctx, w, r, apierrors.MethodNotAllowed(r.Method))
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"message": "Evaluation cancellation not yet implemented",
-	})
-}
+	hard := false
+	if value := r.URL.Query().Get("hard"); value != "" {
+		var err error
+		if hard, err = strconv.ParseBool(value); err != nil {
+			writeError(ctx, w, r, apierrors.BadRequest(fmt.Sprintf("invalid value %q for the hard parameter", value)))
+			return
+		}
+	}
 
-// HandleGetEvaluationSummary handles GET /api/v1/evaluations/jobs/{id}/summary
-func (h *Handlers) HandleGetEvaluationSummary(ctx *execution_context.ExecutionContext, w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	evaluation, err := h.tenantStorage(ctx).GetEvaluationJob(pathID(r))
+	if err != nil {
+		writeError(ctx, w, r, err)
+		return
+	}
+	ctx.EvaluationID = evaluation.ID
+	ctx.Logger = ctx.Logger.With("evaluation_id", evaluation.ID)
+	if !canCancel(ctx, evaluation) {
+		writeError(ctx, w, r, apierrors.Forbidden(fmt.Sprintf("evaluation job %s can only be cancelled by its owner or with the %s permission", evaluation.ID, rbac.PermissionCancelAny)))
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"message": "Evaluation summary not yet implemented",
-	})
-}
-
-// HandleListBenchmarks handles GET /api/v1/evaluations/benchmarks
-func (h *Handlers) HandleListBenchmarks(ctx *execution_context.ExecutionContext, w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	if !state_machine.IsTerminal(evaluation.Status.State) {
+		if err := h.cancelEvaluationJob(ctx, evaluation); err != nil {
+			writeError(ctx, w, r, err)
+			return
+		}
+	} else if !hard {
+		writeError(ctx, w, r, apierrors.Conflict(fmt.Sprintf("evaluation job %s can not be cancelled because it is %s", evaluation.ID, evaluation.Status.State)))
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"benchmarks":         []interface{}{},
-		"total_count":        0,
-		"providers_included": []string{},
-	})
-}
+	if hard {
+		if err := h.tenantStorage(ctx).DeleteEvaluationJob(evaluation.ID); err != nil {
+			writeError(ctx, w, r, err)
+			return
+		}
+		ctx.Logger.Info("Deleted evaluation job")
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
 
-// HandleListCollections handles GET /api/v1/evaluations/collections
-func (h *Handlers) HandleListCollections(ctx *execution_context.ExecutionContext, w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	evaluation, err = h.tenantStorage(ctx).GetEvaluationJob(evaluation.ID)
+	if err != nil {
+		writeError(ctx, w, r, err)
 		return
 	}
+	writeJSON(ctx, w, http.StatusOK, evaluation)
+}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"collections":       []interface{}{},
-		"total_collections": 0,
-	})
+// canCancel reports whether the principal of the request may cancel the job, the principals that
+// can not cancel any job can cancel the jobs they submitted
+func canCancel(ctx *execution_context.ExecutionContext, evaluation *api.EvaluationJobResource) bool {
+	if ctx.Authorization.Allows(rbac.PermissionCancelAny) {
+		return true
+	}
+	return (ctx.Principal != nil) && (evaluation.Owner != "") && (evaluation.Owner == ctx.Principal.Subject)
 }
 
-// HandleCreateCollection handles POST /api/v1/evaluations/collections
-func (h *Handlers) HandleCreateCollection(ctx *execution_context.ExecutionContext, w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		return
+// cancelEvaluationJob stops the job in the runtime and then marks the job and its unfinished
+// benchmarks as cancelled, the runtime no longer updates the job once it has been cancelled
+func (h *Handlers) cancelEvaluationJob(ctx *execution_context.ExecutionContext, evaluation *api.EvaluationJobResource) error {
+	storage := h.tenantStorage(ctx)
+	if h.runtime != nil {
+		if err := h.runtime.CancelEvaluationJob(evaluation, &storage); err != nil {
+			return fmt.Errorf("failed to cancel the evaluation job in the runtime: %w", err)
+		}
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusCreated)
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"message": "Collection creation not yet implemented",
+	// the job is cancelled first so that its state is not derived from the cancelled benchmarks
+	err := storage.UpdateEvaluationJobStatus(evaluation.ID, api.EvaluationJobState{
+		State:   api.StateCancelled,
+		Message: "Evaluation job cancelled",
 	})
+	if err != nil {
+		return err
+	}
+	// the benchmarks are read again because they may have finished while the job was cancelled,
+	// a benchmark that finishes before its cancellation is stored keeps its final state
+	cancelled, err := storage.GetEvaluationJob(evaluation.ID)
+	if err != nil {
+		return err
+	}
+	for _, benchmark := range cancelled.Status.Benchmarks {
+		if state_machine.IsTerminal(benchmark.State) {
+			continue
+		}
+		benchmark.State = api.StateCancelled
+		benchmark.Message = "Benchmark cancelled"
+		if err := storage.UpdateBenchmarkStatusForJob(evaluation.ID, benchmark); err != nil {
+			if state_machine.IsTransitionError(err) {
+				ctx.Logger.Info("The benchmark finished before it was cancelled", "benchmark_id", benchmark.Name)
+				continue
+			}
+			return err
+		}
+	}
+	ctx.Logger.Info("Cancelled evaluation job", "previous_state", evaluation.Status.State)
+	return nil
 }
 
-// HandleGetCollection handles GET /api/v1/evaluations/collections/{collection_id}
-func (h *Handlers) HandleGetCollection(ctx *execution_context.ExecutionContext, w http.ResponseWriter, r *http.Request) {
+// HandleGetEvaluationSummary handles GET /api/v1/evaluations/jobs/{id}/summary, the counters,
+// durations and aggregated metrics are computed from the current state of the job
+func (h *Handlers) HandleGetEvaluationSummary(ctx *execution_context.ExecutionContext, w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		writeError(ctx, w, r, apierrors.MethodNotAllowed(r.Method))
 		return
 	}
 
-	// Extract collection_id from path
-	pathParts := strings.Split(r.URL.Path, "/")
-	collectionID := pathParts[len(pathParts)-1]
-
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"message":       "Collection retrieval not yet implemented",
-		"collection_id": collectionID,
-	})
-}
-
-// HandleUpdateCollection handles PUT /api/v1/evaluations/collections/{collection_id}
-func (h *Handlers) HandleUpdateCollection(ctx *execution_context.ExecutionContext, w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPut {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	evaluation, err := h.tenantStorage(ctx).GetEvaluationJob(parentPathID(r))
+	if err != nil {
+		writeError(ctx, w, r, err)
 		return
 	}
-
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"message": "Collection update not yet implemented",
-	})
+	writeJSON(ctx, w, http.StatusOK, aggregation.Summarize(evaluation))
 }
 
-// HandlePatchCollection handles PATCH /api/v1/evaluations/collections/{collection_id}
-func (h *Handlers) HandlePatchCollection(ctx *execution_context.ExecutionContext, w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPatch {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+// HandleListBenchmarks handles GET /api/v1/evaluations/benchmarks
+func (h *Handlers) HandleListBenchmarks(ctx *execution_context.ExecutionContext, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(ctx, w, r, apierrors.MethodNotAllowed(r.Method))
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"message": "Collection patch not yet implemented",
-	})
-}
-
-// HandleDeleteCollection handles DELETE /api/v1/evaluations/collections/{collection_id}
-func (h *Handlers) HandleDeleteCollection(ctx *execution_context.ExecutionContext, w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodDelete {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	filter, err := benchmarkFilter(r)
+	if err != nil {
+		writeError(ctx, w, r, err)
 		return
 	}
+	benchmarks := h.registry.FindBenchmarks(*filter)
+	writeJSON(ctx, w, http.StatusOK, api.BenchmarkResourceList{TotalCount: len(benchmarks), Items: benchmarks})
+}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"message": "Collection deletion not yet implemented",
-	})
+// benchmarkFilter parses the provider_id, category, tags (comma-separated), tags_match (any or
+// all, any by default) and search query parameters
+func benchmarkFilter(r *http.Request) (*registry.BenchmarkFilter, error) {
+	query := r.URL.Query()
+	filter := &registry.BenchmarkFilter{
+		ProviderID: strings.TrimSpace(query.Get("provider_id")),
+		Category:   strings.TrimSpace(query.Get("category")),
+		Search:     query.Get("search"),
+	}
+	for _, tag := range strings.Split(query.Get("tags"), ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			filter.Tags = append(filter.Tags, tag)
+		}
+	}
+	switch match := query.Get("tags_match"); match {
+	case "", "any":
+	case "all":
+		filter.MatchAllTags = true
+	default:
+		return nil, apierrors.Invalid(fmt.Sprintf("invalid value %q for the tags_match parameter, expected any or all", match), "query", "tags_match")
+	}
+	return filter, nil
 }
 
 // HandleListProviders handles GET /api/v1/evaluations/providers
 func (h *Handlers) HandleListProviders(ctx *execution_context.ExecutionContext, w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		writeError(ctx, w, r, apierrors.MethodNotAllowed(r.Method))
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"providers":        []interface{}{},
-		"total_providers":  0,
-		"total_benchmarks": 0,
-	})
+	providers := h.registry.Providers()
+	writeJSON(ctx, w, http.StatusOK, api.ProviderResourceList{TotalCount: len(providers), Items: providers})
 }
 
 // HandleGetProvider handles GET /api/v1/evaluations/providers/{provider_id}
 func (h *Handlers) HandleGetProvider(ctx *execution_context.ExecutionContext, w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		writeError(ctx, w, r, apierrors.MethodNotAllowed(r.Method))
 		return
 	}
 
-	// Extract provider_id from path
-	pathParts := strings.Split(r.URL.Path, "/")
-	providerID := pathParts[len(pathParts)-1]
-
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"message":     "Provider retrieval not yet implemented",
-		"provider_id": providerID,
-	})
+	id := pathID(r)
+	provider, found := h.registry.Provider(id)
+	if !found {
+		writeError(ctx, w, r, apierrors.NotFound(fmt.Sprintf("provider %s not found", id)))
+		return
+	}
+	writeJSON(ctx, w, http.StatusOK, provider)
 }
 
 // HandleGetSystemMetrics handles GET /api/v1/metrics/system
 func (h *Handlers) HandleGetSystemMetrics(ctx *execution_context.ExecutionContext, w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		writeError(ctx, w, r, apierrors.MethodNotAllowed(r.Method))
 		return
 	}
 
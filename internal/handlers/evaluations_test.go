@@ -0,0 +1,649 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/abstractions"
+	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/auth"
+	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/config"
+	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/execution_context"
+	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/logging"
+	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/rbac"
+	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/registry"
+	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/storage/memory"
+	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/tenancy"
+	"github.ibm.com/julpayne/eval-hub-backend-svc/pkg/api"
+)
+
+// fakeRuntime records the jobs it is asked to run
+type fakeRuntime struct {
+	jobs      []*api.EvaluationJobResource
+	cancelled []string
+	err       error
+	// onCancel is called when a job is cancelled, before the handler updates the job
+	onCancel func(storage abstractions.Storage, id string)
+}
+
+func (r *fakeRuntime) RunEvaluationJob(evaluation *api.EvaluationJobResource, storage *abstractions.Storage) error {
+	r.jobs = append(r.jobs, evaluation)
+	return r.err
+}
+
+func (r *fakeRuntime) CancelEvaluationJob(evaluation *api.EvaluationJobResource, storage *abstractions.Storage) error {
+	r.cancelled = append(r.cancelled, evaluation.ID)
+	if r.onCancel != nil {
+		r.onCancel(*storage, evaluation.ID)
+	}
+	return r.err
+}
+
+func (r *fakeRuntime) Close() error {
+	return nil
+}
+
+// limitedRuntime is a fakeRuntime that runs jobs of at most max benchmarks
+type limitedRuntime struct {
+	fakeRuntime
+	max int
+}
+
+func (r *limitedRuntime) MaxBenchmarks() int {
+	return r.max
+}
+
+// fakeTracker returns the experiment URL or the error and sends the logged benchmark results to
+// the logged channel when it is set, the results are logged in the background
+type fakeTracker struct {
+	url    string
+	err    error
+	logged chan string
+}
+
+func (t *fakeTracker) StartExperiment(ctx context.Context, evaluation *api.EvaluationJobResource) (string, error) {
+	return t.url, t.err
+}
+
+func (t *fakeTracker) LogBenchmarkResult(ctx context.Context, evaluation *api.EvaluationJobResource, result *api.EvaluationJobBenchmarkResult) error {
+	if t.logged != nil {
+		t.logged <- result.ID + "/" + result.AttemptID
+	}
+	return t.err
+}
+
+func newTestContext(t *testing.T, r *http.Request) *execution_context.ExecutionContext {
+	t.Helper()
+	logger, err := logging.NewLogger()
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	return execution_context.NewExecutionContext(r, logger, &config.Config{})
+}
+
+func createJob(t *testing.T, h *Handlers, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/evaluations/jobs", strings.NewReader(body))
+	w := httptest.NewRecorder()
+	h.HandleCreateEvaluation(newTestContext(t, req), w, req)
+	return w
+}
+
+const validJob = `{
+  "model": {"url": "http://model:8000/v1", "name": "granite"},
+  "benchmarks": [{"id": "mmlu", "limit": 5}, {"id": "arc"}],
+  "experiment": {"name": "exp"}
+}`
+
+func TestHandleCreateEvaluation(t *testing.T) {
+	t.Run("valid request creates a pending job and runs it", func(t *testing.T) {
+		runtime := &fakeRuntime{}
+		h := New(memory.NewStorage(), runtime, nil)
+
+		w := createJob(t, h, validJob)
+
+		if w.Code != http.StatusAccepted {
+			t.Fatalf("Expected status code %d, got %d: %s", http.StatusAccepted, w.Code, w.Body.String())
+		}
+		var job api.EvaluationJobResource
+		if err := json.Unmarshal(w.Body.Bytes(), &job); err != nil {
+			t.Fatalf("Failed to unmarshal response: %v", err)
+		}
+		if job.ID == "" || job.Tenant != abstractions.DefaultTenant {
+			t.Errorf("Expected an ID and the default tenant, got %+v", job.Resource)
+		}
+		if location := w.Header().Get("Location"); location != "/api/v1/evaluations/jobs/"+job.ID {
+			t.Errorf("Expected the Location header to point at the job, got %s", location)
+		}
+		if job.Status.State != api.StatePending || len(job.Status.Benchmarks) != 2 {
+			t.Errorf("Expected a pending job with 2 benchmarks, got %+v", job.Status)
+		}
+		if *job.TimeoutMinutes != 60 || *job.RetryAttempts != 3 {
+			t.Errorf("Expected the default timeout and retries, got %d and %d", *job.TimeoutMinutes, *job.RetryAttempts)
+		}
+		if len(runtime.jobs) != 1 || runtime.jobs[0].ID != job.ID {
+			t.Errorf("Expected the job to be handed to the runtime, got %d jobs", len(runtime.jobs))
+		}
+
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/evaluations/jobs/"+job.ID, nil)
+		get := httptest.NewRecorder()
+		h.HandleGetEvaluation(newTestContext(t, req), get, req)
+		if get.Code != http.StatusOK {
+			t.Errorf("Expected the created job to be retrievable, got %d", get.Code)
+		}
+	})
+
+	t.Run("runtime failures mark the job as failed", func(t *testing.T) {
+		storage := memory.NewStorage()
+		h := New(storage, &fakeRuntime{err: fmt.Errorf("no capacity")}, nil)
+
+		w := createJob(t, h, validJob)
+
+		if w.Code != http.StatusInternalServerError {
+			t.Fatalf("Expected status code %d, got %d", http.StatusInternalServerError, w.Code)
+		}
+		list, _ := storage.GetEvaluationJobs(&abstractions.JobQuery{States: []api.State{api.StateFailed}})
+		if list.TotalCount != 1 {
+			t.Errorf("Expected the job to be stored as failed, got %d failed jobs", list.TotalCount)
+		}
+	})
+
+	t.Run("jobs with more benchmarks than the runtime runs are invalid", func(t *testing.T) {
+		storage := memory.NewStorage()
+		runtime := &limitedRuntime{max: 1}
+		w := createJob(t, New(storage, runtime, nil), validJob)
+
+		if w.Code != http.StatusUnprocessableEntity {
+			t.Fatalf("Expected status code %d, got %d", http.StatusUnprocessableEntity, w.Code)
+		}
+		if !strings.Contains(w.Body.String(), `"loc":["body","benchmarks"]`) {
+			t.Errorf("Expected the error to locate the benchmarks, got %s", w.Body.String())
+		}
+		if len(runtime.jobs) != 0 {
+			t.Error("Expected the runtime not to be called")
+		}
+	})
+
+	t.Run("busy runtimes reject the job without storing it", func(t *testing.T) {
+		storage := memory.NewStorage()
+		h := New(storage, &fakeRuntime{err: fmt.Errorf("queue full: %w", abstractions.ErrRuntimeBusy)}, nil)
+
+		w := createJob(t, h, validJob)
+
+		if w.Code != http.StatusServiceUnavailable {
+			t.Fatalf("Expected status code %d, got %d", http.StatusServiceUnavailable, w.Code)
+		}
+		if w.Header().Get("Retry-After") == "" {
+			t.Error("Expected a Retry-After header")
+		}
+		list, _ := storage.GetEvaluationJobs(&abstractions.JobQuery{})
+		if list.TotalCount != 0 {
+			t.Errorf("Expected the job not to be stored, got %d jobs", list.TotalCount)
+		}
+	})
+
+	testCases := []struct {
+		name   string
+		body   string
+		status int
+	}{
+		{"empty body", ``, http.StatusBadRequest},
+		{"malformed JSON", `{"model":`, http.StatusBadRequest},
+		{"unknown field", `{"model": {"url": "http://m", "name": "m"}, "benchmarks": [{"id": "a"}], "unknown": 1}`, http.StatusBadRequest},
+		{"missing model name", `{"model": {"url": "http://m"}, "benchmarks": [{"id": "a"}]}`, http.StatusUnprocessableEntity},
+		{"relative model URL", `{"model": {"url": "/v1", "name": "m"}, "benchmarks": [{"id": "a"}]}`, http.StatusUnprocessableEntity},
+		{"no benchmarks or collection", `{"model": {"url": "http://m", "name": "m"}}`, http.StatusUnprocessableEntity},
+		{"empty benchmark ID", `{"model": {"url": "http://m", "name": "m"}, "benchmarks": [{"id": ""}]}`, http.StatusUnprocessableEntity},
+		{"zero limit", `{"model": {"url": "http://m", "name": "m"}, "benchmarks": [{"id": "a", "limit": 0}]}`, http.StatusUnprocessableEntity},
+		{"zero timeout", `{"model": {"url": "http://m", "name": "m"}, "benchmarks": [{"id": "a"}], "timeout_minutes": 0}`, http.StatusUnprocessableEntity},
+		{"too many retries", `{"model": {"url": "http://m", "name": "m"}, "benchmarks": [{"id": "a"}], "retry_attempts": 100}`, http.StatusUnprocessableEntity},
+		{"invalid callback URL", `{"model": {"url": "http://m", "name": "m"}, "benchmarks": [{"id": "a"}], "callback_url": "ftp://x"}`, http.StatusUnprocessableEntity},
+		{"internal callback URL", `{"model": {"url": "http://m", "name": "m"}, "benchmarks": [{"id": "a"}], "callback_url": "http://169.254.169.254/latest"}`, http.StatusUnprocessableEntity},
+		{"unknown collection", `{"model": {"url": "http://m", "name": "m"}, "collection": {"id": "missing"}}`, http.StatusUnprocessableEntity},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			runtime := &fakeRuntime{}
+			w := createJob(t, New(memory.NewStorage(), runtime, nil), tc.body)
+
+			if w.Code != tc.status {
+				t.Errorf("Expected status code %d, got %d: %s", tc.status, w.Code, w.Body.String())
+			}
+			if tc.status == http.StatusUnprocessableEntity {
+				var response api.HTTPValidationError
+				if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil || len(response.Detail) != 1 || len(response.Detail[0].Loc) < 2 {
+					t.Errorf("Expected the location of the invalid field, got %s", w.Body.String())
+				}
+			} else {
+				var response api.Error
+				if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil || response.Detail == "" || response.Code != "bad_request" {
+					t.Errorf("Expected an error detail, got %s", w.Body.String())
+				}
+			}
+			if len(runtime.jobs) != 0 {
+				t.Error("Expected the runtime not to be called")
+			}
+		})
+	}
+}
+
+func TestHandleCreateEvaluationExpandsTheCollection(t *testing.T) {
+	storage := memory.NewStorage()
+	storage.CreateCollection(&api.CollectionResource{
+		Resource:         api.Resource{ID: "col-1"},
+		CollectionConfig: api.CollectionConfig{Name: "suite", Benchmarks: []string{"mmlu", "arc", "hellaswag"}},
+	})
+	h := New(storage, &fakeRuntime{}, nil)
+
+	w := createJob(t, h, `{
+	  "model": {"url": "http://model:8000/v1", "name": "granite"},
+	  "collection": {"id": "col-1"},
+	  "benchmarks": [
+	    {"id": "arc", "limit": 3, "parameters": {"shots": 5, "seed": 1}},
+	    {"id": "gsm8k"},
+	    {"id": "arc", "parameters": {"seed": 2}}
+	  ]
+	}`)
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("Expected status code %d, got %d: %s", http.StatusAccepted, w.Code, w.Body.String())
+	}
+	var job api.EvaluationJobResource
+	json.Unmarshal(w.Body.Bytes(), &job)
+
+	ids := []string{}
+	for _, benchmark := range job.Benchmarks {
+		ids = append(ids, benchmark.ID)
+	}
+	if strings.Join(ids, ",") != "mmlu,arc,hellaswag,gsm8k" {
+		t.Fatalf("Expected the collection followed by the inline benchmarks, got %v", ids)
+	}
+	arc := job.Benchmarks[1]
+	if (*arc.Limit != 3) || (arc.Parameters["shots"] != float64(5)) || (arc.Parameters["seed"] != float64(2)) {
+		t.Errorf("Expected the inline limit and the merged parameters, got %+v", arc)
+	}
+	if (job.Benchmarks[0].Limit != nil) || (job.Benchmarks[0].Parameters != nil) || (len(job.Status.Benchmarks) != 4) {
+		t.Errorf("Expected 4 benchmarks with the defaults of the collection, got %+v", job.Status.Benchmarks)
+	}
+
+	// the job keeps the benchmarks it was submitted with
+	storage.UpdateCollection(&api.CollectionResource{
+		Resource:         api.Resource{ID: "col-1"},
+		CollectionConfig: api.CollectionConfig{Name: "suite", Benchmarks: []string{"mmlu"}},
+	})
+	stored, _ := storage.GetEvaluationJob(job.ID)
+	if (len(stored.Benchmarks) != 4) || (stored.Collection.ID != "col-1") {
+		t.Errorf("Expected the resolved benchmarks to be stored with the job, got %+v", stored.Benchmarks)
+	}
+}
+
+func TestHandleGetEvaluation(t *testing.T) {
+	h := New(memory.NewStorage(), nil, nil)
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/evaluations/jobs/missing", nil)
+	w := httptest.NewRecorder()
+
+	h.HandleGetEvaluation(newTestContext(t, req), w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status code %d, got %d", http.StatusNotFound, w.Code)
+	}
+}
+
+func TestTenantScoping(t *testing.T) {
+	h := New(memory.NewStorage(), &fakeRuntime{}, nil)
+	call := func(tenant api.Tenant, method string, target string, body string, handler func(*execution_context.ExecutionContext, http.ResponseWriter, *http.Request)) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		req = req.WithContext(tenancy.WithTenant(req.Context(), tenant))
+		w := httptest.NewRecorder()
+		handler(newTestContext(t, req), w, req)
+		return w
+	}
+	listAllTenants := func(authorization *rbac.Authorization) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/evaluations/jobs?all_tenants=true", nil)
+		req = req.WithContext(rbac.WithAuthorization(tenancy.WithTenant(req.Context(), "acme"), authorization))
+		w := httptest.NewRecorder()
+		h.HandleListEvaluations(newTestContext(t, req), w, req)
+		return w
+	}
+
+	w := call("acme", http.MethodPost, "/api/v1/evaluations/jobs", validJob, h.HandleCreateEvaluation)
+	var job api.EvaluationJobResource
+	json.Unmarshal(w.Body.Bytes(), &job)
+	if (w.Code != http.StatusAccepted) || (job.Tenant != "acme") {
+		t.Fatalf("Expected the job to be created for the tenant, got %d: %s", w.Code, w.Body.String())
+	}
+
+	if w := call("acme", http.MethodGet, "/api/v1/evaluations/jobs/"+job.ID, "", h.HandleGetEvaluation); w.Code != http.StatusOK {
+		t.Errorf("Expected the tenant to read its job, got %d", w.Code)
+	}
+	if w := call("other", http.MethodGet, "/api/v1/evaluations/jobs/"+job.ID, "", h.HandleGetEvaluation); w.Code != http.StatusNotFound {
+		t.Errorf("Expected the job of another tenant not to be found, got %d", w.Code)
+	}
+	if w := call("other", http.MethodDelete, "/api/v1/evaluations/jobs/"+job.ID, "", h.HandleCancelEvaluation); w.Code != http.StatusNotFound {
+		t.Errorf("Expected the job of another tenant not to be cancelled, got %d", w.Code)
+	}
+
+	var list api.EvaluationJobResourceList
+	json.Unmarshal(call("other", http.MethodGet, "/api/v1/evaluations/jobs", "", h.HandleListEvaluations).Body.Bytes(), &list)
+	if list.TotalCount != 0 {
+		t.Errorf("Expected no jobs for another tenant, got %d", list.TotalCount)
+	}
+	if w := listAllTenants(nil); w.Code != http.StatusForbidden {
+		t.Errorf("Expected status code %d for an unauthorized request listing all the tenants, got %d", http.StatusForbidden, w.Code)
+	}
+	if w := listAllTenants(&rbac.Authorization{Roles: []rbac.Role{rbac.RoleSubmitter}}); w.Code != http.StatusForbidden {
+		t.Errorf("Expected status code %d for a submitter listing all the tenants, got %d", http.StatusForbidden, w.Code)
+	}
+	call("other", http.MethodPost, "/api/v1/evaluations/jobs", validJob, h.HandleCreateEvaluation)
+	list = api.EvaluationJobResourceList{}
+	json.Unmarshal(listAllTenants(&rbac.Authorization{Roles: []rbac.Role{rbac.RoleAdmin}}).Body.Bytes(), &list)
+	if list.TotalCount != 2 {
+		t.Errorf("Expected an admin to list the jobs of all the tenants, got %d", list.TotalCount)
+	}
+}
+
+func TestJobOwnership(t *testing.T) {
+	h := New(memory.NewStorage(), &fakeRuntime{}, nil)
+	call := func(subject string, role rbac.Role, method string, target string, body string, handler func(*execution_context.ExecutionContext, http.ResponseWriter, *http.Request)) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		ctx := auth.WithPrincipal(req.Context(), &auth.Principal{Subject: subject, Method: auth.MethodJWT})
+		req = req.WithContext(rbac.WithAuthorization(ctx, &rbac.Authorization{Roles: []rbac.Role{role}}))
+		w := httptest.NewRecorder()
+		handler(newTestContext(t, req), w, req)
+		return w
+	}
+	create := func(subject string) *api.EvaluationJobResource {
+		var job api.EvaluationJobResource
+		json.Unmarshal(call(subject, rbac.RoleSubmitter, http.MethodPost, "/api/v1/evaluations/jobs", validJob, h.HandleCreateEvaluation).Body.Bytes(), &job)
+		return &job
+	}
+
+	job := create("alice")
+	if job.Owner != "alice" {
+		t.Fatalf("Expected the job to be owned by its submitter, got %q", job.Owner)
+	}
+	if w := call("bob", rbac.RoleSubmitter, http.MethodDelete, "/api/v1/evaluations/jobs/"+job.ID, "", h.HandleCancelEvaluation); w.Code != http.StatusForbidden {
+		t.Errorf("Expected status code %d for another submitter, got %d", http.StatusForbidden, w.Code)
+	}
+	if w := call("alice", rbac.RoleSubmitter, http.MethodDelete, "/api/v1/evaluations/jobs/"+job.ID, "", h.HandleCancelEvaluation); w.Code != http.StatusOK {
+		t.Errorf("Expected the owner to cancel the job, got %d: %s", w.Code, w.Body.String())
+	}
+	job = create("alice")
+	if w := call("root", rbac.RoleAdmin, http.MethodDelete, "/api/v1/evaluations/jobs/"+job.ID, "", h.HandleCancelEvaluation); w.Code != http.StatusOK {
+		t.Errorf("Expected an admin to cancel the job of another principal, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestHandleGetEvaluationSummary(t *testing.T) {
+	storage := memory.NewStorage()
+	h := New(storage, nil, nil)
+	w := createJob(t, h, validJob)
+	var job api.EvaluationJobResource
+	json.Unmarshal(w.Body.Bytes(), &job)
+	storage.UpdateBenchmarkStatusForJob(job.ID, api.BenchmarkStatus{Name: "mmlu", State: api.StateRunning})
+	storage.RecordBenchmarkResult(job.ID, api.EvaluationJobBenchmarkResult{ID: "mmlu", State: api.StateCompleted, AttemptID: "1", Metrics: map[string]any{"acc": 0.75}})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/evaluations/jobs/"+job.ID+"/summary", nil)
+	w = httptest.NewRecorder()
+	h.HandleGetEvaluationSummary(newTestContext(t, req), w, req)
+	var summary api.EvaluationJobSummary
+	json.Unmarshal(w.Body.Bytes(), &summary)
+	if (w.Code != http.StatusOK) || (summary.TotalEvaluations != 2) || (summary.CompletedEvaluations != 1) || (len(summary.Benchmarks) != 2) {
+		t.Fatalf("Expected the summary of the job, got %d: %s", w.Code, w.Body.String())
+	}
+	if (summary.AggregatedMetrics["acc"].Mean != 0.75) || (summary.Benchmarks[0].DurationSeconds == nil) {
+		t.Errorf("Expected the aggregated metrics and the duration of mmlu, got %s", w.Body.String())
+	}
+
+	w, _ = listJobs(t, h, "/api/v1/evaluations/jobs?summary=true")
+	var list api.EvaluationJobSummaryList
+	json.Unmarshal(w.Body.Bytes(), &list)
+	if (w.Code != http.StatusOK) || (len(list.Items) != 1) || (list.Items[0].AggregatedMetrics["acc"].Mean != 0.75) || (list.Items[0].Benchmarks != nil) {
+		t.Errorf("Expected the summary view without the benchmarks, got %d: %s", w.Code, w.Body.String())
+	}
+	if w, _ := listJobs(t, h, "/api/v1/evaluations/jobs?summary=maybe"); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status code %d, got %d", http.StatusBadRequest, w.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/evaluations/jobs/missing/summary", nil)
+	w = httptest.NewRecorder()
+	h.HandleGetEvaluationSummary(newTestContext(t, req), w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status code %d, got %d", http.StatusNotFound, w.Code)
+	}
+}
+
+func TestHandleCancelEvaluation(t *testing.T) {
+	cancel := func(h *Handlers, path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodDelete, path, nil)
+		w := httptest.NewRecorder()
+		h.HandleCancelEvaluation(newTestContext(t, req), w, req)
+		return w
+	}
+	newCreatedJob := func(t *testing.T, h *Handlers) string {
+		var job api.EvaluationJobResource
+		json.Unmarshal(createJob(t, h, validJob).Body.Bytes(), &job)
+		return job.ID
+	}
+
+	t.Run("running jobs and their unfinished benchmarks are cancelled", func(t *testing.T) {
+		storage := memory.NewStorage()
+		runtime := &fakeRuntime{}
+		h := New(storage, runtime, nil)
+		id := newCreatedJob(t, h)
+		storage.UpdateEvaluationJobStatus(id, api.EvaluationJobState{State: api.StateRunning})
+		storage.UpdateBenchmarkStatusForJob(id, api.BenchmarkStatus{Name: "mmlu", State: api.StateCompleted})
+
+		w := cancel(h, evaluationJobsPath+"/"+id)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status code %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+		if len(runtime.cancelled) != 1 || runtime.cancelled[0] != id {
+			t.Errorf("Expected the runtime to cancel the job, got %v", runtime.cancelled)
+		}
+		job, _ := storage.GetEvaluationJob(id)
+		if job.Status.State != api.StateCancelled {
+			t.Errorf("Expected the job to be cancelled, got %s", job.Status.State)
+		}
+		for _, benchmark := range job.Status.Benchmarks {
+			expected := api.StateCancelled
+			if benchmark.Name == "mmlu" {
+				expected = api.StateCompleted
+			}
+			if benchmark.State != expected {
+				t.Errorf("Expected benchmark %s to be %s, got %s", benchmark.Name, expected, benchmark.State)
+			}
+		}
+
+		if w := cancel(h, evaluationJobsPath+"/"+id); w.Code != http.StatusConflict {
+			t.Errorf("Expected cancelling a cancelled job to conflict, got %d", w.Code)
+		}
+	})
+
+	t.Run("benchmarks that finish during the cancellation keep their state", func(t *testing.T) {
+		storage := memory.NewStorage()
+		runtime := &fakeRuntime{onCancel: func(storage abstractions.Storage, id string) {
+			storage.UpdateBenchmarkStatusForJob(id, api.BenchmarkStatus{Name: "arc", State: api.StateCompleted})
+		}}
+		h := New(storage, runtime, nil)
+		id := newCreatedJob(t, h)
+		storage.UpdateBenchmarkStatusForJob(id, api.BenchmarkStatus{Name: "arc", State: api.StateRunning})
+
+		if w := cancel(h, evaluationJobsPath+"/"+id); w.Code != http.StatusOK {
+			t.Fatalf("Expected status code %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+		job, _ := storage.GetEvaluationJob(id)
+		if (job.Status.State != api.StateCancelled) || (job.Status.Benchmarks[0].State != api.StateCancelled) || (job.Status.Benchmarks[1].State != api.StateCompleted) {
+			t.Errorf("Expected a cancelled job with the completed benchmark, got %+v", job.Status)
+		}
+	})
+
+	t.Run("hard deletes remove the job", func(t *testing.T) {
+		storage := memory.NewStorage()
+		h := New(storage, &fakeRuntime{}, nil)
+		id := newCreatedJob(t, h)
+		storage.UpdateEvaluationJobStatus(id, api.EvaluationJobState{State: api.StateCompleted})
+
+		if w := cancel(h, evaluationJobsPath+"/"+id+"?hard=true"); w.Code != http.StatusNoContent {
+			t.Fatalf("Expected status code %d, got %d: %s", http.StatusNoContent, w.Code, w.Body.String())
+		}
+		if _, err := storage.GetEvaluationJob(id); !abstractions.IsNotFound(err) {
+			t.Errorf("Expected the job to be deleted, got %v", err)
+		}
+	})
+
+	t.Run("runtime failures leave the job unchanged", func(t *testing.T) {
+		storage := memory.NewStorage()
+		runtime := &fakeRuntime{}
+		h := New(storage, runtime, nil)
+		id := newCreatedJob(t, h)
+		runtime.err = fmt.Errorf("unreachable")
+
+		if w := cancel(h, evaluationJobsPath+"/"+id); w.Code != http.StatusInternalServerError {
+			t.Errorf("Expected status code %d, got %d", http.StatusInternalServerError, w.Code)
+		}
+		if job, _ := storage.GetEvaluationJob(id); job.Status.State != api.StatePending {
+			t.Errorf("Expected the job to stay pending, got %s", job.Status.State)
+		}
+	})
+
+	t.Run("invalid requests", func(t *testing.T) {
+		h := New(memory.NewStorage(), nil, nil)
+		if w := cancel(h, evaluationJobsPath+"/missing"); w.Code != http.StatusNotFound {
+			t.Errorf("Expected status code %d for a missing job, got %d", http.StatusNotFound, w.Code)
+		}
+		if w := cancel(h, evaluationJobsPath+"/missing?hard=maybe"); w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status code %d for an invalid hard parameter, got %d", http.StatusBadRequest, w.Code)
+		}
+	})
+}
+
+func TestHandleListEvaluationCallbacks(t *testing.T) {
+	storage := memory.NewStorage()
+	h := New(storage, &fakeRuntime{}, nil)
+	body := strings.Replace(validJob, `"experiment"`, `"callback_url": "http://receiver/hook", "experiment"`, 1)
+	var job api.EvaluationJobResource
+	json.Unmarshal(createJob(t, h, body).Body.Bytes(), &job)
+	storage.UpdateEvaluationJobStatus(job.ID, api.EvaluationJobState{State: api.StateCancelled})
+
+	list := func(path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		w := httptest.NewRecorder()
+		h.HandleListEvaluationCallbacks(newTestContext(t, req), w, req)
+		return w
+	}
+
+	w := list("/api/v1/evaluations/jobs/" + job.ID + "/callbacks")
+	var deliveries api.CallbackDeliveryResourceList
+	if err := json.Unmarshal(w.Body.Bytes(), &deliveries); (err != nil) || (w.Code != http.StatusOK) {
+		t.Fatalf("Expected the deliveries, got %d: %s", w.Code, w.Body.String())
+	}
+	if (len(deliveries.Items) != 1) || (deliveries.Items[0].Event != api.CallbackEventCompleted) || (deliveries.Items[0].URL != "http://receiver/hook") {
+		t.Errorf("Expected the completed event to be queued, got %+v", deliveries.Items)
+	}
+	if w := list("/api/v1/evaluations/jobs/" + job.ID + "/callbacks?state=lost"); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status code %d for an invalid state, got %d", http.StatusBadRequest, w.Code)
+	}
+	if w := list("/api/v1/evaluations/jobs/unknown/callbacks"); w.Code != http.StatusNotFound {
+		t.Errorf("Expected status code %d for an unknown job, got %d", http.StatusNotFound, w.Code)
+	}
+}
+
+func TestHandleCreateEvaluationTracksTheExperiment(t *testing.T) {
+	create := func(tracker *fakeTracker) api.EvaluationJobResource {
+		h := New(memory.NewStorage(), &fakeRuntime{}, nil)
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/evaluations/jobs", strings.NewReader(validJob))
+		ctx := newTestContext(t, req)
+		ctx.MLflowClient = tracker
+		w := httptest.NewRecorder()
+		h.HandleCreateEvaluation(ctx, w, req)
+		if w.Code != http.StatusAccepted {
+			t.Fatalf("Expected status code %d, got %d: %s", http.StatusAccepted, w.Code, w.Body.String())
+		}
+		var job api.EvaluationJobResource
+		json.Unmarshal(w.Body.Bytes(), &job)
+		return job
+	}
+
+	job := create(&fakeTracker{url: "http://mlflow/#/experiments/1"})
+	if (job.Results == nil) || (job.Results.MLFlowExperimentURL == nil) || (*job.Results.MLFlowExperimentURL != "http://mlflow/#/experiments/1") {
+		t.Errorf("Expected the experiment URL in the results, got %+v", job.Results)
+	}
+	if job := create(&fakeTracker{err: fmt.Errorf("unavailable")}); job.Results != nil {
+		t.Errorf("Expected no results when the tracking server is unavailable, got %+v", job.Results)
+	}
+}
+
+// newTestRegistry loads a registry from a single YAML file
+func newTestRegistry(t *testing.T, content string) *registry.Registry {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "registry.yaml"), []byte(content), 0o644); err != nil {
+		t.Fatalf("Failed to write the registry: %v", err)
+	}
+	logger, _ := logging.NewLogger()
+	providers, err := registry.NewRegistry(logger, &config.RegistryConfig{Dir: dir})
+	if err != nil {
+		t.Fatalf("NewRegistry() returned error: %v", err)
+	}
+	return providers
+}
+
+const testRegistry = `
+providers:
+  - id: lm_evaluation_harness
+    label: LM Evaluation Harness
+benchmarks:
+  - id: mmlu
+    provider_id: lm_evaluation_harness
+    category: knowledge
+  - id: arc
+    provider_id: lm_evaluation_harness
+`
+
+func TestHandleProviders(t *testing.T) {
+	h := New(memory.NewStorage(), nil, newTestRegistry(t, testRegistry))
+	get := func(path string, handler func(*execution_context.ExecutionContext, http.ResponseWriter, *http.Request)) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		w := httptest.NewRecorder()
+		handler(newTestContext(t, req), w, req)
+		return w
+	}
+
+	var providers api.ProviderResourceList
+	json.Unmarshal(get("/api/v1/evaluations/providers", h.HandleListProviders).Body.Bytes(), &providers)
+	if (providers.TotalCount != 1) || (len(providers.Items[0].SupportedBenchmarks) != 2) {
+		t.Errorf("Expected the provider with its benchmarks, got %+v", providers)
+	}
+
+	w := get("/api/v1/evaluations/providers/lm_evaluation_harness", h.HandleGetProvider)
+	var provider api.ProviderResource
+	json.Unmarshal(w.Body.Bytes(), &provider)
+	if (w.Code != http.StatusOK) || (provider.Label != "LM Evaluation Harness") {
+		t.Errorf("Expected the provider, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := get("/api/v1/evaluations/providers/unknown", h.HandleGetProvider); w.Code != http.StatusNotFound {
+		t.Errorf("Expected status code %d, got %d", http.StatusNotFound, w.Code)
+	}
+
+	var benchmarks api.BenchmarkResourceList
+	json.Unmarshal(get("/api/v1/evaluations/benchmarks", h.HandleListBenchmarks).Body.Bytes(), &benchmarks)
+	if (benchmarks.TotalCount != 2) || (benchmarks.Items[0].ID != "arc") || (benchmarks.Items[1].ProviderID != "lm_evaluation_harness") {
+		t.Errorf("Expected the benchmarks of the registry, got %+v", benchmarks)
+	}
+
+	benchmarks = api.BenchmarkResourceList{}
+	json.Unmarshal(get("/api/v1/evaluations/benchmarks?provider_id=lm_evaluation_harness&category=knowledge", h.HandleListBenchmarks).Body.Bytes(), &benchmarks)
+	if (benchmarks.TotalCount != 1) || (benchmarks.Items[0].ID != "mmlu") {
+		t.Errorf("Expected the filtered benchmarks, got %+v", benchmarks)
+	}
+	if w := get("/api/v1/evaluations/benchmarks?tags=a,b&tags_match=some", h.HandleListBenchmarks); w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("Expected status code %d for an invalid tags_match, got %d", http.StatusUnprocessableEntity, w.Code)
+	}
+}
@@ -4,17 +4,36 @@ import (
   "encoding/json"
   "net/http"
   "time"
+
+  "github.ibm.com/julpayne/eval-hub-backend-svc/internal/abstractions"
+  "github.ibm.com/julpayne/eval-hub-backend-svc/internal/apierrors"
+  "github.ibm.com/julpayne/eval-hub-backend-svc/internal/execution_context"
+  "github.ibm.com/julpayne/eval-hub-backend-svc/internal/registry"
 )
 
-type Handlers struct{}
+type Handlers struct {
+  storage  abstractions.Storage
+  runtime  abstractions.Runtime
+  registry *registry.Registry
+}
+
+// New creates the handlers, the runtime is optional and evaluation jobs stay pending without one
+func New(storage abstractions.Storage, runtime abstractions.Runtime, registry *registry.Registry) *Handlers {
+  return &Handlers{
+    storage:  storage,
+    runtime:  runtime,
+    registry: registry,
+  }
+}
 
-func New() *Handlers {
-  return &Handlers{}
+// tenantStorage returns the storage scoped to the tenant of the request
+func (h *Handlers) tenantStorage(ctx *execution_context.ExecutionContext) abstractions.Storage {
+  return h.storage.WithTenant(ctx.Tenant)
 }
 
 func (h *Handlers) HandleHealth(w http.ResponseWriter, r *http.Request) {
   if r.Method != http.MethodGet {
-    http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+    apierrors.Write(w, r, apierrors.MethodNotAllowed(r.Method))
     return
   }
 
@@ -27,7 +46,7 @@ func (h *Handlers) HandleHealth(w http.ResponseWriter, r *http.Request) {
 
 func (h *Handlers) HandleStatus(w http.ResponseWriter, r *http.Request) {
   if r.Method != http.MethodGet {
-    http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+    apierrors.Write(w, r, apierrors.MethodNotAllowed(r.Method))
     return
   }
 
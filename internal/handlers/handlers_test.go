@@ -9,14 +9,14 @@ import (
 )
 
 func TestNew(t *testing.T) {
-  h := New()
+  h := New(nil, nil, nil)
   if h == nil {
     t.Error("New() returned nil")
   }
 }
 
 func TestHandleHealth(t *testing.T) {
-  h := New()
+  h := New(nil, nil, nil)
 
   t.Run("GET request returns healthy status", func(t *testing.T) {
     req := httptest.NewRequest(http.MethodGet, "/health", nil)
@@ -78,7 +78,7 @@ func TestHandleHealth(t *testing.T) {
 }
 
 func TestHandleStatus(t *testing.T) {
-  h := New()
+  h := New(nil, nil, nil)
 
   t.Run("GET request returns status information", func(t *testing.T) {
     req := httptest.NewRequest(http.MethodGet, "/api/v1/status", nil)
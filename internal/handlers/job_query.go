@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/abstractions"
+	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/apierrors"
+	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/state_machine"
+	"github.ibm.com/julpayne/eval-hub-backend-svc/pkg/api"
+)
+
+// the query parameters of the evaluation job listing
+const (
+	paramStatusFilter   = "status_filter"
+	paramModelName      = "model_name"
+	paramModelURL       = "model_url"
+	paramBenchmarkID    = "benchmark_id"
+	paramCollectionID   = "collection_id"
+	paramExperimentName = "experiment_name"
+	paramExperimentTag  = "experiment_tag"
+	paramCreatedFrom    = "created_from"
+	paramCreatedTo      = "created_to"
+	paramUpdatedFrom    = "updated_from"
+	paramUpdatedTo      = "updated_to"
+	paramSort           = "sort"
+	paramAllTenants     = "all_tenants"
+)
+
+// parseJobQuery converts the query parameters of the evaluation job listing into a JobQuery, the
+// validation error has a detail for every invalid parameter
+func parseJobQuery(values url.Values) (*abstractions.JobQuery, error) {
+	query := &abstractions.JobQuery{
+		ModelName:      values.Get(paramModelName),
+		ModelURL:       values.Get(paramModelURL),
+		BenchmarkID:    values.Get(paramBenchmarkID),
+		CollectionID:   values.Get(paramCollectionID),
+		ExperimentName: values.Get(paramExperimentName),
+	}
+	problems := []api.ValidationError{}
+	invalid := func(param string, message string) {
+		problems = append(problems, api.ValidationError{Loc: []any{"query", param}, Msg: message, Type: apierrors.TypeInvalid})
+	}
+
+	if value := values.Get(paramStatusFilter); value != "" {
+		unknown := []string{}
+		for _, state := range strings.Split(value, ",") {
+			state := api.State(strings.TrimSpace(state))
+			if !state_machine.IsValid(state) {
+				unknown = append(unknown, strconv.Quote(string(state)))
+			}
+			query.States = append(query.States, state)
+		}
+		if len(unknown) > 0 {
+			invalid(paramStatusFilter, fmt.Sprintf("invalid state %s for the %s parameter", strings.Join(unknown, ", "), paramStatusFilter))
+		}
+	}
+
+	for _, tag := range values[paramExperimentTag] {
+		key, value, _ := strings.Cut(tag, ":")
+		if key == "" {
+			invalid(paramExperimentTag, fmt.Sprintf("invalid value %q for the %s parameter, expected key or key:value", tag, paramExperimentTag))
+			break
+		}
+		if query.ExperimentTags == nil {
+			query.ExperimentTags = map[string]string{}
+		}
+		query.ExperimentTags[key] = value
+	}
+
+	for _, bound := range []struct {
+		param string
+		value **time.Time
+	}{
+		{paramCreatedFrom, &query.CreatedFrom},
+		{paramCreatedTo, &query.CreatedTo},
+		{paramUpdatedFrom, &query.UpdatedFrom},
+		{paramUpdatedTo, &query.UpdatedTo},
+	} {
+		value := values.Get(bound.param)
+		if value == "" {
+			continue
+		}
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			invalid(bound.param, fmt.Sprintf("invalid value %q for the %s parameter, expected an RFC 3339 date-time", value, bound.param))
+			continue
+		}
+		*bound.value = &parsed
+	}
+	if (query.CreatedFrom != nil) && (query.CreatedTo != nil) && !query.CreatedFrom.Before(*query.CreatedTo) {
+		invalid(paramCreatedTo, fmt.Sprintf("the %s parameter must be after the %s parameter", paramCreatedTo, paramCreatedFrom))
+	}
+	if (query.UpdatedFrom != nil) && (query.UpdatedTo != nil) && !query.UpdatedFrom.Before(*query.UpdatedTo) {
+		invalid(paramUpdatedTo, fmt.Sprintf("the %s parameter must be after the %s parameter", paramUpdatedTo, paramUpdatedFrom))
+	}
+
+	if value := values.Get(paramSort); value != "" {
+		sort, err := abstractions.ParseJobSort(value)
+		if err != nil {
+			invalid(paramSort, fmt.Sprintf("invalid value %q for the %s parameter, expected one of %s optionally prefixed with -", value, paramSort, sortFields()))
+		}
+		query.Sort = sort
+	}
+
+	if value := values.Get(paramAllTenants); value != "" {
+		allTenants, err := strconv.ParseBool(value)
+		if err != nil {
+			invalid(paramAllTenants, fmt.Sprintf("invalid value %q for the %s parameter", value, paramAllTenants))
+		}
+		query.AllTenants = allTenants
+	}
+
+	if len(problems) > 0 {
+		return nil, apierrors.Validation(problems...)
+	}
+	if err := query.Validate(); err != nil {
+		return nil, apierrors.Invalid(err.Error(), "query")
+	}
+	return query, nil
+}
+
+func sortFields() string {
+	fields := make([]string, 0, len(abstractions.JobSortFields))
+	for _, field := range abstractions.JobSortFields {
+		fields = append(fields, string(field))
+	}
+	return strings.Join(fields, ", ")
+}
@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/abstractions"
+	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/apierrors"
+	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/storage/memory"
+	"github.ibm.com/julpayne/eval-hub-backend-svc/pkg/api"
+)
+
+func TestParseJobQuery(t *testing.T) {
+	t.Run("all the parameters are parsed", func(t *testing.T) {
+		values, _ := url.ParseQuery("status_filter=running,failed&model_name=granite&model_url=http://m&benchmark_id=mmlu" +
+			"&collection_id=col-1&experiment_name=exp&experiment_tag=team:nlp&experiment_tag=nightly" +
+			"&created_from=2026-01-01T00:00:00Z&created_to=2026-02-01T00:00:00Z&updated_from=2026-01-15T00:00:00%2B01:00&sort=-updated_at")
+		query, err := parseJobQuery(values)
+		if err != nil {
+			t.Fatalf("parseJobQuery() returned error: %v", err)
+		}
+		if (len(query.States) != 2) || (query.States[1] != api.StateFailed) || (query.ModelName != "granite") ||
+			(query.ModelURL != "http://m") || (query.BenchmarkID != "mmlu") || (query.CollectionID != "col-1") || (query.ExperimentName != "exp") {
+			t.Errorf("Unexpected filters %+v", query)
+		}
+		if (len(query.ExperimentTags) != 2) || (query.ExperimentTags["team"] != "nlp") || (query.ExperimentTags["nightly"] != "") {
+			t.Errorf("Unexpected experiment tags %v", query.ExperimentTags)
+		}
+		if !query.UpdatedFrom.Equal(time.Date(2026, 1, 14, 23, 0, 0, 0, time.UTC)) || (query.CreatedTo.Month() != time.February) || (query.UpdatedTo != nil) {
+			t.Errorf("Unexpected time ranges %v %v %v", query.CreatedTo, query.UpdatedFrom, query.UpdatedTo)
+		}
+		if query.Sort != (abstractions.JobSort{Field: abstractions.JobSortUpdatedAt, Descending: true}) {
+			t.Errorf("Unexpected sort %+v", query.Sort)
+		}
+	})
+
+	t.Run("all the invalid parameters are reported", func(t *testing.T) {
+		values, _ := url.ParseQuery("status_filter=done&experiment_tag=:x&created_from=yesterday&sort=name")
+		_, err := parseJobQuery(values)
+		var invalid *apierrors.Error
+		if !errors.As(err, &invalid) || (invalid.Code != apierrors.CodeValidation) {
+			t.Fatalf("Expected a validation error, got %v", err)
+		}
+		locs := []string{}
+		for _, detail := range invalid.Details {
+			locs = append(locs, fmt.Sprint(detail.Loc))
+		}
+		if strings.Join(locs, ",") != "[query status_filter],[query experiment_tag],[query created_from],[query sort]" {
+			t.Errorf("Expected a detail for every invalid parameter, got %v", locs)
+		}
+	})
+
+	t.Run("an empty time range is rejected", func(t *testing.T) {
+		values, _ := url.ParseQuery("updated_from=2026-01-02T00:00:00Z&updated_to=2026-01-01T00:00:00Z")
+		var invalid *apierrors.Error
+		if _, err := parseJobQuery(values); !errors.As(err, &invalid) || (fmt.Sprint(invalid.Details[0].Loc) != "[query updated_to]") {
+			t.Errorf("Expected the updated_to parameter to be reported, got %v", err)
+		}
+	})
+}
+
+func TestListEvaluationsQuery(t *testing.T) {
+	storage := memory.NewStorage()
+	h := New(storage, nil, nil)
+	for i, name := range []string{"llama", "granite", "mistral", "granite"} {
+		job := &api.EvaluationJobResource{
+			Resource: api.Resource{ID: fmt.Sprintf("job-%d", i), CreatedAt: time.Date(2026, 1, 1, i, 0, 0, 0, time.UTC)},
+			EvaluationJobConfig: api.EvaluationJobConfig{
+				Model:      api.ModelRef{URL: "http://" + name, Name: name},
+				Benchmarks: []api.BenchmarkConfig{{Ref: api.Ref{ID: "mmlu"}}},
+			},
+			Status: api.EvaluationJobStatus{EvaluationJobState: api.EvaluationJobState{State: api.StatePending}},
+		}
+		storage.CreateEvaluationJob(job)
+	}
+
+	ids := []string{}
+	target := "/api/v1/evaluations/jobs?sort=model_name&benchmark_id=mmlu&limit=3"
+	for target != "" {
+		w, list := listJobs(t, h, target)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status code %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+		for _, job := range list.Items {
+			ids = append(ids, job.ID)
+		}
+		target = ""
+		if list.Next != nil {
+			target = list.Next.Href
+		}
+	}
+	if strings.Join(ids, ",") != "job-1,job-3,job-0,job-2" {
+		t.Errorf("Expected the jobs by model name, got %v", ids)
+	}
+
+	if w, list := listJobs(t, h, "/api/v1/evaluations/jobs?model_url=http://granite&created_from=2026-01-01T02:00:00Z"); (w.Code != http.StatusOK) || (len(list.Items) != 1) || (list.Items[0].ID != "job-3") {
+		t.Errorf("Expected job-3, got %d: %s", w.Code, w.Body.String())
+	}
+	if w, _ := listJobs(t, h, "/api/v1/evaluations/jobs?sort=-unknown"); w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("Expected status code %d, got %d", http.StatusUnprocessableEntity, w.Code)
+	}
+}
@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/abstractions"
+	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/apierrors"
+	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/execution_context"
+	"github.ibm.com/julpayne/eval-hub-backend-svc/pkg/api"
+)
+
+// The query parameters of the leaderboard
+const (
+	leaderboardBenchmark       = "benchmark"
+	leaderboardCollection      = "collection"
+	leaderboardMetric          = "metric"
+	leaderboardMode            = "mode"
+	leaderboardOrder           = "order"
+	leaderboardTieBreak        = "tie_break"
+	leaderboardMinCompleteness = "min_completeness"
+	leaderboardLimit           = "limit"
+)
+
+// HandleGetLeaderboard handles GET /api/v1/evaluations/leaderboard, it ranks the models by a metric
+// of a benchmark, or by its mean over the benchmarks of a collection, using the latest or the best
+// completed evaluation of every model
+func (h *Handlers) HandleGetLeaderboard(ctx *execution_context.ExecutionContext, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(ctx, w, r, apierrors.MethodNotAllowed(r.Method))
+		return
+	}
+
+	values := r.URL.Query()
+	query, err := parseLeaderboardQuery(values)
+	if err != nil {
+		writeError(ctx, w, r, apierrors.BadRequest(err.Error()))
+		return
+	}
+	collectionID := values.Get(leaderboardCollection)
+	if collectionID != "" {
+		collection, err := h.tenantStorage(ctx).GetCollection(collectionID)
+		if err != nil {
+			writeError(ctx, w, r, err)
+			return
+		}
+		query.Benchmarks = collection.Benchmarks
+	}
+	if err := query.Validate(); err != nil {
+		writeError(ctx, w, r, apierrors.BadRequest(err.Error()))
+		return
+	}
+
+	entries, err := h.tenantStorage(ctx).GetLeaderboard(query)
+	if err != nil {
+		writeError(ctx, w, r, err)
+		return
+	}
+	writeJSON(ctx, w, http.StatusOK, &api.Leaderboard{
+		Benchmark:  values.Get(leaderboardBenchmark),
+		Collection: collectionID,
+		Metric:     query.Metric,
+		Mode:       string(query.Mode),
+		Items:      entries,
+	})
+}
+
+// parseLeaderboardQuery parses the parameters of the leaderboard, the benchmarks of a collection
+// are filled in by the caller
+func parseLeaderboardQuery(values url.Values) (*abstractions.LeaderboardQuery, error) {
+	query := &abstractions.LeaderboardQuery{
+		Metric:   values.Get(leaderboardMetric),
+		Mode:     abstractions.LeaderboardMode(values.Get(leaderboardMode)),
+		TieBreak: abstractions.LeaderboardTieBreak(values.Get(leaderboardTieBreak)),
+		Limit:    defaultPageLimit,
+	}
+
+	benchmark, collection := values.Get(leaderboardBenchmark), values.Get(leaderboardCollection)
+	if (benchmark == "") == (collection == "") {
+		return nil, fmt.Errorf("exactly one of the %s and %s parameters is required", leaderboardBenchmark, leaderboardCollection)
+	}
+	if benchmark != "" {
+		query.Benchmarks = []string{benchmark}
+	}
+	if query.Metric == "" {
+		return nil, fmt.Errorf("the %s parameter is required", leaderboardMetric)
+	}
+
+	switch order := values.Get(leaderboardOrder); order {
+	case "", "desc":
+	case "asc":
+		query.Ascending = true
+	default:
+		return nil, fmt.Errorf("invalid value %q for the %s parameter, expected asc or desc", order, leaderboardOrder)
+	}
+	if value := values.Get(leaderboardMinCompleteness); value != "" {
+		completeness, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value %q for the %s parameter, expected a number between 0 and 1", value, leaderboardMinCompleteness)
+		}
+		query.MinCompleteness = completeness
+	}
+	if value := values.Get(leaderboardLimit); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value %q for the %s parameter, expected an integer", value, leaderboardLimit)
+		}
+		query.Limit = limit
+	}
+	return query, nil
+}
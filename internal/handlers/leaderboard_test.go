@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/storage/memory"
+	"github.ibm.com/julpayne/eval-hub-backend-svc/pkg/api"
+)
+
+func TestHandleGetLeaderboard(t *testing.T) {
+	storage := memory.NewStorage()
+	h := New(storage, nil, newTestRegistry(t, testRegistry))
+	for _, scores := range [][2]float64{{0.5, 0.25}, {0.75, 0.5}} {
+		var job api.EvaluationJobResource
+		json.Unmarshal(createJob(t, h, validJob).Body.Bytes(), &job)
+		storage.RecordBenchmarkResult(job.ID, api.EvaluationJobBenchmarkResult{ID: "mmlu", State: api.StateCompleted, AttemptID: "1", Metrics: map[string]any{"acc": scores[0]}})
+		storage.RecordBenchmarkResult(job.ID, api.EvaluationJobBenchmarkResult{ID: "arc", State: api.StateCompleted, AttemptID: "1", Metrics: map[string]any{"acc": scores[1]}})
+	}
+	_, collection := callCollections(t, h, http.MethodPost, collectionsPath, `{"name": "all", "benchmarks": ["mmlu", "arc"]}`)
+
+	leaderboard := func(query string) (*httptest.ResponseRecorder, api.Leaderboard) {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/evaluations/leaderboard?"+query, nil)
+		w := httptest.NewRecorder()
+		h.HandleGetLeaderboard(newTestContext(t, req), w, req)
+		var board api.Leaderboard
+		json.Unmarshal(w.Body.Bytes(), &board)
+		return w, board
+	}
+
+	w, board := leaderboard("benchmark=mmlu&metric=acc&mode=best")
+	if (w.Code != http.StatusOK) || (board.Mode != "best") || (len(board.Items) != 1) || (board.Items[0].Score != 0.75) || (board.Items[0].Evaluations != 2) {
+		t.Errorf("Expected the best score of granite, got %d: %s", w.Code, w.Body.String())
+	}
+	w, board = leaderboard("collection=" + collection.ID + "&metric=acc&order=asc&min_completeness=1")
+	if (w.Code != http.StatusOK) || (board.Collection != collection.ID) || (len(board.Items) != 1) || (board.Items[0].Score != 0.625) {
+		t.Errorf("Expected the latest mean over the collection, got %d: %s", w.Code, w.Body.String())
+	}
+
+	testCases := []struct {
+		name   string
+		query  string
+		status int
+	}{
+		{"no benchmark", "metric=acc", http.StatusBadRequest},
+		{"benchmark and collection", "benchmark=mmlu&collection=" + collection.ID + "&metric=acc", http.StatusBadRequest},
+		{"no metric", "benchmark=mmlu", http.StatusBadRequest},
+		{"invalid mode", "benchmark=mmlu&metric=acc&mode=worst", http.StatusBadRequest},
+		{"invalid order", "benchmark=mmlu&metric=acc&order=up", http.StatusBadRequest},
+		{"invalid tie break", "benchmark=mmlu&metric=acc&tie_break=random", http.StatusBadRequest},
+		{"invalid completeness", "benchmark=mmlu&metric=acc&min_completeness=2", http.StatusBadRequest},
+		{"invalid limit", "benchmark=mmlu&metric=acc&limit=0", http.StatusBadRequest},
+		{"missing collection", "collection=missing&metric=acc", http.StatusNotFound},
+	}
+	for _, tc := range testCases {
+		if w, _ := leaderboard(tc.query); w.Code != tc.status {
+			t.Errorf("%s: expected status code %d, got %d: %s", tc.name, tc.status, w.Code, w.Body.String())
+		}
+	}
+}
@@ -1,14 +1,17 @@
 package handlers
 
 import (
+  "fmt"
   "net/http"
   "os"
   "path/filepath"
+
+  "github.ibm.com/julpayne/eval-hub-backend-svc/internal/apierrors"
 )
 
 func (h *Handlers) HandleOpenAPI(w http.ResponseWriter, r *http.Request) {
   if r.Method != http.MethodGet {
-    http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+    apierrors.Write(w, r, apierrors.MethodNotAllowed(r.Method))
     return
   }
 
@@ -49,7 +52,7 @@ func (h *Handlers) HandleOpenAPI(w http.ResponseWriter, r *http.Request) {
   }
 
   if err != nil {
-    http.Error(w, "Failed to read OpenAPI spec: "+err.Error(), http.StatusInternalServerError)
+    apierrors.Write(w, r, apierrors.Internal(fmt.Errorf("failed to read the OpenAPI spec: %w", err)))
     return
   }
 
@@ -58,7 +61,7 @@ func (h *Handlers) HandleOpenAPI(w http.ResponseWriter, r *http.Request) {
 
 func (h *Handlers) HandleDocs(w http.ResponseWriter, r *http.Request) {
   if r.Method != http.MethodGet {
-    http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+    apierrors.Write(w, r, apierrors.MethodNotAllowed(r.Method))
     return
   }
 
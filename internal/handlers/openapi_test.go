@@ -9,7 +9,7 @@ import (
 )
 
 func TestHandleOpenAPI(t *testing.T) {
-  h := New()
+  h := New(nil, nil, nil)
 
   // Ensure the OpenAPI file exists for testing
   apiPath := filepath.Join("..", "..", "api", "openapi.yaml")
@@ -73,7 +73,7 @@ func TestHandleOpenAPI(t *testing.T) {
 }
 
 func TestHandleDocs(t *testing.T) {
-  h := New()
+  h := New(nil, nil, nil)
 
   t.Run("GET request returns HTML documentation", func(t *testing.T) {
     req := httptest.NewRequest(http.MethodGet, "/docs", nil)
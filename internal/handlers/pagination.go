@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/abstractions"
+	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/execution_context"
+	"github.ibm.com/julpayne/eval-hub-backend-svc/pkg/api"
+)
+
+const (
+	defaultPageLimit = 50
+	maxPageLimit     = 100
+	// the query parameters of the pagination, the other parameters of a listing are its filters
+	paramLimit  = "limit"
+	paramOffset = "offset"
+	paramCursor = "cursor"
+)
+
+// randomCursorSecret signs the cursors when no secret is configured
+var randomCursorSecret = sync.OnceValue(func() []byte {
+	secret := make([]byte, 32)
+	rand.Read(secret)
+	return secret
+})
+
+// cursor is the signed content of the cursor parameter. It holds the position of the last resource
+// of the previous page, which keeps the pages stable when resources are created in the meantime,
+// and the filters of the listing so that a cursor can not be reused with other filters.
+type cursor struct {
+	After   string `json:"a"`
+	Filters string `json:"f"`
+}
+
+// pageRequest is the pagination of a listing request
+type pageRequest struct {
+	path    string
+	filters url.Values
+	limit   int
+}
+
+// parsePageRequest validates the limit, offset and cursor parameters and adds them to the storage
+// query. One more resource than the limit is requested to know whether there is a next page.
+func parsePageRequest(ctx *execution_context.ExecutionContext, r *http.Request, query abstractions.Query) (*pageRequest, error) {
+	values := r.URL.Query()
+	request := &pageRequest{path: r.URL.Path, filters: url.Values{}, limit: defaultPageLimit}
+	for key, value := range values {
+		if (key != paramLimit) && (key != paramOffset) && (key != paramCursor) {
+			request.filters[key] = value
+		}
+	}
+
+	if value := values.Get(paramLimit); value != "" {
+		limit, err := strconv.Atoi(value)
+		if (err != nil) || (limit < 1) || (limit > maxPageLimit) {
+			return nil, fmt.Errorf("invalid value %q for the limit parameter, expected an integer between 1 and %d", value, maxPageLimit)
+		}
+		request.limit = limit
+	}
+	query[abstractions.QueryLimit] = strconv.Itoa(request.limit + 1)
+
+	if value := values.Get(paramOffset); value != "" {
+		offset, err := strconv.Atoi(value)
+		if (err != nil) || (offset < 0) {
+			return nil, fmt.Errorf("invalid value %q for the offset parameter, expected a non negative integer", value)
+		}
+		query[abstractions.QueryOffset] = value
+	}
+
+	if value := values.Get(paramCursor); value != "" {
+		decoded, err := decodeCursor(ctx, value)
+		if err != nil {
+			return nil, err
+		}
+		if decoded.Filters != request.filters.Encode() {
+			return nil, fmt.Errorf("the cursor does not match the filters of the request")
+		}
+		query[abstractions.QueryAfter] = decoded.After
+	}
+	return request, nil
+}
+
+// paginate trims the extra resource requested by parsePageRequest and sets the links of the page,
+// position returns the abstractions.QueryAfter value of an item
+func paginate[T any](ctx *execution_context.ExecutionContext, request *pageRequest, page *api.Page, items []T, position func(*T) string) []T {
+	values := url.Values{}
+	for key, value := range request.filters {
+		values[key] = value
+	}
+	values.Set(paramLimit, strconv.Itoa(request.limit))
+	page.First = &api.HRef{Href: request.path + "?" + values.Encode()}
+	page.Limit = request.limit
+	page.Next = nil
+
+	if len(items) > request.limit {
+		items = items[:request.limit]
+		last := position(&items[len(items)-1])
+		values.Set(paramCursor, encodeCursor(ctx, cursor{After: last, Filters: request.filters.Encode()}))
+		page.Next = &api.HRef{Href: request.path + "?" + values.Encode()}
+	}
+	return items
+}
+
+func encodeCursor(ctx *execution_context.ExecutionContext, value cursor) string {
+	data, _ := json.Marshal(value)
+	payload := base64.RawURLEncoding.EncodeToString(data)
+	return payload + "." + base64.RawURLEncoding.EncodeToString(signCursor(ctx, payload))
+}
+
+func decodeCursor(ctx *execution_context.ExecutionContext, value string) (*cursor, error) {
+	invalid := fmt.Errorf("invalid value for the cursor parameter")
+	payload, signature, found := strings.Cut(value, ".")
+	decodedSignature, err := base64.RawURLEncoding.DecodeString(signature)
+	if !found || (err != nil) || !hmac.Equal(decodedSignature, signCursor(ctx, payload)) {
+		return nil, invalid
+	}
+	data, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return nil, invalid
+	}
+	decoded := &cursor{}
+	if err := json.Unmarshal(data, decoded); err != nil {
+		return nil, invalid
+	}
+	return decoded, nil
+}
+
+func signCursor(ctx *execution_context.ExecutionContext, payload string) []byte {
+	secret := randomCursorSecret()
+	if (ctx.Config != nil) && (ctx.Config.Service != nil) && (ctx.Config.Service.CursorSecret != "") {
+		secret = []byte(ctx.Config.Service.CursorSecret)
+	}
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(payload))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/storage/memory"
+	"github.ibm.com/julpayne/eval-hub-backend-svc/pkg/api"
+)
+
+func listJobs(t *testing.T, h *Handlers, target string) (*httptest.ResponseRecorder, api.EvaluationJobResourceList) {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	w := httptest.NewRecorder()
+	h.HandleListEvaluations(newTestContext(t, req), w, req)
+	var list api.EvaluationJobResourceList
+	json.Unmarshal(w.Body.Bytes(), &list)
+	return w, list
+}
+
+func TestPagination(t *testing.T) {
+	h := New(memory.NewStorage(), nil, nil)
+	for range 5 {
+		createJob(t, h, validJob)
+	}
+
+	t.Run("the pages are linked and stable under concurrent inserts", func(t *testing.T) {
+		seen := map[string]bool{}
+		w, list := listJobs(t, h, "/api/v1/evaluations/jobs?limit=2")
+		if (w.Code != http.StatusOK) || (list.Limit != 2) || (list.TotalCount != 5) || (list.First.Href != "/api/v1/evaluations/jobs?limit=2") {
+			t.Fatalf("Expected the first page, got %d: %s", w.Code, w.Body.String())
+		}
+		pages := 1
+		for {
+			for _, job := range list.Items {
+				if seen[job.ID] {
+					t.Fatalf("The job %s is listed twice", job.ID)
+				}
+				seen[job.ID] = true
+			}
+			if list.Next == nil {
+				break
+			}
+			if pages == 1 {
+				// a job created while paging is listed at the end
+				createJob(t, h, validJob)
+			}
+			_, list = listJobs(t, h, list.Next.Href)
+			pages++
+		}
+		if (len(seen) != 6) || (pages != 3) {
+			t.Errorf("Expected 6 jobs in 3 pages, got %d jobs in %d pages", len(seen), pages)
+		}
+	})
+
+	t.Run("offset skips jobs", func(t *testing.T) {
+		_, all := listJobs(t, h, "/api/v1/evaluations/jobs")
+		_, list := listJobs(t, h, "/api/v1/evaluations/jobs?limit=2&offset=4")
+		if (len(list.Items) != 2) || (list.Items[0].ID != all.Items[4].ID) || (list.Next != nil) {
+			t.Errorf("Expected the last 2 jobs, got %+v", list.Items)
+		}
+	})
+
+	t.Run("invalid parameters are rejected", func(t *testing.T) {
+		_, list := listJobs(t, h, "/api/v1/evaluations/jobs?limit=1&status_filter=pending")
+		next := list.Next.Href
+		for _, target := range []string{
+			"/api/v1/evaluations/jobs?limit=0",
+			"/api/v1/evaluations/jobs?limit=101",
+			"/api/v1/evaluations/jobs?offset=-1",
+			"/api/v1/evaluations/jobs?cursor=garbage",
+			// a tampered cursor
+			strings.Replace(next, "cursor=", "cursor=x", 1),
+			// the cursor of other filters
+			strings.Replace(next, "status_filter=pending", "status_filter=running", 1),
+		} {
+			if w, _ := listJobs(t, h, target); w.Code != http.StatusBadRequest {
+				t.Errorf("Expected status code %d for %s, got %d", http.StatusBadRequest, target, w.Code)
+			}
+		}
+	})
+}
@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/apierrors"
+	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/execution_context"
+)
+
+// maxRequestBodyBytes limits the size of the JSON documents accepted by the API
+const maxRequestBodyBytes = 1 << 20
+
+// writeJSON writes the value as a JSON response with the given status code
+func writeJSON(ctx *execution_context.ExecutionContext, w http.ResponseWriter, status int, value any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(value); err != nil {
+		ctx.Logger.Error("Failed to write the response", "error", err.Error())
+	}
+}
+
+// writeError logs the error and writes its error response, the typed storage errors are mapped to
+// not found and conflict and an illegal state transition is a conflict with the current state of
+// the resource
+func writeError(ctx *execution_context.ExecutionContext, w http.ResponseWriter, r *http.Request, err error) {
+	apiErr := apierrors.From(err)
+	if apiErr.Status() >= http.StatusInternalServerError {
+		ctx.Logger.Error("Request failed", "code", apiErr.Status(), "error", apiErr.Error())
+	} else {
+		ctx.Logger.Info("Request rejected", "code", apiErr.Status(), "error", apiErr.Error())
+	}
+	apierrors.Write(w, r, apiErr)
+}
+
+// decodeJSON decodes the request body into the value, unknown fields are rejected so that typos
+// in optional fields are not silently ignored
+func decodeJSON(r *http.Request, w http.ResponseWriter, value any) error {
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestBodyBytes))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(value); err != nil {
+		if errors.Is(err, io.EOF) {
+			return fmt.Errorf("the request body is required")
+		}
+		return fmt.Errorf("invalid request body: %w", err)
+	}
+	if decoder.More() {
+		return fmt.Errorf("invalid request body: unexpected data after the JSON document")
+	}
+	return nil
+}
+
+// negotiateContentType returns the offered media type that the Accept header of the request prefers,
+// the first offer when the header is missing and an empty string when no offer is acceptable. The
+// wildcards are honoured and the quality values break the ties between the listed types.
+func negotiateContentType(r *http.Request, offers ...string) string {
+	accept := r.Header.Get("Accept")
+	if strings.TrimSpace(accept) == "" {
+		return offers[0]
+	}
+	best, bestQuality, bestSpecificity := "", 0.0, -1
+	for _, mediaRange := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(mediaRange))
+		if err != nil {
+			continue
+		}
+		quality := 1.0
+		if q, found := params["q"]; found {
+			if quality, err = strconv.ParseFloat(q, 64); err != nil {
+				continue
+			}
+		}
+		for _, offer := range offers {
+			specificity := -1
+			switch {
+			case mediaType == offer:
+				specificity = 2
+			case strings.HasSuffix(mediaType, "/*") && strings.HasPrefix(offer, strings.TrimSuffix(mediaType, "*")):
+				specificity = 1
+			case mediaType == "*/*":
+				specificity = 0
+			}
+			if (specificity < 0) || (quality <= 0) {
+				continue
+			}
+			if (quality > bestQuality) || ((quality == bestQuality) && (specificity > bestSpecificity)) {
+				best, bestQuality, bestSpecificity = offer, quality, specificity
+			}
+		}
+	}
+	return best
+}
+
+// pathID returns the last segment of the request path, which is the ID for the resource endpoints
+func pathID(r *http.Request) string {
+	pathParts := strings.Split(strings.TrimSuffix(r.URL.Path, "/"), "/")
+	return pathParts[len(pathParts)-1]
+}
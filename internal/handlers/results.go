@@ -0,0 +1,167 @@
+package handlers
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/apierrors"
+	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/execution_context"
+	"github.ibm.com/julpayne/eval-hub-backend-svc/pkg/api"
+)
+
+const (
+	// maxAttemptIDLength limits the size of the attempt IDs chosen by the runners
+	maxAttemptIDLength = 256
+	// resultTrackingTimeout bounds the logging of a result to the experiment tracking service
+	resultTrackingTimeout = time.Minute
+)
+
+// HandleCreateBenchmarkResult handles POST /api/v1/evaluations/jobs/{id}/benchmarks/{benchmark_id}/results.
+// The runtimes and the external runners report the result of a benchmark with the runner token,
+// the benchmark moves to the state of the result and the results of the job are recomputed. The
+// result is recorded once per attempt: 201 is returned when it is recorded and 200 when the same
+// attempt reports it again.
+func (h *Handlers) HandleCreateBenchmarkResult(ctx *execution_context.ExecutionContext, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(ctx, w, r, apierrors.MethodNotAllowed(r.Method))
+		return
+	}
+	if err := authorizeRunner(ctx, r); err != nil {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="runners"`)
+		writeError(ctx, w, r, apierrors.Unauthorized(err.Error()))
+		return
+	}
+
+	request := api.BenchmarkResultRequest{}
+	if err := decodeJSON(r, w, &request); err != nil {
+		writeError(ctx, w, r, apierrors.BadRequest(err.Error()))
+		return
+	}
+	if err := validateBenchmarkResult(&request); err != nil {
+		writeError(ctx, w, r, apierrors.AsValidation(err))
+		return
+	}
+
+	jobID, benchmarkID := resultPathIDs(r)
+	result := api.EvaluationJobBenchmarkResult{
+		ID:          benchmarkID,
+		Name:        benchmarkID,
+		State:       request.State,
+		AttemptID:   request.AttemptID,
+		StartedAt:   request.StartedAt,
+		CompletedAt: request.CompletedAt,
+		Metrics:     request.Metrics,
+		Error:       request.Error,
+	}
+	if benchmark, found := h.registry.Benchmark(benchmarkID); found && (benchmark.Label != "") {
+		result.Name = benchmark.Label
+	}
+	ctx.EvaluationID = jobID
+	ctx.Logger = ctx.Logger.With("evaluation_id", jobID, "benchmark_id", benchmarkID, "attempt_id", request.AttemptID)
+
+	job, recorded, err := h.tenantStorage(ctx).RecordBenchmarkResult(jobID, result)
+	if err != nil {
+		writeError(ctx, w, r, err)
+		return
+	}
+	if !recorded {
+		ctx.Logger.Info("The benchmark result was already recorded")
+		writeJSON(ctx, w, http.StatusOK, job)
+		return
+	}
+	ctx.Logger.Info("Recorded the benchmark result", "state", request.State, "metrics", len(request.Metrics))
+
+	// experiment tracking is best effort, the result is logged in the background so that the
+	// runners do not wait for the tracking server and it is recorded even if the server is unavailable
+	if ctx.MLflowClient != nil {
+		tracker, logger := ctx.MLflowClient, ctx.Logger
+		go func() {
+			background, cancel := context.WithTimeout(context.Background(), resultTrackingTimeout)
+			defer cancel()
+			if err := tracker.LogBenchmarkResult(background, job, &result); err != nil {
+				logger.Warn("Failed to log the benchmark result to MLflow", "error", err.Error())
+			}
+		}()
+	}
+	writeJSON(ctx, w, http.StatusCreated, job)
+}
+
+// authorizeRunner checks the runner token when the request is not authorized by the roles of its
+// principal, the results are then rejected when no token is configured
+func authorizeRunner(ctx *execution_context.ExecutionContext, r *http.Request) error {
+	if ctx.Authorization != nil {
+		return nil
+	}
+	if (ctx.Config == nil) || (ctx.Config.Runners == nil) || (ctx.Config.Runners.Token == "") {
+		return errors.New("no runner token is configured, the results can not be reported")
+	}
+	token, found := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !found || (subtle.ConstantTimeCompare([]byte(token), []byte(ctx.Config.Runners.Token)) != 1) {
+		return errors.New("a valid runner token is required")
+	}
+	return nil
+}
+
+// validateBenchmarkResult checks that the result is final, a completed benchmark reports its
+// metrics and a failed one may report the error instead
+func validateBenchmarkResult(request *api.BenchmarkResultRequest) error {
+	if strings.TrimSpace(request.AttemptID) == "" {
+		return apierrors.Invalid("attempt_id is required", "body", "attempt_id")
+	}
+	if len(request.AttemptID) > maxAttemptIDLength {
+		return apierrors.Invalid(fmt.Sprintf("attempt_id must not be longer than %d characters", maxAttemptIDLength), "body", "attempt_id")
+	}
+	switch request.State {
+	case api.StateCompleted:
+		if len(request.Metrics) == 0 {
+			return apierrors.Invalid("metrics are required for a completed benchmark", "body", "metrics")
+		}
+		if request.Error != nil {
+			return apierrors.Invalid("error is only allowed for a failed benchmark", "body", "error")
+		}
+	case api.StateFailed:
+	default:
+		return apierrors.Invalid(fmt.Sprintf("state must be %s or %s, got %q", api.StateCompleted, api.StateFailed, request.State), "body", "state")
+	}
+	if err := validateMetrics("metrics", request.Metrics); err != nil {
+		return err
+	}
+	if (request.StartedAt != nil) && (request.CompletedAt != nil) && request.CompletedAt.Before(*request.StartedAt) {
+		return apierrors.Invalid("completed_at must not be before started_at", "body", "completed_at")
+	}
+	return nil
+}
+
+// validateMetrics checks that the metrics are numbers, booleans or strings, nested objects group
+// related metrics
+func validateMetrics(path string, metrics map[string]any) error {
+	for name, value := range metrics {
+		if strings.TrimSpace(name) == "" {
+			return fmt.Errorf("%s must not have empty names", path)
+		}
+		switch value := value.(type) {
+		case float64, bool, string:
+		case map[string]any:
+			if err := validateMetrics(path+"."+name, value); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("%s.%s must be a number, a boolean, a string or an object", path, name)
+		}
+	}
+	return nil
+}
+
+// resultPathIDs returns the job and the benchmark IDs of a path ending in /benchmarks/{benchmark_id}/results
+func resultPathIDs(r *http.Request) (string, string) {
+	pathParts := strings.Split(strings.TrimSuffix(r.URL.Path, "/"), "/")
+	if len(pathParts) < 4 {
+		return "", ""
+	}
+	return pathParts[len(pathParts)-4], pathParts[len(pathParts)-2]
+}
@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/config"
+	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/storage/memory"
+	"github.ibm.com/julpayne/eval-hub-backend-svc/pkg/api"
+)
+
+const testRunnerToken = "runner-token"
+
+func TestHandleCreateBenchmarkResult(t *testing.T) {
+	h := New(memory.NewStorage(), nil, newTestRegistry(t, testRegistry))
+	var job api.EvaluationJobResource
+	json.Unmarshal(createJob(t, h, validJob).Body.Bytes(), &job)
+	tracker := &fakeTracker{logged: make(chan string, 10)}
+
+	report := func(t *testing.T, path string, token string, body string) (*httptest.ResponseRecorder, api.EvaluationJobResource) {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		ctx := newTestContext(t, req)
+		ctx.Config.Runners = &config.RunnersConfig{Token: testRunnerToken}
+		ctx.MLflowClient = tracker
+		w := httptest.NewRecorder()
+		h.HandleCreateBenchmarkResult(ctx, w, req)
+		var updated api.EvaluationJobResource
+		json.Unmarshal(w.Body.Bytes(), &updated)
+		return w, updated
+	}
+	resultsPath := func(benchmarkID string) string {
+		return evaluationJobsPath + "/" + job.ID + "/benchmarks/" + benchmarkID + "/results"
+	}
+	completed := `{"attempt_id": "a1", "state": "completed", "metrics": {"acc": 0.8, "subtasks": {"algebra": 0.5}}}`
+
+	t.Run("a valid runner token is required", func(t *testing.T) {
+		for _, token := range []string{"", "wrong"} {
+			if w, _ := report(t, resultsPath("mmlu"), token, completed); (w.Code != http.StatusUnauthorized) || (w.Header().Get("WWW-Authenticate") == "") {
+				t.Errorf("Expected status code %d for token %q, got %d", http.StatusUnauthorized, token, w.Code)
+			}
+		}
+		req := httptest.NewRequest(http.MethodPost, resultsPath("mmlu"), strings.NewReader(completed))
+		req.Header.Set("Authorization", "Bearer "+testRunnerToken)
+		w := httptest.NewRecorder()
+		h.HandleCreateBenchmarkResult(newTestContext(t, req), w, req)
+		if w.Code != http.StatusUnauthorized {
+			t.Errorf("Expected status code %d without a configured token, got %d", http.StatusUnauthorized, w.Code)
+		}
+	})
+
+	t.Run("invalid results are rejected", func(t *testing.T) {
+		testCases := []struct {
+			name   string
+			body   string
+			status int
+		}{
+			{"empty body", ``, http.StatusBadRequest},
+			{"unknown field", `{"attempt_id": "a1", "state": "completed", "metrics": {"acc": 1}, "score": 1}`, http.StatusBadRequest},
+			{"missing attempt", `{"state": "completed", "metrics": {"acc": 1}}`, http.StatusUnprocessableEntity},
+			{"not final", `{"attempt_id": "a1", "state": "running"}`, http.StatusUnprocessableEntity},
+			{"no metrics", `{"attempt_id": "a1", "state": "completed"}`, http.StatusUnprocessableEntity},
+			{"error on completion", `{"attempt_id": "a1", "state": "completed", "metrics": {"acc": 1}, "error": "x"}`, http.StatusUnprocessableEntity},
+			{"list metric", `{"attempt_id": "a1", "state": "completed", "metrics": {"acc": [1]}}`, http.StatusUnprocessableEntity},
+			{"null metric", `{"attempt_id": "a1", "state": "completed", "metrics": {"nested": {"acc": null}}}`, http.StatusUnprocessableEntity},
+			{"completed before started", `{"attempt_id": "a1", "state": "failed", "started_at": "2026-01-02T00:00:00Z", "completed_at": "2026-01-01T00:00:00Z"}`, http.StatusUnprocessableEntity},
+		}
+		for _, tc := range testCases {
+			if w, _ := report(t, resultsPath("mmlu"), testRunnerToken, tc.body); w.Code != tc.status {
+				t.Errorf("%s: expected status code %d, got %d: %s", tc.name, tc.status, w.Code, w.Body.String())
+			}
+		}
+		if w, _ := report(t, resultsPath("hellaswag"), testRunnerToken, completed); w.Code != http.StatusNotFound {
+			t.Errorf("Expected status code %d for a benchmark of another job, got %d", http.StatusNotFound, w.Code)
+		}
+		if w, _ := report(t, evaluationJobsPath+"/missing/benchmarks/mmlu/results", testRunnerToken, completed); w.Code != http.StatusNotFound {
+			t.Errorf("Expected status code %d for a missing job, got %d", http.StatusNotFound, w.Code)
+		}
+	})
+
+	t.Run("a result is recorded once per attempt", func(t *testing.T) {
+		w, updated := report(t, resultsPath("mmlu"), testRunnerToken, completed)
+		if (w.Code != http.StatusCreated) || (updated.Status.State != api.StateRunning) || (updated.Results == nil) || (updated.Results.CompletedEvaluations != 1) {
+			t.Fatalf("Expected the result to be recorded, got %d: %s", w.Code, w.Body.String())
+		}
+		result := updated.Results.Benchmarks[0]
+		if (result.AttemptID != "a1") || (result.Name != "mmlu") || (result.CompletedAt == nil) || (updated.Results.AggregatedMetrics["subtasks.algebra"] == nil) {
+			t.Errorf("Unexpected result %+v", updated.Results)
+		}
+
+		if w, _ := report(t, resultsPath("mmlu"), testRunnerToken, completed); w.Code != http.StatusOK {
+			t.Errorf("Expected status code %d when the attempt reports again, got %d", http.StatusOK, w.Code)
+		}
+		if w, _ := report(t, resultsPath("mmlu"), testRunnerToken, `{"attempt_id": "a2", "state": "failed"}`); w.Code != http.StatusConflict {
+			t.Errorf("Expected status code %d for another attempt, got %d", http.StatusConflict, w.Code)
+		}
+		select {
+		case logged := <-tracker.logged:
+			if logged != "mmlu/a1" {
+				t.Errorf("Expected the result of the first attempt to be logged to MLflow, got %s", logged)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("Expected the result to be logged to MLflow")
+		}
+		select {
+		case logged := <-tracker.logged:
+			t.Errorf("Expected the result to be logged once to MLflow, got %s again", logged)
+		case <-time.After(100 * time.Millisecond):
+		}
+	})
+
+	t.Run("the job finishes with its last result", func(t *testing.T) {
+		w, updated := report(t, resultsPath("arc")+"/", testRunnerToken, `{"attempt_id": "b1", "state": "failed", "error": "out of memory"}`)
+		if (w.Code != http.StatusCreated) || (updated.Status.State != api.StateFailed) {
+			t.Fatalf("Expected the job to fail, got %d: %s", w.Code, w.Body.String())
+		}
+		if (updated.Results.TotalEvaluations != 2) || (updated.Results.CompletedEvaluations != 1) || (updated.Results.FailedEvaluations != 1) {
+			t.Errorf("Expected the counters to be updated, got %+v", updated.Results)
+		}
+		if (updated.Status.Benchmarks[1].Message != "out of memory") || (*updated.Results.Benchmarks[1].Error != "out of memory") {
+			t.Errorf("Expected the error of arc, got %+v", updated.Status.Benchmarks[1])
+		}
+	})
+}
@@ -30,10 +30,25 @@ func NewLogger() (*slog.Logger, error) {
 	return slog.New(zapslog.NewHandler(zapLog.Core())), nil
 }
 
+// RequestIDHeader holds the ID of a request, it is generated when the client does not send it
+const RequestIDHeader = "X-Global-Transaction-Id"
+
+// RequestIDMiddleware sets a generated ID on the requests without one and returns the ID of the
+// request in the response header, so that the logs and the error responses report the same ID
+func RequestIDMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(RequestIDHeader) == "" {
+			r.Header.Set(RequestIDHeader, uuid.New().String())
+		}
+		w.Header().Set(RequestIDHeader, r.Header.Get(RequestIDHeader))
+		next.ServeHTTP(w, r)
+	})
+}
+
 // LoggerWithRequest enhances a logger with request-specific fields
 func LoggerWithRequest(logger *slog.Logger, r *http.Request) *slog.Logger {
 	// Extract RequestID from X-Global-Transaction-Id header, or generate a UUID if not present
-	requestID := r.Header.Get("X-Global-Transaction-Id")
+	requestID := r.Header.Get(RequestIDHeader)
 	if requestID == "" {
 		requestID = uuid.New().String()
 	}
@@ -69,17 +84,8 @@ func LoggerWithRequest(logger *slog.Logger, r *http.Request) *slog.Logger {
 		enhancedLogger = enhancedLogger.With(constants.LOG_REMOTE_ADR, remoteAddr)
 	}
 
-	// Extract remote_user from URL user info or header
-	remoteUser := ""
-	if r.URL != nil && r.URL.User != nil {
-		remoteUser = r.URL.User.Username()
-	}
-	if remoteUser == "" {
-		remoteUser = r.Header.Get("Remote-User")
-	}
-	if remoteUser != "" {
-		enhancedLogger = enhancedLogger.With(constants.LOG_USER, remoteUser)
-	}
+	// The remote_user is added from the authenticated principal by the execution context, the
+	// request headers can not be trusted for it
 
 	referer := r.Header.Get("Referer")
 	if referer != "" {
@@ -0,0 +1,232 @@
+// Package mlflow is a client of the MLflow tracking REST API and the MLflow implementation of
+// abstractions.ExperimentTracker.
+package mlflow
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/config"
+)
+
+const (
+	apiPrefix      = "/api/2.0/mlflow/"
+	defaultTimeout = 10 * time.Second
+	// the limits of a single runs/log-batch request
+	maxBatchMetrics = 1000
+	maxBatchParams  = 100
+)
+
+// Run statuses accepted by UpdateRun
+const (
+	RunStatusFinished = "FINISHED"
+	RunStatusFailed   = "FAILED"
+	RunStatusKilled   = "KILLED"
+)
+
+// Tag is a key value pair attached to an experiment or a run
+type Tag struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// Metric is a single metric value of a run, the timestamp is in milliseconds
+type Metric struct {
+	Key       string  `json:"key"`
+	Value     float64 `json:"value"`
+	Timestamp int64   `json:"timestamp"`
+	Step      int64   `json:"step"`
+}
+
+// Param is a single parameter of a run
+type Param struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// Experiment is the part of an MLflow experiment used by the service
+type Experiment struct {
+	ExperimentID string `json:"experiment_id"`
+	Name         string `json:"name"`
+	Tags         []Tag  `json:"tags,omitempty"`
+}
+
+// APIError is an error response of the tracking server
+type APIError struct {
+	StatusCode int
+	ErrorCode  string `json:"error_code"`
+	Message    string `json:"message"`
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("mlflow returned %d %s: %s", e.StatusCode, e.ErrorCode, e.Message)
+}
+
+// hasErrorCode checks the MLflow error code of an error
+func hasErrorCode(err error, code string) bool {
+	var apiError *APIError
+	return errors.As(err, &apiError) && (apiError.ErrorCode == code)
+}
+
+// Client calls the MLflow tracking REST API
+type Client struct {
+	trackingURI string
+	token       string
+	httpClient  *http.Client
+}
+
+// NewClient creates a client for the tracking server of the configuration
+func NewClient(mlflowConfig *config.MLflowConfig) (*Client, error) {
+	if (mlflowConfig == nil) || (mlflowConfig.TrackingURI == "") {
+		return nil, fmt.Errorf("the MLflow tracking URI is required")
+	}
+	parsed, err := url.Parse(mlflowConfig.TrackingURI)
+	if (err != nil) || ((parsed.Scheme != "http") && (parsed.Scheme != "https")) || (parsed.Host == "") {
+		return nil, fmt.Errorf("the MLflow tracking URI %q must be an absolute http or https URL", mlflowConfig.TrackingURI)
+	}
+	timeout := mlflowConfig.Timeout
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+	return &Client{
+		trackingURI: strings.TrimSuffix(mlflowConfig.TrackingURI, "/"),
+		token:       mlflowConfig.Token,
+		httpClient:  &http.Client{Timeout: timeout},
+	}, nil
+}
+
+// GetExperimentByName returns the experiment with the name, or nil when it does not exist
+func (c *Client) GetExperimentByName(ctx context.Context, name string) (*Experiment, error) {
+	response := struct {
+		Experiment Experiment `json:"experiment"`
+	}{}
+	err := c.call(ctx, http.MethodGet, "experiments/get-by-name?experiment_name="+url.QueryEscape(name), nil, &response)
+	if hasErrorCode(err, "RESOURCE_DOES_NOT_EXIST") {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &response.Experiment, nil
+}
+
+// CreateExperiment creates an experiment and returns its ID
+func (c *Client) CreateExperiment(ctx context.Context, name string, tags []Tag) (string, error) {
+	response := struct {
+		ExperimentID string `json:"experiment_id"`
+	}{}
+	request := map[string]any{"name": name, "tags": tags}
+	if err := c.call(ctx, http.MethodPost, "experiments/create", request, &response); err != nil {
+		return "", err
+	}
+	return response.ExperimentID, nil
+}
+
+// SetExperimentTag sets or replaces a tag of an experiment
+func (c *Client) SetExperimentTag(ctx context.Context, experimentID string, tag Tag) error {
+	request := map[string]any{"experiment_id": experimentID, "key": tag.Key, "value": tag.Value}
+	return c.call(ctx, http.MethodPost, "experiments/set-experiment-tag", request, nil)
+}
+
+// CreateRun starts a run in the experiment and returns its ID
+func (c *Client) CreateRun(ctx context.Context, experimentID string, name string, startTime time.Time, tags []Tag) (string, error) {
+	response := struct {
+		Run struct {
+			Info struct {
+				RunID string `json:"run_id"`
+			} `json:"info"`
+		} `json:"run"`
+	}{}
+	request := map[string]any{
+		"experiment_id": experimentID,
+		"run_name":      name,
+		"start_time":    startTime.UnixMilli(),
+		"tags":          tags,
+	}
+	if err := c.call(ctx, http.MethodPost, "runs/create", request, &response); err != nil {
+		return "", err
+	}
+	return response.Run.Info.RunID, nil
+}
+
+// LogBatch logs the metrics and the params of a run, they are split in as many requests as the
+// MLflow batch limits require
+func (c *Client) LogBatch(ctx context.Context, runID string, metrics []Metric, params []Param) error {
+	for (len(metrics) > 0) || (len(params) > 0) {
+		batchParams := params[:min(len(params), maxBatchParams)]
+		params = params[len(batchParams):]
+		batchMetrics := metrics[:min(len(metrics), maxBatchMetrics-len(batchParams))]
+		metrics = metrics[len(batchMetrics):]
+
+		request := map[string]any{"run_id": runID, "metrics": batchMetrics, "params": batchParams}
+		if err := c.call(ctx, http.MethodPost, "runs/log-batch", request, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// UpdateRun ends a run with one of the RunStatus... statuses
+func (c *Client) UpdateRun(ctx context.Context, runID string, status string, endTime time.Time) error {
+	request := map[string]any{"run_id": runID, "status": status, "end_time": endTime.UnixMilli()}
+	return c.call(ctx, http.MethodPost, "runs/update", request, nil)
+}
+
+// ExperimentURL returns the URL of the experiment in the MLflow UI
+func (c *Client) ExperimentURL(experimentID string) string {
+	return c.trackingURI + "/#/experiments/" + url.PathEscape(experimentID)
+}
+
+// call sends a request to the REST API and decodes the response into the value when it is not nil
+func (c *Client) call(ctx context.Context, method string, endpoint string, request any, value any) error {
+	var body io.Reader
+	if request != nil {
+		data, err := json.Marshal(request)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+	httpRequest, err := http.NewRequestWithContext(ctx, method, c.trackingURI+apiPrefix+endpoint, body)
+	if err != nil {
+		return err
+	}
+	if request != nil {
+		httpRequest.Header.Set("Content-Type", "application/json")
+	}
+	if c.token != "" {
+		httpRequest.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	response, err := c.httpClient.Do(httpRequest)
+	if err != nil {
+		return fmt.Errorf("mlflow request %s failed: %w", endpoint, err)
+	}
+	defer response.Body.Close()
+	data, err := io.ReadAll(response.Body)
+	if err != nil {
+		return err
+	}
+	if (response.StatusCode < 200) || (response.StatusCode >= 300) {
+		apiError := &APIError{StatusCode: response.StatusCode}
+		if json.Unmarshal(data, apiError) != nil {
+			apiError.Message = strings.TrimSpace(string(data))
+		}
+		return apiError
+	}
+	if value == nil {
+		return nil
+	}
+	if err := json.Unmarshal(data, value); err != nil {
+		return fmt.Errorf("invalid mlflow response for %s: %w", endpoint, err)
+	}
+	return nil
+}
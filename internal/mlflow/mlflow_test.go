@@ -0,0 +1,248 @@
+package mlflow
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/config"
+	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/logging"
+	"github.ibm.com/julpayne/eval-hub-backend-svc/pkg/api"
+)
+
+type fakeRun struct {
+	experimentID string
+	name         string
+	tags         []Tag
+	metrics      []Metric
+	params       []Param
+	status       string
+}
+
+// fakeMLflow implements the part of the MLflow tracking REST API used by the client
+type fakeMLflow struct {
+	lock        sync.Mutex
+	experiments map[string]*Experiment
+	runs        map[string]*fakeRun
+	batches     int
+	tokens      []string
+}
+
+func newFakeMLflow(t *testing.T) (*fakeMLflow, *httptest.Server) {
+	fake := &fakeMLflow{experiments: map[string]*Experiment{}, runs: map[string]*fakeRun{}}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+	return fake, server
+}
+
+func (f *fakeMLflow) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.tokens = append(f.tokens, r.Header.Get("Authorization"))
+
+	request := map[string]json.RawMessage{}
+	if r.Method == http.MethodPost {
+		json.NewDecoder(r.Body).Decode(&request)
+	}
+	field := func(name string, value any) { json.Unmarshal(request[name], value) }
+	reply := func(status int, value any) {
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(value)
+	}
+
+	switch strings.TrimPrefix(r.URL.Path, apiPrefix) {
+	case "experiments/get-by-name":
+		experiment, found := f.experiments[r.URL.Query().Get("experiment_name")]
+		if !found {
+			reply(http.StatusNotFound, map[string]string{"error_code": "RESOURCE_DOES_NOT_EXIST", "message": "not found"})
+			return
+		}
+		reply(http.StatusOK, map[string]any{"experiment": experiment})
+	case "experiments/create":
+		experiment := &Experiment{ExperimentID: fmt.Sprint(len(f.experiments) + 1)}
+		field("name", &experiment.Name)
+		field("tags", &experiment.Tags)
+		f.experiments[experiment.Name] = experiment
+		reply(http.StatusOK, map[string]string{"experiment_id": experiment.ExperimentID})
+	case "experiments/set-experiment-tag":
+		var id string
+		var tag Tag
+		field("experiment_id", &id)
+		field("key", &tag.Key)
+		field("value", &tag.Value)
+		for _, experiment := range f.experiments {
+			if experiment.ExperimentID == id {
+				experiment.Tags = append(experiment.Tags, tag)
+			}
+		}
+		reply(http.StatusOK, map[string]any{})
+	case "runs/create":
+		run := &fakeRun{}
+		field("experiment_id", &run.experimentID)
+		field("run_name", &run.name)
+		field("tags", &run.tags)
+		id := fmt.Sprintf("run-%d", len(f.runs)+1)
+		f.runs[id] = run
+		reply(http.StatusOK, map[string]any{"run": map[string]any{"info": map[string]string{"run_id": id}}})
+	case "runs/log-batch":
+		var id string
+		var metrics []Metric
+		var params []Param
+		field("run_id", &id)
+		field("metrics", &metrics)
+		field("params", &params)
+		f.runs[id].metrics = append(f.runs[id].metrics, metrics...)
+		f.runs[id].params = append(f.runs[id].params, params...)
+		f.batches++
+		reply(http.StatusOK, map[string]any{})
+	case "runs/update":
+		var id string
+		field("run_id", &id)
+		field("status", &f.runs[id].status)
+		reply(http.StatusOK, map[string]any{})
+	default:
+		reply(http.StatusNotFound, map[string]string{"error_code": "ENDPOINT_NOT_FOUND", "message": r.URL.Path})
+	}
+}
+
+func newTestTracker(t *testing.T, trackingURI string) *Tracker {
+	t.Helper()
+	logger, err := logging.NewLogger()
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	tracker, err := NewTracker(logger, &config.MLflowConfig{TrackingURI: trackingURI, Token: "secret-token"})
+	if err != nil {
+		t.Fatalf("NewTracker() returned error: %v", err)
+	}
+	return tracker.(*Tracker)
+}
+
+func newJob(experiment string, tags map[string]string) *api.EvaluationJobResource {
+	limit := 5
+	return &api.EvaluationJobResource{
+		Resource: api.Resource{ID: "job-1"},
+		EvaluationJobConfig: api.EvaluationJobConfig{
+			Model: api.ModelRef{URL: "http://model", Name: "granite"},
+			Benchmarks: []api.BenchmarkConfig{{
+				Ref:        api.Ref{ID: "mmlu"},
+				Limit:      &limit,
+				Parameters: map[string]any{"num_fewshot": 5, "subset": "stem"},
+			}},
+			Experiment: api.ExperimentConfig{Name: experiment, Tags: tags},
+		},
+	}
+}
+
+func TestStartExperiment(t *testing.T) {
+	fake, server := newFakeMLflow(t)
+	tracker := newTestTracker(t, server.URL+"/")
+
+	url, err := tracker.StartExperiment(t.Context(), newJob("granite-eval", map[string]string{"team": "ai"}))
+	if err != nil {
+		t.Fatalf("StartExperiment() returned error: %v", err)
+	}
+	if url != server.URL+"/#/experiments/1" {
+		t.Errorf("Expected the experiment URL, got %q", url)
+	}
+
+	// a second job reuses the experiment and adds its tags
+	url, err = tracker.StartExperiment(t.Context(), newJob("granite-eval", map[string]string{"team": "ai", "stage": "nightly"}))
+	if (err != nil) || (url != server.URL+"/#/experiments/1") {
+		t.Errorf("Expected the experiment to be reused, got %q and error %v", url, err)
+	}
+	experiment := fake.experiments["granite-eval"]
+	if (len(fake.experiments) != 1) || (len(experiment.Tags) != 2) {
+		t.Errorf("Expected one experiment with 2 tags, got %+v", experiment)
+	}
+	if fake.tokens[0] != "Bearer secret-token" {
+		t.Errorf("Expected the bearer token, got %q", fake.tokens[0])
+	}
+
+	// the experiment is cached with its tags, a job with tags that are already applied makes no request
+	requests := len(fake.tokens)
+	if _, err := tracker.StartExperiment(t.Context(), newJob("granite-eval", map[string]string{"stage": "nightly"})); (err != nil) || (len(fake.tokens) != requests) {
+		t.Errorf("Expected the cached experiment to be reused, got %d requests and error %v", len(fake.tokens)-requests, err)
+	}
+	if _, err := tracker.StartExperiment(t.Context(), newJob("granite-eval", map[string]string{"stage": "weekly"})); (err != nil) || (len(fake.tokens) != requests+2) {
+		t.Errorf("Expected the changed tag to be looked up and set, got %d requests and error %v", len(fake.tokens)-requests, err)
+	}
+
+	if url, err := tracker.StartExperiment(t.Context(), newJob("", nil)); (err != nil) || (url != "") {
+		t.Errorf("Expected jobs without an experiment not to be tracked, got %q and error %v", url, err)
+	}
+}
+
+func TestLogBenchmarkResult(t *testing.T) {
+	fake, server := newFakeMLflow(t)
+	tracker := newTestTracker(t, server.URL)
+	job := newJob("granite-eval", nil)
+	completed := time.Now().UTC()
+
+	err := tracker.LogBenchmarkResult(t.Context(), job, &api.EvaluationJobBenchmarkResult{
+		ID:          "mmlu",
+		State:       api.StateCompleted,
+		CompletedAt: &completed,
+		Metrics:     map[string]any{"accuracy": 0.71, "stem": map[string]any{"accuracy": 0.6}, "passed": true, "notes": "n/a"},
+	})
+	if err != nil {
+		t.Fatalf("LogBenchmarkResult() returned error: %v", err)
+	}
+
+	run := fake.runs["run-1"]
+	if (run == nil) || (run.experimentID != "1") || (run.name != "mmlu") || (run.status != RunStatusFinished) {
+		t.Fatalf("Expected a finished run for the benchmark, got %+v", run)
+	}
+	expectedMetrics := []Metric{
+		{Key: "accuracy", Value: 0.71, Timestamp: completed.UnixMilli()},
+		{Key: "passed", Value: 1, Timestamp: completed.UnixMilli()},
+		{Key: "stem.accuracy", Value: 0.6, Timestamp: completed.UnixMilli()},
+	}
+	if fmt.Sprint(run.metrics) != fmt.Sprint(expectedMetrics) {
+		t.Errorf("Expected the metrics %v, got %v", expectedMetrics, run.metrics)
+	}
+	expectedParams := []Param{{Key: "limit", Value: "5"}, {Key: "num_fewshot", Value: "5"}, {Key: "subset", Value: "stem"}}
+	if fmt.Sprint(run.params) != fmt.Sprint(expectedParams) {
+		t.Errorf("Expected the params %v, got %v", expectedParams, run.params)
+	}
+	if !strings.Contains(fmt.Sprint(run.tags), TagJobID+" job-1") {
+		t.Errorf("Expected the run to be tagged with the job, got %v", run.tags)
+	}
+}
+
+func TestLogBatch(t *testing.T) {
+	fake, server := newFakeMLflow(t)
+	client, _ := NewClient(&config.MLflowConfig{TrackingURI: server.URL})
+	fake.runs["run-1"] = &fakeRun{}
+
+	metrics := make([]Metric, 1500)
+	params := make([]Param, 150)
+	if err := client.LogBatch(t.Context(), "run-1", metrics, params); err != nil {
+		t.Fatalf("LogBatch() returned error: %v", err)
+	}
+	if (fake.batches != 2) || (len(fake.runs["run-1"].metrics) != 1500) || (len(fake.runs["run-1"].params) != 150) {
+		t.Errorf("Expected everything to be logged in 2 batches, got %d batches", fake.batches)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	_, server := newFakeMLflow(t)
+	client, _ := NewClient(&config.MLflowConfig{TrackingURI: server.URL})
+
+	err := client.call(t.Context(), http.MethodGet, "unknown", nil, nil)
+	if !hasErrorCode(err, "ENDPOINT_NOT_FOUND") {
+		t.Errorf("Expected the MLflow error code, got %v", err)
+	}
+	if _, err := NewClient(&config.MLflowConfig{TrackingURI: "mlflow:5000"}); err == nil {
+		t.Error("Expected an error for a relative tracking URI")
+	}
+	logger, _ := logging.NewLogger()
+	if tracker, err := NewTracker(logger, &config.MLflowConfig{}); (tracker != nil) || (err != nil) {
+		t.Errorf("Expected no tracker without a tracking URI, got %v and error %v", tracker, err)
+	}
+}
@@ -0,0 +1,241 @@
+package mlflow
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/abstractions"
+	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/aggregation"
+	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/config"
+	"github.ibm.com/julpayne/eval-hub-backend-svc/pkg/api"
+)
+
+// Tags set on every run so that the runs can be traced back to the evaluation jobs
+const (
+	TagJobID       = "eval_hub.job_id"
+	TagBenchmarkID = "eval_hub.benchmark_id"
+	TagModelName   = "eval_hub.model_name"
+	TagModelURL    = "eval_hub.model_url"
+)
+
+// maxParamValueLength is the longest parameter value accepted by MLflow
+const maxParamValueLength = 6000
+
+// Tracker is the MLflow implementation of abstractions.ExperimentTracker
+type Tracker struct {
+	logger *slog.Logger
+	client *Client
+
+	lock sync.Mutex
+	// experiments caches the experiments by name with the tags that were applied to them
+	experiments map[string]*cachedExperiment
+}
+
+// cachedExperiment is an experiment whose tags are known to be set
+type cachedExperiment struct {
+	id   string
+	tags map[string]string
+}
+
+// hasTags reports whether all the tags are already applied with the same values
+func (e *cachedExperiment) hasTags(tags map[string]string) bool {
+	for key, value := range tags {
+		if applied, found := e.tags[key]; !found || (applied != value) {
+			return false
+		}
+	}
+	return true
+}
+
+var _ abstractions.ExperimentTracker = (*Tracker)(nil)
+
+// NewTracker creates a tracker for the configured MLflow server, it returns nil when no tracking
+// URI is configured
+func NewTracker(logger *slog.Logger, mlflowConfig *config.MLflowConfig) (abstractions.ExperimentTracker, error) {
+	if (mlflowConfig == nil) || (mlflowConfig.TrackingURI == "") {
+		logger.Warn("No MLflow tracking URI is configured, the experiments are not tracked")
+		return nil, nil
+	}
+	client, err := NewClient(mlflowConfig)
+	if err != nil {
+		return nil, err
+	}
+	return &Tracker{logger: logger, client: client, experiments: make(map[string]*cachedExperiment)}, nil
+}
+
+// StartExperiment creates the experiment with its tags or reuses the experiment with the same name,
+// in which case the tags of the job are added to it. Jobs without an experiment name are not
+// tracked and have no URL.
+func (t *Tracker) StartExperiment(ctx context.Context, evaluation *api.EvaluationJobResource) (string, error) {
+	if evaluation.Experiment.Name == "" {
+		return "", nil
+	}
+	experimentID, err := t.experimentID(ctx, evaluation.Experiment)
+	if err != nil {
+		return "", err
+	}
+	return t.client.ExperimentURL(experimentID), nil
+}
+
+// LogBenchmarkResult creates a run named after the benchmark, logs the numeric metrics of the
+// result (nested metrics are flattened with dots) and the benchmark parameters, and ends the run
+// with the state of the result
+func (t *Tracker) LogBenchmarkResult(ctx context.Context, evaluation *api.EvaluationJobResource, result *api.EvaluationJobBenchmarkResult) error {
+	if evaluation.Experiment.Name == "" {
+		return nil
+	}
+	experimentID, err := t.experimentID(ctx, evaluation.Experiment)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	startTime, endTime := now, now
+	if result.StartedAt != nil {
+		startTime = *result.StartedAt
+	}
+	if result.CompletedAt != nil {
+		endTime = *result.CompletedAt
+	}
+	tags := []Tag{
+		{Key: TagJobID, Value: evaluation.ID},
+		{Key: TagBenchmarkID, Value: result.ID},
+		{Key: TagModelName, Value: evaluation.Model.Name},
+		{Key: TagModelURL, Value: evaluation.Model.URL},
+	}
+	runID, err := t.client.CreateRun(ctx, experimentID, result.ID, startTime, tags)
+	if err != nil {
+		return err
+	}
+
+	metrics := toMetrics(result.Metrics, endTime)
+	params := []Param{}
+	for _, benchmark := range evaluation.Benchmarks {
+		if benchmark.ID == result.ID {
+			params = toParams(benchmark)
+			break
+		}
+	}
+	if err := t.client.LogBatch(ctx, runID, metrics, params); err != nil {
+		return err
+	}
+	if err := t.client.UpdateRun(ctx, runID, runStatus(result.State), endTime); err != nil {
+		return err
+	}
+	t.logger.Info("Logged the benchmark result to MLflow", "evaluation_id", evaluation.ID, "benchmark_id", result.ID, "run_id", runID, "metrics", len(metrics))
+	return nil
+}
+
+// experimentID returns the ID of the experiment, creating it when it does not exist. The experiment
+// is only looked up again when the job has tags that were not applied to it yet.
+func (t *Tracker) experimentID(ctx context.Context, experiment api.ExperimentConfig) (string, error) {
+	t.lock.Lock()
+	cached, found := t.experiments[experiment.Name]
+	t.lock.Unlock()
+	if found && cached.hasTags(experiment.Tags) {
+		return cached.id, nil
+	}
+
+	existing, err := t.client.GetExperimentByName(ctx, experiment.Name)
+	if err != nil {
+		return "", err
+	}
+	var experimentID string
+	if existing == nil {
+		experimentID, err = t.client.CreateExperiment(ctx, experiment.Name, toTags(experiment.Tags))
+		if hasErrorCode(err, "RESOURCE_ALREADY_EXISTS") {
+			// created concurrently, reuse it
+			if existing, err = t.client.GetExperimentByName(ctx, experiment.Name); (err == nil) && (existing == nil) {
+				err = fmt.Errorf("the MLflow experiment %s can not be found after its creation", experiment.Name)
+			}
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+	applied := map[string]string{}
+	if existing != nil {
+		experimentID = existing.ExperimentID
+		for _, tag := range existing.Tags {
+			applied[tag.Key] = tag.Value
+		}
+		for _, tag := range toTags(experiment.Tags) {
+			if slices.Contains(existing.Tags, tag) {
+				continue
+			}
+			if err := t.client.SetExperimentTag(ctx, experimentID, tag); err != nil {
+				return "", err
+			}
+			applied[tag.Key] = tag.Value
+		}
+	} else {
+		maps.Copy(applied, experiment.Tags)
+	}
+
+	t.lock.Lock()
+	t.experiments[experiment.Name] = &cachedExperiment{id: experimentID, tags: applied}
+	t.lock.Unlock()
+	return experimentID, nil
+}
+
+func toTags(values map[string]string) []Tag {
+	tags := make([]Tag, 0, len(values))
+	for key, value := range values {
+		tags = append(tags, Tag{Key: key, Value: value})
+	}
+	slices.SortFunc(tags, func(a, b Tag) int { return strings.Compare(a.Key, b.Key) })
+	return tags
+}
+
+// toMetrics converts the numeric metrics, booleans are logged as 0 or 1 and the other values are skipped
+func toMetrics(values map[string]any, timestamp time.Time) []Metric {
+	metrics := []Metric{}
+	for key, value := range aggregation.FlattenMetrics(values) {
+		metrics = append(metrics, Metric{Key: key, Value: value, Timestamp: timestamp.UnixMilli()})
+	}
+	slices.SortFunc(metrics, func(a, b Metric) int { return strings.Compare(a.Key, b.Key) })
+	return metrics
+}
+
+// toParams converts the benchmark configuration, values that are not strings are logged as JSON
+func toParams(benchmark api.BenchmarkConfig) []Param {
+	params := []Param{}
+	if benchmark.Limit != nil {
+		params = append(params, Param{Key: "limit", Value: strconv.Itoa(*benchmark.Limit)})
+	}
+	for key, value := range benchmark.Parameters {
+		text, ok := value.(string)
+		if !ok {
+			data, err := json.Marshal(value)
+			if err != nil {
+				continue
+			}
+			text = string(data)
+		}
+		if len(text) > maxParamValueLength {
+			text = text[:maxParamValueLength]
+		}
+		params = append(params, Param{Key: key, Value: text})
+	}
+	slices.SortFunc(params, func(a, b Param) int { return strings.Compare(a.Key, b.Key) })
+	return params
+}
+
+func runStatus(state api.State) string {
+	switch state {
+	case api.StateCompleted:
+		return RunStatusFinished
+	case api.StateCancelled:
+		return RunStatusKilled
+	default:
+		return RunStatusFailed
+	}
+}
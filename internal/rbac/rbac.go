@@ -0,0 +1,217 @@
+// Package rbac authorizes the requests by the roles of their principal, the roles are mapped from
+// the claims of the principal and every route declares the permission each of its methods requires.
+package rbac
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/apierrors"
+	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/auth"
+	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/config"
+)
+
+// Role is a set of permissions granted to a principal
+type Role string
+
+const (
+	// RoleViewer reads the evaluation jobs, the collections and the registry
+	RoleViewer Role = "viewer"
+	// RoleSubmitter also submits evaluation jobs and cancels its own jobs
+	RoleSubmitter Role = "submitter"
+	// RoleAdmin is allowed everything
+	RoleAdmin Role = "admin"
+	// RoleRunner reports the benchmark results, it is the role of the runner token
+	RoleRunner Role = "runner"
+)
+
+// Roles lists the supported roles
+var Roles = []Role{RoleViewer, RoleSubmitter, RoleAdmin, RoleRunner}
+
+// Permission is an operation a route requires
+type Permission string
+
+const (
+	PermissionRead   Permission = "read"
+	PermissionSubmit Permission = "submit"
+	// PermissionCancel cancels the jobs submitted by the principal
+	PermissionCancel Permission = "cancel"
+	// PermissionCancelAny cancels the jobs of any principal
+	PermissionCancelAny         Permission = "cancel_any"
+	PermissionManageCollections Permission = "manage_collections"
+	PermissionReportResults     Permission = "report_results"
+	// PermissionListAllTenants lists the evaluation jobs of every tenant with ?all_tenants=true
+	PermissionListAllTenants Permission = "list_all_tenants"
+)
+
+var rolePermissions = map[Role][]Permission{
+	RoleViewer:    {PermissionRead},
+	RoleSubmitter: {PermissionRead, PermissionSubmit, PermissionCancel},
+	RoleAdmin:     {PermissionRead, PermissionSubmit, PermissionCancel, PermissionCancelAny, PermissionManageCollections, PermissionReportResults, PermissionListAllTenants},
+	RoleRunner:    {PermissionRead, PermissionReportResults},
+}
+
+// DefaultRolesClaim holds the roles when no claim is configured
+const DefaultRolesClaim = "roles"
+
+// Permissions declares the permission each method of a route requires, the methods that are not
+// listed are left to the route
+type Permissions map[string]Permission
+
+// Authorization holds the roles of the principal of a request. A nil authorization allows
+// everything, the requests are not authorized when the authentication is not enabled or the route
+// is exempt from it.
+type Authorization struct {
+	Roles []Role
+}
+
+// Allows reports whether one of the roles grants the permission
+func (a *Authorization) Allows(permission Permission) bool {
+	if a == nil {
+		return true
+	}
+	for _, role := range a.Roles {
+		if slices.Contains(rolePermissions[role], permission) {
+			return true
+		}
+	}
+	return false
+}
+
+type contextKey struct{}
+
+// Authorizer maps the claims of the principals to their roles
+type Authorizer struct {
+	claim       string
+	mappings    map[string][]Role
+	defaultRole Role
+}
+
+// NewAuthorizer creates the authorizer of the configuration, it returns nil when the authentication
+// is not enabled
+func NewAuthorizer(authConfig *config.AuthConfig) (*Authorizer, error) {
+	if (authConfig == nil) || !authConfig.Enabled {
+		return nil, nil
+	}
+	a := &Authorizer{claim: DefaultRolesClaim, mappings: map[string][]Role{}}
+	rolesConfig := authConfig.Roles
+	if rolesConfig == nil {
+		return a, nil
+	}
+	if rolesConfig.Claim != "" {
+		a.claim = rolesConfig.Claim
+	}
+	for name, values := range rolesConfig.Mappings {
+		role := Role(name)
+		if !slices.Contains(Roles, role) {
+			return nil, fmt.Errorf("unknown role %q in the role mappings, expected one of %v", name, Roles)
+		}
+		for _, value := range values {
+			a.mappings[value] = append(a.mappings[value], role)
+		}
+	}
+	if rolesConfig.Default != "" {
+		a.defaultRole = Role(rolesConfig.Default)
+		if !slices.Contains(Roles, a.defaultRole) {
+			return nil, fmt.Errorf("unknown default role %q, expected one of %v", rolesConfig.Default, Roles)
+		}
+	}
+	return a, nil
+}
+
+// Roles returns the roles of the principal, the runner token is granted the runner role and the
+// other principals the roles named by their claim or mapped from its values
+func (a *Authorizer) Roles(principal *auth.Principal) []Role {
+	roles := []Role{}
+	add := func(role Role) {
+		if !slices.Contains(roles, role) {
+			roles = append(roles, role)
+		}
+	}
+	if principal.Method == auth.MethodRunnerToken {
+		add(RoleRunner)
+	}
+	for _, value := range claimValues(principal.Claims, a.claim) {
+		if slices.Contains(Roles, Role(value)) {
+			add(Role(value))
+		}
+		for _, role := range a.mappings[value] {
+			add(role)
+		}
+	}
+	if (len(roles) == 0) && (a.defaultRole != "") {
+		add(a.defaultRole)
+	}
+	return roles
+}
+
+// Middleware stores the authorization of the authenticated requests in their context, a nil
+// authorizer lets every request through unauthorized
+func (a *Authorizer) Middleware(next http.Handler) http.Handler {
+	if a == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		principal := auth.FromContext(r.Context())
+		if principal == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		authorization := &Authorization{Roles: a.Roles(principal)}
+		next.ServeHTTP(w, r.WithContext(WithAuthorization(r.Context(), authorization)))
+	})
+}
+
+// Require rejects the requests whose method requires a permission that their roles do not grant
+// with 403
+func Require(permissions Permissions, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		permission, found := permissions[r.Method]
+		if found && !FromContext(r.Context()).Allows(permission) {
+			apierrors.Write(w, r, apierrors.Forbidden(fmt.Sprintf("the %s permission is required", permission)))
+			return
+		}
+		next(w, r)
+	}
+}
+
+// WithAuthorization returns a copy of the context holding the authorization
+func WithAuthorization(ctx context.Context, authorization *Authorization) context.Context {
+	return context.WithValue(ctx, contextKey{}, authorization)
+}
+
+// FromContext returns the authorization stored in the context, nil when the request is not authorized
+func FromContext(ctx context.Context) *Authorization {
+	authorization, _ := ctx.Value(contextKey{}).(*Authorization)
+	return authorization
+}
+
+// claimValues returns the string or the strings of the claim at the dotted path
+func claimValues(claims map[string]any, path string) []string {
+	var value any = claims
+	for _, name := range strings.Split(path, ".") {
+		object, ok := value.(map[string]any)
+		if !ok {
+			return nil
+		}
+		value = object[name]
+	}
+	switch value := value.(type) {
+	case string:
+		return []string{value}
+	case []any:
+		values := []string{}
+		for _, item := range value {
+			if item, ok := item.(string); ok {
+				values = append(values, item)
+			}
+		}
+		return values
+	case []string:
+		return value
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+package rbac
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/auth"
+	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/config"
+)
+
+func TestNewAuthorizer(t *testing.T) {
+	if a, err := NewAuthorizer(&config.AuthConfig{}); (a != nil) || (err != nil) {
+		t.Errorf("Expected no authorizer when the authentication is not enabled, got %v, %v", a, err)
+	}
+	if _, err := NewAuthorizer(&config.AuthConfig{Enabled: true, Roles: &config.RolesConfig{Mappings: map[string][]string{"owner": {"eval-owners"}}}}); err == nil {
+		t.Error("Expected an error for an unknown role in the mappings")
+	}
+	if _, err := NewAuthorizer(&config.AuthConfig{Enabled: true, Roles: &config.RolesConfig{Default: "guest"}}); err == nil {
+		t.Error("Expected an error for an unknown default role")
+	}
+	if a, err := NewAuthorizer(&config.AuthConfig{Enabled: true}); (a == nil) || (err != nil) || (a.claim != DefaultRolesClaim) {
+		t.Errorf("Expected an authorizer reading the %s claim, got %+v, %v", DefaultRolesClaim, a, err)
+	}
+}
+
+func TestRoles(t *testing.T) {
+	a, err := NewAuthorizer(&config.AuthConfig{Enabled: true, Roles: &config.RolesConfig{
+		Claim:    "realm_access.roles",
+		Mappings: map[string][]string{"admin": {"eval-admins"}, "submitter": {"eval-users", "eval-admins"}},
+		Default:  "viewer",
+	}})
+	if err != nil {
+		t.Fatalf("NewAuthorizer() returned error: %v", err)
+	}
+	realm := func(roles ...any) map[string]any {
+		return map[string]any{"realm_access": map[string]any{"roles": roles}}
+	}
+
+	testCases := []struct {
+		name      string
+		principal *auth.Principal
+		roles     []Role
+	}{
+		{"runner token", &auth.Principal{Subject: auth.RunnerSubject, Method: auth.MethodRunnerToken}, []Role{RoleRunner}},
+		{"role names", &auth.Principal{Method: auth.MethodJWT, Claims: realm("submitter", "unknown")}, []Role{RoleSubmitter}},
+		{"mapped values", &auth.Principal{Method: auth.MethodJWT, Claims: realm("eval-admins")}, []Role{RoleAdmin, RoleSubmitter}},
+		{"single value", &auth.Principal{Method: auth.MethodAPIKey, Claims: map[string]any{"realm_access": map[string]any{"roles": "eval-users"}}}, []Role{RoleSubmitter}},
+		{"default role", &auth.Principal{Method: auth.MethodJWT, Claims: realm("unknown")}, []Role{RoleViewer}},
+		{"no claims", &auth.Principal{Method: auth.MethodAPIKey}, []Role{RoleViewer}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			roles := a.Roles(tc.principal)
+			slices.Sort(roles)
+			slices.Sort(tc.roles)
+			if !slices.Equal(roles, tc.roles) {
+				t.Errorf("Expected the roles %v, got %v", tc.roles, roles)
+			}
+		})
+	}
+}
+
+func TestAllows(t *testing.T) {
+	var anonymous *Authorization
+	if !anonymous.Allows(PermissionCancelAny) {
+		t.Error("Expected a nil authorization to allow everything")
+	}
+	submitter := &Authorization{Roles: []Role{RoleSubmitter}}
+	if !submitter.Allows(PermissionCancel) || submitter.Allows(PermissionCancelAny) || submitter.Allows(PermissionReportResults) || submitter.Allows(PermissionListAllTenants) {
+		t.Errorf("Unexpected permissions for the submitter role")
+	}
+	if (&Authorization{}).Allows(PermissionRead) {
+		t.Error("Expected no permission without a role")
+	}
+}
+
+func TestRequire(t *testing.T) {
+	a, _ := NewAuthorizer(&config.AuthConfig{Enabled: true})
+	handler := a.Middleware(Require(Permissions{http.MethodPost: PermissionSubmit}, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	testCases := []struct {
+		name      string
+		method    string
+		principal *auth.Principal
+		status    int
+	}{
+		{"permission granted", http.MethodPost, &auth.Principal{Claims: map[string]any{"roles": []any{"submitter"}}}, http.StatusOK},
+		{"permission denied", http.MethodPost, &auth.Principal{Claims: map[string]any{"roles": []any{"viewer"}}}, http.StatusForbidden},
+		{"method without a permission", http.MethodGet, &auth.Principal{}, http.StatusOK},
+		{"anonymous request", http.MethodPost, nil, http.StatusOK},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, "/api/v1/evaluations/jobs", nil)
+			if tc.principal != nil {
+				req = req.WithContext(auth.WithPrincipal(req.Context(), tc.principal))
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+			if w.Code != tc.status {
+				t.Errorf("Expected status %d, got %d: %s", tc.status, w.Code, w.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,397 @@
+// Package registry holds the evaluation providers and their benchmarks, loaded from a directory of
+// YAML files. Every file can define providers, benchmarks or both:
+//
+//	providers:
+//	  - id: lm_evaluation_harness
+//	    label: LM Evaluation Harness
+//	benchmarks:
+//	  - id: mmlu
+//	    provider_id: lm_evaluation_harness
+//	    label: MMLU
+//	    description: Massive Multitask Language Understanding
+//	    category: knowledge
+//	    tags: [reasoning, multiple-choice]
+//
+// The benchmark IDs are unique across the providers because the evaluation jobs refer to the
+// benchmarks by ID only.
+package registry
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"sigs.k8s.io/yaml"
+
+	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/config"
+	"github.ibm.com/julpayne/eval-hub-backend-svc/pkg/api"
+)
+
+// reloadDelay groups the file events of a single change (i.e. an editor save or a ConfigMap update)
+const reloadDelay = 200 * time.Millisecond
+
+// definitions is the content of a registry file
+type definitions struct {
+	Providers  []providerDefinition  `json:"providers"`
+	Benchmarks []benchmarkDefinition `json:"benchmarks"`
+}
+
+type providerDefinition struct {
+	ID    string `json:"id"`
+	Label string `json:"label"`
+}
+
+type benchmarkDefinition struct {
+	ID          string   `json:"id"`
+	ProviderID  string   `json:"provider_id"`
+	Label       string   `json:"label"`
+	Description string   `json:"description"`
+	Category    string   `json:"category"`
+	Tags        []string `json:"tags"`
+}
+
+// catalog is an immutable snapshot of the registry
+type catalog struct {
+	providers  []api.ProviderResource
+	benchmarks []api.BenchmarkResource
+	// indexes by ID into the slices
+	providerIndex  map[string]int
+	benchmarkIndex map[string]int
+}
+
+// Registry serves the providers and the benchmarks, it is safe for concurrent use
+type Registry struct {
+	logger *slog.Logger
+	dir    string
+
+	lock    sync.RWMutex
+	catalog *catalog
+
+	watcher *fsnotify.Watcher
+	done    chan struct{}
+}
+
+// NewRegistry loads the registry from the configured directory, invalid files are an error. When
+// watching is enabled the registry is reloaded when the files change and an invalid change keeps
+// the previous content.
+func NewRegistry(logger *slog.Logger, registryConfig *config.RegistryConfig) (*Registry, error) {
+	registry := &Registry{logger: logger, catalog: emptyCatalog()}
+	if (registryConfig == nil) || (registryConfig.Dir == "") {
+		logger.Warn("No registry directory is configured, there are no providers and benchmarks")
+		return registry, nil
+	}
+
+	registry.dir = registryConfig.Dir
+	if err := registry.Reload(); err != nil {
+		return nil, err
+	}
+	if registryConfig.Watch {
+		if err := registry.watch(); err != nil {
+			return nil, err
+		}
+	}
+	return registry, nil
+}
+
+// Providers returns the providers ordered by ID
+func (r *Registry) Providers() []api.ProviderResource {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	return slices.Clone(r.catalog.providers)
+}
+
+// Provider returns the provider with the ID
+func (r *Registry) Provider(id string) (*api.ProviderResource, bool) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	index, found := r.catalog.providerIndex[id]
+	if !found {
+		return nil, false
+	}
+	provider := r.catalog.providers[index]
+	return &provider, true
+}
+
+// Benchmarks returns the benchmarks ordered by provider and ID
+func (r *Registry) Benchmarks() []api.BenchmarkResource {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	return slices.Clone(r.catalog.benchmarks)
+}
+
+// Benchmark returns the benchmark with the ID
+func (r *Registry) Benchmark(id string) (*api.BenchmarkResource, bool) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	index, found := r.catalog.benchmarkIndex[id]
+	if !found {
+		return nil, false
+	}
+	benchmark := r.catalog.benchmarks[index]
+	return &benchmark, true
+}
+
+// Reload loads the files of the directory again, the registry is unchanged when they are invalid
+func (r *Registry) Reload() error {
+	if r.dir == "" {
+		return nil
+	}
+	loaded, err := load(r.dir)
+	if err != nil {
+		return err
+	}
+
+	r.lock.Lock()
+	r.catalog = loaded
+	r.lock.Unlock()
+	r.logger.Info("Loaded the registry", "dir", r.dir, "providers", len(loaded.providers), "benchmarks", len(loaded.benchmarks))
+	return nil
+}
+
+// Close stops watching the directory
+func (r *Registry) Close() error {
+	if r.watcher == nil {
+		return nil
+	}
+	err := r.watcher.Close()
+	<-r.done
+	return err
+}
+
+// watch reloads the registry after the file events of the directory have settled
+func (r *Registry) watch() error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to watch the registry directory: %w", err)
+	}
+	if err := watcher.Add(r.dir); err != nil {
+		watcher.Close()
+		return fmt.Errorf("failed to watch the registry directory %s: %w", r.dir, err)
+	}
+	r.watcher = watcher
+	r.done = make(chan struct{})
+
+	go func() {
+		defer close(r.done)
+		var timer *time.Timer
+		defer func() {
+			if timer != nil {
+				timer.Stop()
+			}
+		}()
+		for {
+			select {
+			case _, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if timer == nil {
+					timer = time.AfterFunc(reloadDelay, func() {
+						if err := r.Reload(); err != nil {
+							r.logger.Error("Failed to reload the registry, keeping the previous content", "dir", r.dir, "error", err.Error())
+						}
+					})
+				} else {
+					timer.Reset(reloadDelay)
+				}
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				r.logger.Error("Failed to watch the registry directory", "dir", r.dir, "error", err.Error())
+			}
+		}
+	}()
+	return nil
+}
+
+// load reads the YAML files at the top of the directory, hidden entries are skipped so that the
+// ..data links of a mounted ConfigMap are not loaded twice
+func load(dir string) (*catalog, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the registry directory: %w", err)
+	}
+
+	loaded := emptyCatalog()
+	providerFiles := map[string]string{}
+	benchmarkFiles := map[string]string{}
+	type pendingBenchmark struct {
+		file       string
+		definition benchmarkDefinition
+		loadedAt   time.Time
+	}
+	benchmarks := []pendingBenchmark{}
+	errs := []error{}
+
+	for _, entry := range entries {
+		name := entry.Name()
+		extension := filepath.Ext(name)
+		if strings.HasPrefix(name, ".") || ((extension != ".yaml") && (extension != ".yml")) {
+			continue
+		}
+		path := filepath.Join(dir, name)
+		info, err := os.Stat(path)
+		if (err != nil) || info.IsDir() {
+			continue
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+			continue
+		}
+		file := definitions{}
+		if err := yaml.UnmarshalStrict(data, &file); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+			continue
+		}
+
+		modified := info.ModTime().UTC()
+		for i, provider := range file.Providers {
+			switch {
+			case provider.ID == "":
+				errs = append(errs, fmt.Errorf("%s: providers[%d].id is required", name, i))
+			case providerFiles[provider.ID] != "":
+				errs = append(errs, fmt.Errorf("%s: duplicate provider %s, already defined in %s", name, provider.ID, providerFiles[provider.ID]))
+			default:
+				providerFiles[provider.ID] = name
+				loaded.providers = append(loaded.providers, api.ProviderResource{ID: provider.ID, Label: orID(provider.Label, provider.ID)})
+			}
+		}
+		for i, benchmark := range file.Benchmarks {
+			switch {
+			case benchmark.ID == "":
+				errs = append(errs, fmt.Errorf("%s: benchmarks[%d].id is required", name, i))
+			case benchmarkFiles[benchmark.ID] != "":
+				errs = append(errs, fmt.Errorf("%s: duplicate benchmark %s, already defined in %s", name, benchmark.ID, benchmarkFiles[benchmark.ID]))
+			default:
+				benchmarkFiles[benchmark.ID] = name
+				benchmarks = append(benchmarks, pendingBenchmark{file: name, definition: benchmark, loadedAt: modified})
+			}
+		}
+	}
+
+	// the providers can be defined in any file so the references are checked once all are loaded
+	slices.SortFunc(loaded.providers, func(a, b api.ProviderResource) int { return strings.Compare(a.ID, b.ID) })
+	for i, provider := range loaded.providers {
+		loaded.providerIndex[provider.ID] = i
+	}
+	for _, pending := range benchmarks {
+		definition := pending.definition
+		index, found := loaded.providerIndex[definition.ProviderID]
+		if !found {
+			errs = append(errs, fmt.Errorf("%s: benchmark %s refers to the unknown provider %q", pending.file, definition.ID, definition.ProviderID))
+			continue
+		}
+		loaded.benchmarks = append(loaded.benchmarks, api.BenchmarkResource{
+			Resource:    api.Resource{ID: definition.ID, CreatedAt: pending.loadedAt, UpdatedAt: pending.loadedAt},
+			Label:       orID(definition.Label, definition.ID),
+			Description: definition.Description,
+			Category:    definition.Category,
+			ProviderID:  definition.ProviderID,
+			Tags:        definition.Tags,
+		})
+		provider := &loaded.providers[index]
+		provider.SupportedBenchmarks = append(provider.SupportedBenchmarks, api.SupportedBenchmark{ID: definition.ID})
+	}
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("invalid registry in %s: %w", dir, errors.Join(errs...))
+	}
+
+	slices.SortFunc(loaded.benchmarks, func(a, b api.BenchmarkResource) int {
+		if c := strings.Compare(a.ProviderID, b.ProviderID); c != 0 {
+			return c
+		}
+		return strings.Compare(a.ID, b.ID)
+	})
+	for i, benchmark := range loaded.benchmarks {
+		loaded.benchmarkIndex[benchmark.ID] = i
+	}
+	for i := range loaded.providers {
+		slices.SortFunc(loaded.providers[i].SupportedBenchmarks, func(a, b api.SupportedBenchmark) int { return strings.Compare(a.ID, b.ID) })
+	}
+	return loaded, nil
+}
+
+func emptyCatalog() *catalog {
+	return &catalog{
+		providers:      []api.ProviderResource{},
+		benchmarks:     []api.BenchmarkResource{},
+		providerIndex:  map[string]int{},
+		benchmarkIndex: map[string]int{},
+	}
+}
+
+func orID(label string, id string) string {
+	if label == "" {
+		return id
+	}
+	return label
+}
+
+// BenchmarkFilter selects benchmarks, the empty fields match every benchmark. The comparisons
+// ignore the case.
+type BenchmarkFilter struct {
+	ProviderID string
+	Category   string
+	Tags       []string
+	// MatchAllTags requires every tag (AND), otherwise any of the tags is enough (OR)
+	MatchAllTags bool
+	// Search is free text, every word must appear in the label or the description
+	Search string
+}
+
+// FindBenchmarks returns the benchmarks matching the filter ordered by provider and ID
+func (r *Registry) FindBenchmarks(filter BenchmarkFilter) []api.BenchmarkResource {
+	words := strings.Fields(strings.ToLower(filter.Search))
+	matches := []api.BenchmarkResource{}
+	for _, benchmark := range r.Benchmarks() {
+		if (filter.ProviderID != "") && !strings.EqualFold(benchmark.ProviderID, filter.ProviderID) {
+			continue
+		}
+		if (filter.Category != "") && !strings.EqualFold(benchmark.Category, filter.Category) {
+			continue
+		}
+		if !matchTags(benchmark.Tags, filter.Tags, filter.MatchAllTags) {
+			continue
+		}
+		text := strings.ToLower(benchmark.Label + "\n" + benchmark.Description)
+		if !allWords(text, words) {
+			continue
+		}
+		matches = append(matches, benchmark)
+	}
+	return matches
+}
+
+func matchTags(tags []string, wanted []string, matchAll bool) bool {
+	if len(wanted) == 0 {
+		return true
+	}
+	for _, tag := range wanted {
+		found := slices.ContainsFunc(tags, func(candidate string) bool { return strings.EqualFold(candidate, tag) })
+		if found && !matchAll {
+			return true
+		}
+		if !found && matchAll {
+			return false
+		}
+	}
+	return matchAll
+}
+
+func allWords(text string, words []string) bool {
+	for _, word := range words {
+		if !strings.Contains(text, word) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,208 @@
+package registry
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/config"
+	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/logging"
+)
+
+const providers = `
+providers:
+  - id: lm_evaluation_harness
+    label: LM Evaluation Harness
+  - id: ragas
+`
+
+const benchmarks = `
+benchmarks:
+  - id: mmlu
+    provider_id: lm_evaluation_harness
+    label: MMLU
+    category: knowledge
+    tags: [reasoning]
+  - id: arc
+    provider_id: lm_evaluation_harness
+  - id: faithfulness
+    provider_id: ragas
+`
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+}
+
+func newTestRegistry(t *testing.T, dir string, watch bool) (*Registry, error) {
+	t.Helper()
+	logger, err := logging.NewLogger()
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	registry, err := NewRegistry(logger, &config.RegistryConfig{Dir: dir, Watch: watch})
+	if registry != nil {
+		t.Cleanup(func() { registry.Close() })
+	}
+	return registry, err
+}
+
+func TestNewRegistry(t *testing.T) {
+	t.Run("providers and benchmarks are loaded from all the files", func(t *testing.T) {
+		dir := t.TempDir()
+		writeFiles(t, dir, map[string]string{"providers.yaml": providers, "benchmarks.yml": benchmarks, "README.md": "ignored"})
+
+		registry, err := newTestRegistry(t, dir, false)
+		if err != nil {
+			t.Fatalf("NewRegistry() returned error: %v", err)
+		}
+
+		all := registry.Providers()
+		if (len(all) != 2) || (all[0].ID != "lm_evaluation_harness") || (all[1].Label != "ragas") {
+			t.Fatalf("Expected the 2 providers ordered by ID, got %+v", all)
+		}
+		if (len(all[0].SupportedBenchmarks) != 2) || (all[0].SupportedBenchmarks[0].ID != "arc") {
+			t.Errorf("Expected the benchmarks of the provider, got %+v", all[0].SupportedBenchmarks)
+		}
+		if ids := benchmarkIDs(registry); ids != "arc,mmlu,faithfulness" {
+			t.Errorf("Expected the benchmarks ordered by provider and ID, got %s", ids)
+		}
+		benchmark, found := registry.Benchmark("mmlu")
+		if !found || (benchmark.Label != "MMLU") || (benchmark.Category != "knowledge") || (benchmark.CreatedAt.IsZero()) {
+			t.Errorf("Expected the mmlu benchmark, got %+v", benchmark)
+		}
+		if _, found := registry.Provider("unknown"); found {
+			t.Error("Expected an unknown provider not to be found")
+		}
+	})
+
+	t.Run("invalid definitions are rejected", func(t *testing.T) {
+		for name, files := range map[string]map[string]string{
+			"unknown provider":   {"benchmarks.yaml": benchmarks},
+			"duplicate provider": {"a.yaml": providers, "b.yaml": providers},
+			"duplicate benchmark": {"providers.yaml": providers, "a.yaml": benchmarks, "b.yaml": `
+benchmarks:
+  - id: mmlu
+    provider_id: ragas
+`},
+			"missing ID":    {"a.yaml": "providers:\n  - label: nameless\n"},
+			"unknown field": {"a.yaml": "providers:\n  - id: a\n    url: http://provider\n"},
+		} {
+			t.Run(name, func(t *testing.T) {
+				dir := t.TempDir()
+				writeFiles(t, dir, files)
+				if _, err := newTestRegistry(t, dir, false); err == nil {
+					t.Error("Expected an error")
+				}
+			})
+		}
+	})
+
+	t.Run("the registry is empty without a directory", func(t *testing.T) {
+		registry, err := newTestRegistry(t, "", true)
+		if (err != nil) || (len(registry.Providers()) != 0) || (len(registry.Benchmarks()) != 0) {
+			t.Errorf("Expected an empty registry, got error %v", err)
+		}
+	})
+}
+
+func TestWatch(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"providers.yaml": providers, "benchmarks.yaml": benchmarks})
+	registry, err := newTestRegistry(t, dir, true)
+	if err != nil {
+		t.Fatalf("NewRegistry() returned error: %v", err)
+	}
+
+	writeFiles(t, dir, map[string]string{"more.yaml": "benchmarks:\n  - id: hellaswag\n    provider_id: lm_evaluation_harness\n"})
+	waitFor(t, func() bool { _, found := registry.Benchmark("hellaswag"); return found })
+
+	// an invalid change keeps the previous content
+	writeFiles(t, dir, map[string]string{"more.yaml": "benchmarks:\n  - id: hellaswag\n    provider_id: unknown\n"})
+	time.Sleep(3 * reloadDelay)
+	if _, found := registry.Benchmark("hellaswag"); !found {
+		t.Error("Expected the previous content to be kept")
+	}
+
+	os.Remove(filepath.Join(dir, "more.yaml"))
+	waitFor(t, func() bool { _, found := registry.Benchmark("hellaswag"); return !found })
+}
+
+func waitFor(t *testing.T, condition func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if condition() {
+			return
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	t.Fatal("The registry was not reloaded in time")
+}
+
+func benchmarkIDs(registry *Registry) string {
+	ids := []string{}
+	for _, benchmark := range registry.Benchmarks() {
+		ids = append(ids, benchmark.ID)
+	}
+	return strings.Join(ids, ",")
+}
+
+func TestFindBenchmarks(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"registry.yaml": providers + `
+benchmarks:
+  - id: mmlu
+    provider_id: lm_evaluation_harness
+    label: MMLU
+    description: Massive Multitask Language Understanding
+    category: knowledge
+    tags: [reasoning, multiple-choice]
+  - id: arc
+    provider_id: lm_evaluation_harness
+    label: AI2 Reasoning Challenge
+    category: Reasoning
+    tags: [reasoning, science]
+  - id: faithfulness
+    provider_id: ragas
+    description: Factual consistency of the answer
+    category: rag
+    tags: [generation]
+`})
+	registry, err := newTestRegistry(t, dir, false)
+	if err != nil {
+		t.Fatalf("NewRegistry() returned error: %v", err)
+	}
+
+	for _, tc := range []struct {
+		name     string
+		filter   BenchmarkFilter
+		expected string
+	}{
+		{"no filter", BenchmarkFilter{}, "arc,mmlu,faithfulness"},
+		{"provider", BenchmarkFilter{ProviderID: "ragas"}, "faithfulness"},
+		{"category ignores the case", BenchmarkFilter{Category: "reasoning"}, "arc"},
+		{"any tag", BenchmarkFilter{Tags: []string{"science", "generation"}}, "arc,faithfulness"},
+		{"all tags", BenchmarkFilter{Tags: []string{"reasoning", "Multiple-Choice"}, MatchAllTags: true}, "mmlu"},
+		{"all tags without a match", BenchmarkFilter{Tags: []string{"science", "generation"}, MatchAllTags: true}, ""},
+		{"search in the label", BenchmarkFilter{Search: "reasoning challenge"}, "arc"},
+		{"search in the description", BenchmarkFilter{Search: "LANGUAGE"}, "mmlu"},
+		{"combined filters", BenchmarkFilter{ProviderID: "lm_evaluation_harness", Tags: []string{"reasoning"}, Search: "mmlu"}, "mmlu"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ids := []string{}
+			for _, benchmark := range registry.FindBenchmarks(tc.filter) {
+				ids = append(ids, benchmark.ID)
+			}
+			if got := strings.Join(ids, ","); got != tc.expected {
+				t.Errorf("Expected %q, got %q", tc.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,562 @@
+package k8s
+
+import (
+	"bytes"
+	"context"
+	_ "embed"
+	"fmt"
+	"log/slog"
+	"maps"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/abstractions"
+	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/config"
+	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/runtimes/runtime_env"
+	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/state_machine"
+	"github.ibm.com/julpayne/eval-hub-backend-svc/pkg/api"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/clientcmd"
+	"sigs.k8s.io/yaml"
+)
+
+// defaultJobTemplate runs the configured image with the benchmark passed in the environment
+//
+//go:embed default_job.yaml
+var defaultJobTemplate string
+
+// The labels and annotations that tie the Kubernetes resources to the evaluation jobs, the Job
+// labels and annotations are copied to the pod template so that the pods can be tracked too.
+const (
+	labelManagedBy        = "app.kubernetes.io/managed-by"
+	managedByEvalHub      = "eval-hub"
+	labelJobID            = "eval-hub/job-id"
+	annotationBenchmarkID = "eval-hub/benchmark-id"
+	annotationTenant      = "eval-hub/tenant"
+)
+
+const (
+	maxNameLength       = 63
+	defaultNamespace    = "default"
+	namespaceFile       = "/var/run/secrets/kubernetes.io/serviceaccount/namespace"
+	requestTimeout      = 30 * time.Second
+	cacheSyncTimeout    = time.Minute
+	waitingReasonPrefix = "Waiting for the benchmark pod"
+)
+
+var invalidNameCharacters = regexp.MustCompile(`[^a-z0-9-]+`)
+
+// TemplateData is the data available to the Job template
+type TemplateData struct {
+	// Name and Namespace are set on the Job by the runtime, whatever the template says
+	Name       string
+	Namespace  string
+	Image      string
+	JobID      string
+	Model      api.ModelRef
+	Benchmark  api.BenchmarkConfig
+	Experiment api.ExperimentConfig
+	// Env holds the environment variables that are also added to every container
+	Env map[string]string
+}
+
+// Runtime runs every benchmark of an evaluation job as a Kubernetes Job. The Jobs and their pods
+// are watched with informers and their states are written to the storage passed with the job.
+type Runtime struct {
+	logger *slog.Logger
+	client kubernetes.Interface
+	// storage is used to restore the tracking of the Jobs that were created before a restart
+	storage   abstractions.Storage
+	namespace string
+	image     string
+	template  *template.Template
+	stop      chan struct{}
+	stopOnce  sync.Once
+	lock      sync.Mutex
+	// runs holds the evaluation jobs that have benchmarks that have not finished, by job ID
+	runs map[string]*jobRun
+}
+
+// jobRun tracks the benchmarks of an evaluation job
+type jobRun struct {
+	evaluation api.EvaluationJobResource
+	storage    abstractions.Storage
+	benchmarks map[string]*api.BenchmarkStatus
+}
+
+// NewRuntime creates a Kubernetes runtime from the kubeconfig, or the in-cluster configuration. The
+// evaluation jobs of the storage that still have Kubernetes Jobs are tracked again.
+func NewRuntime(logger *slog.Logger, runtimeConfig *config.KubernetesRuntimeConfig, storage abstractions.Storage) (*Runtime, error) {
+	if runtimeConfig == nil {
+		runtimeConfig = &config.KubernetesRuntimeConfig{}
+	}
+	restConfig, err := clientcmd.BuildConfigFromFlags("", runtimeConfig.Kubeconfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load the Kubernetes configuration: %w", err)
+	}
+	client, err := kubernetes.NewForConfig(restConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the Kubernetes client: %w", err)
+	}
+	return newRuntime(logger, runtimeConfig, client, storage)
+}
+
+// newRuntime creates a runtime that uses the client, the tests pass a fake clientset
+func newRuntime(logger *slog.Logger, runtimeConfig *config.KubernetesRuntimeConfig, client kubernetes.Interface, storage abstractions.Storage) (*Runtime, error) {
+	jobTemplate := defaultJobTemplate
+	if runtimeConfig.JobTemplate != "" {
+		content, err := os.ReadFile(runtimeConfig.JobTemplate)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the Job template: %w", err)
+		}
+		jobTemplate = string(content)
+	} else if runtimeConfig.Image == "" {
+		return nil, fmt.Errorf("the Kubernetes runtime requires a Job template or an image")
+	}
+	tmpl, err := template.New("job").Option("missingkey=error").Parse(jobTemplate)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Job template: %w", err)
+	}
+
+	namespace := runtimeConfig.Namespace
+	if namespace == "" {
+		namespace = defaultNamespace
+		if content, err := os.ReadFile(namespaceFile); err == nil {
+			namespace = strings.TrimSpace(string(content))
+		}
+	}
+
+	r := &Runtime{
+		logger:    logger.With("runtime", config.RuntimeTypeKubernetes, "namespace", namespace),
+		client:    client,
+		storage:   storage,
+		namespace: namespace,
+		image:     runtimeConfig.Image,
+		template:  tmpl,
+		stop:      make(chan struct{}),
+		runs:      make(map[string]*jobRun),
+	}
+	if err := r.startInformers(); err != nil {
+		close(r.stop)
+		return nil, err
+	}
+	r.logger.Info("Started the Kubernetes runtime")
+	return r, nil
+}
+
+// startInformers watches the Jobs and pods created by the service
+func (r *Runtime) startInformers() error {
+	factory := informers.NewSharedInformerFactoryWithOptions(r.client, 0,
+		informers.WithNamespace(r.namespace),
+		informers.WithTweakListOptions(func(options *metav1.ListOptions) {
+			options.LabelSelector = labelManagedBy + "=" + managedByEvalHub
+		}))
+
+	jobs := factory.Batch().V1().Jobs().Informer()
+	_, err := jobs.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(obj any) { r.onJob(obj, false) },
+		UpdateFunc: func(_, obj any) { r.onJob(obj, false) },
+		DeleteFunc: func(obj any) { r.onJob(obj, true) },
+	})
+	if err != nil {
+		return err
+	}
+	pods := factory.Core().V1().Pods().Informer()
+	_, err = pods.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    r.onPod,
+		UpdateFunc: func(_, obj any) { r.onPod(obj) },
+	})
+	if err != nil {
+		return err
+	}
+
+	factory.Start(r.stop)
+	ctx, cancel := context.WithTimeout(context.Background(), cacheSyncTimeout)
+	defer cancel()
+	if !cache.WaitForCacheSync(ctx.Done(), jobs.HasSynced, pods.HasSynced) {
+		return fmt.Errorf("failed to sync the Kubernetes Jobs and pods in namespace %s", r.namespace)
+	}
+	r.restoreRuns(jobs.GetStore().List(), pods.GetStore().List())
+	return nil
+}
+
+// restoreRuns tracks again the evaluation jobs of the synced Kubernetes Jobs that have not
+// finished, so that the jobs created before a restart do not stay running forever. The stored
+// evaluation job is read from the storage of the tenant of its Kubernetes Jobs, and the current
+// states of the Jobs and their pods are then applied as if they had just been received.
+func (r *Runtime) restoreRuns(jobs []any, pods []any) {
+	if r.storage == nil {
+		return
+	}
+	restored := 0
+	for _, obj := range jobs {
+		job, ok := obj.(*batchv1.Job)
+		if !ok {
+			continue
+		}
+		id := job.Labels[labelJobID]
+		r.lock.Lock()
+		_, tracked := r.runs[id]
+		r.lock.Unlock()
+		if (id == "") || tracked {
+			continue
+		}
+		tenant := api.Tenant(job.Annotations[annotationTenant])
+		if tenant == "" {
+			tenant = abstractions.DefaultTenant
+		}
+		storage := r.storage.WithTenant(tenant)
+		evaluation, err := storage.GetEvaluationJob(id)
+		if err != nil {
+			r.logger.Warn("Failed to restore the evaluation job of a Kubernetes Job", "name", job.Name, "job_id", id, "tenant", tenant, "error", err.Error())
+			continue
+		}
+		if state_machine.IsTerminal(evaluation.Status.State) {
+			continue
+		}
+
+		run := &jobRun{
+			evaluation: *evaluation,
+			storage:    storage,
+			benchmarks: make(map[string]*api.BenchmarkStatus),
+		}
+		for _, benchmark := range evaluation.Benchmarks {
+			run.benchmarks[benchmark.ID] = &api.BenchmarkStatus{Name: benchmark.ID, State: api.StatePending}
+		}
+		for _, status := range evaluation.Status.Benchmarks {
+			if _, found := run.benchmarks[status.Name]; found {
+				run.benchmarks[status.Name] = &status
+			}
+		}
+		r.lock.Lock()
+		r.runs[id] = run
+		r.lock.Unlock()
+		restored++
+	}
+	if restored == 0 {
+		return
+	}
+
+	for _, obj := range jobs {
+		r.onJob(obj, false)
+	}
+	for _, obj := range pods {
+		r.onPod(obj)
+	}
+	r.logger.Info("Restored the tracking of the evaluation jobs", "count", restored)
+}
+
+// RunEvaluationJob creates a Kubernetes Job for every benchmark of the evaluation job, the Jobs
+// that were created are deleted again when one of them can not be created.
+func (r *Runtime) RunEvaluationJob(evaluation *api.EvaluationJobResource, storage *abstractions.Storage) error {
+	if len(evaluation.Benchmarks) == 0 {
+		return fmt.Errorf("the evaluation job %s has no benchmarks to run", evaluation.ID)
+	}
+
+	jobs := make([]*batchv1.Job, 0, len(evaluation.Benchmarks))
+	for i := range evaluation.Benchmarks {
+		job, err := r.renderJob(evaluation, i)
+		if err != nil {
+			return err
+		}
+		jobs = append(jobs, job)
+	}
+
+	run := &jobRun{
+		evaluation: *evaluation,
+		storage:    *storage,
+		benchmarks: make(map[string]*api.BenchmarkStatus),
+	}
+	for _, benchmark := range evaluation.Benchmarks {
+		run.benchmarks[benchmark.ID] = &api.BenchmarkStatus{Name: benchmark.ID, State: api.StatePending}
+	}
+	r.lock.Lock()
+	r.runs[evaluation.ID] = run
+	r.lock.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+	for i, job := range jobs {
+		if _, err := r.client.BatchV1().Jobs(r.namespace).Create(ctx, job, metav1.CreateOptions{}); err != nil {
+			r.lock.Lock()
+			delete(r.runs, evaluation.ID)
+			r.lock.Unlock()
+			r.deleteJobs(ctx, jobs[:i])
+			return fmt.Errorf("failed to create the Kubernetes Job for benchmark %s: %w", evaluation.Benchmarks[i].ID, err)
+		}
+	}
+	r.logger.Info("Created the Kubernetes Jobs", "job_id", evaluation.ID, "count", len(jobs))
+	return nil
+}
+
+// CancelEvaluationJob deletes the Kubernetes Jobs of the evaluation job together with their pods,
+// the runtime does not update the states of the job once it has been cancelled.
+func (r *Runtime) CancelEvaluationJob(evaluation *api.EvaluationJobResource, storage *abstractions.Storage) error {
+	r.lock.Lock()
+	delete(r.runs, evaluation.ID)
+	r.lock.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+	// the Jobs are listed rather than tracked so that Jobs created before a restart are deleted too
+	jobs, err := r.client.BatchV1().Jobs(r.namespace).List(ctx, metav1.ListOptions{LabelSelector: labelJobID + "=" + evaluation.ID})
+	if err != nil {
+		return fmt.Errorf("failed to list the Kubernetes Jobs of evaluation job %s: %w", evaluation.ID, err)
+	}
+	propagation := metav1.DeletePropagationBackground
+	for _, job := range jobs.Items {
+		err := r.client.BatchV1().Jobs(r.namespace).Delete(ctx, job.Name, metav1.DeleteOptions{PropagationPolicy: &propagation})
+		if (err != nil) && !apierrors.IsNotFound(err) {
+			return fmt.Errorf("failed to delete the Kubernetes Job %s: %w", job.Name, err)
+		}
+	}
+	r.logger.Info("Cancelled the evaluation job", "job_id", evaluation.ID, "deleted_jobs", len(jobs.Items))
+	return nil
+}
+
+// Close stops watching the Kubernetes Jobs, the Jobs themselves keep running
+func (r *Runtime) Close() error {
+	r.stopOnce.Do(func() { close(r.stop) })
+	return nil
+}
+
+func (r *Runtime) deleteJobs(ctx context.Context, jobs []*batchv1.Job) {
+	propagation := metav1.DeletePropagationBackground
+	for _, job := range jobs {
+		err := r.client.BatchV1().Jobs(r.namespace).Delete(ctx, job.Name, metav1.DeleteOptions{PropagationPolicy: &propagation})
+		if err != nil {
+			r.logger.Error("Failed to delete the Kubernetes Job", "name", job.Name, "error", err.Error())
+		}
+	}
+}
+
+// renderJob renders the Job template for a benchmark and sets the parts that the runtime relies on:
+// the name, the labels, the environment and, unless the template sets them, the retries and deadline
+func (r *Runtime) renderJob(evaluation *api.EvaluationJobResource, index int) (*batchv1.Job, error) {
+	benchmark := &evaluation.Benchmarks[index]
+	env, err := runtime_env.BenchmarkEnv(evaluation, benchmark)
+	if err != nil {
+		return nil, err
+	}
+	data := TemplateData{
+		Name:       jobName(evaluation.ID, index, benchmark.ID),
+		Namespace:  r.namespace,
+		Image:      r.image,
+		JobID:      evaluation.ID,
+		Model:      evaluation.Model,
+		Benchmark:  *benchmark,
+		Experiment: evaluation.Experiment,
+		Env:        env,
+	}
+
+	var manifest bytes.Buffer
+	if err := r.template.Execute(&manifest, data); err != nil {
+		return nil, fmt.Errorf("failed to render the Job template: %w", err)
+	}
+	job := &batchv1.Job{}
+	if err := yaml.UnmarshalStrict(manifest.Bytes(), job); err != nil {
+		return nil, fmt.Errorf("the rendered Job template is not a valid Job: %w", err)
+	}
+	if (job.Kind != "" && job.Kind != "Job") || (job.APIVersion != "" && job.APIVersion != "batch/v1") {
+		return nil, fmt.Errorf("the Job template must be a batch/v1 Job, not %s %s", job.APIVersion, job.Kind)
+	}
+	if len(job.Spec.Template.Spec.Containers) == 0 {
+		return nil, fmt.Errorf("the Job template has no containers")
+	}
+
+	job.Name = data.Name
+	job.Namespace = r.namespace
+	for _, meta := range []*metav1.ObjectMeta{&job.ObjectMeta, &job.Spec.Template.ObjectMeta} {
+		if meta.Labels == nil {
+			meta.Labels = make(map[string]string)
+		}
+		meta.Labels[labelManagedBy] = managedByEvalHub
+		meta.Labels[labelJobID] = evaluation.ID
+		if meta.Annotations == nil {
+			meta.Annotations = make(map[string]string)
+		}
+		meta.Annotations[annotationBenchmarkID] = benchmark.ID
+		meta.Annotations[annotationTenant] = string(evaluation.Tenant)
+	}
+	if (job.Spec.BackoffLimit == nil) && (evaluation.RetryAttempts != nil) {
+		backoffLimit := int32(*evaluation.RetryAttempts)
+		job.Spec.BackoffLimit = &backoffLimit
+	}
+	if (job.Spec.ActiveDeadlineSeconds == nil) && (evaluation.TimeoutMinutes != nil) {
+		deadline := int64(*evaluation.TimeoutMinutes) * 60
+		job.Spec.ActiveDeadlineSeconds = &deadline
+	}
+
+	// the benchmark variables come last so that they win over variables of the same name in the template
+	vars := make([]corev1.EnvVar, 0, len(env))
+	for _, name := range slices.Sorted(maps.Keys(env)) {
+		vars = append(vars, corev1.EnvVar{Name: name, Value: env[name]})
+	}
+	for i := range job.Spec.Template.Spec.Containers {
+		container := &job.Spec.Template.Spec.Containers[i]
+		container.Env = append(container.Env, vars...)
+	}
+	return job, nil
+}
+
+// onJob maps the conditions of a Kubernetes Job to the state of its benchmark
+func (r *Runtime) onJob(obj any, deleted bool) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	job, ok := obj.(*batchv1.Job)
+	if !ok {
+		return
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	run, status := r.benchmarkFor(&job.ObjectMeta)
+	if status == nil {
+		return
+	}
+
+	updated := *status
+	now := time.Now().UTC()
+	switch state, message := jobState(job); {
+	case state == api.StateCompleted || state == api.StateFailed:
+		updated.State = state
+		updated.Message = message
+		completedAt := now
+		if job.Status.CompletionTime != nil {
+			completedAt = job.Status.CompletionTime.UTC()
+		}
+		updated.CompletedAt = &completedAt
+	case deleted:
+		updated.State = api.StateFailed
+		updated.Message = "The Kubernetes Job was deleted before it finished"
+		completedAt := now
+		updated.CompletedAt = &completedAt
+	case state == api.StateRunning:
+		updated.State = api.StateRunning
+		updated.Message = ""
+	default:
+		return
+	}
+	if (updated.StartedAt == nil) && (updated.State != api.StatePending) {
+		startedAt := now
+		if job.Status.StartTime != nil {
+			startedAt = job.Status.StartTime.UTC()
+		}
+		updated.StartedAt = &startedAt
+	}
+	r.setBenchmarkStatus(run, status, updated)
+}
+
+// onPod reports running benchmarks and the reasons pods are waiting, such as image pull failures
+func (r *Runtime) onPod(obj any) {
+	pod, ok := obj.(*corev1.Pod)
+	if !ok {
+		return
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	run, status := r.benchmarkFor(&pod.ObjectMeta)
+	if (status == nil) || state_machine.IsTerminal(status.State) {
+		return
+	}
+
+	updated := *status
+	switch pod.Status.Phase {
+	case corev1.PodRunning:
+		updated.State = api.StateRunning
+		updated.Message = ""
+		if updated.StartedAt == nil {
+			startedAt := time.Now().UTC()
+			if pod.Status.StartTime != nil {
+				startedAt = pod.Status.StartTime.UTC()
+			}
+			updated.StartedAt = &startedAt
+		}
+	case corev1.PodPending:
+		for _, container := range pod.Status.ContainerStatuses {
+			waiting := container.State.Waiting
+			if (waiting != nil) && (waiting.Reason != "") && (waiting.Reason != "ContainerCreating") && (waiting.Reason != "PodInitializing") {
+				updated.Message = fmt.Sprintf("%s: %s %s", waitingReasonPrefix, waiting.Reason, waiting.Message)
+				break
+			}
+		}
+	default:
+		return
+	}
+	r.setBenchmarkStatus(run, status, updated)
+}
+
+// benchmarkFor returns the tracked benchmark of a Kubernetes resource, must be called with the lock held
+func (r *Runtime) benchmarkFor(meta *metav1.ObjectMeta) (*jobRun, *api.BenchmarkStatus) {
+	run := r.runs[meta.Labels[labelJobID]]
+	if run == nil {
+		return nil, nil
+	}
+	return run, run.benchmarks[meta.Annotations[annotationBenchmarkID]]
+}
+
+// setBenchmarkStatus stores the benchmark status when it changed, the storage derives the state
+// of the evaluation job from the states of its benchmarks. The job stops being tracked once all its
+// benchmarks have finished. Must be called with the lock held.
+func (r *Runtime) setBenchmarkStatus(run *jobRun, status *api.BenchmarkStatus, updated api.BenchmarkStatus) {
+	if (updated.State == status.State) && (updated.Message == status.Message) {
+		return
+	}
+	*status = updated
+	if err := run.storage.UpdateBenchmarkStatusForJob(run.evaluation.ID, updated); err != nil {
+		r.logger.Error("Failed to update the benchmark status", "job_id", run.evaluation.ID, "benchmark_id", status.Name,
+			"state", updated.State, "error", err.Error())
+	}
+
+	for _, benchmark := range run.benchmarks {
+		if !state_machine.IsTerminal(benchmark.State) {
+			return
+		}
+	}
+	delete(r.runs, run.evaluation.ID)
+}
+
+// jobState maps the conditions of a Kubernetes Job to a state, the Job is running while it has
+// ready pods and pending until then
+func jobState(job *batchv1.Job) (api.State, string) {
+	for _, condition := range job.Status.Conditions {
+		if condition.Status != corev1.ConditionTrue {
+			continue
+		}
+		switch condition.Type {
+		case batchv1.JobComplete:
+			return api.StateCompleted, "Benchmark completed"
+		case batchv1.JobFailed:
+			return api.StateFailed, strings.TrimSpace(fmt.Sprintf("The Kubernetes Job failed: %s %s", condition.Reason, condition.Message))
+		}
+	}
+	if (job.Status.Ready != nil) && (*job.Status.Ready > 0) {
+		return api.StateRunning, ""
+	}
+	return api.StatePending, ""
+}
+
+// jobName builds a DNS-1123 name for the Job of a benchmark, the index keeps it unique when
+// benchmark IDs only differ in characters that are not allowed in names
+func jobName(jobID string, index int, benchmarkID string) string {
+	name := fmt.Sprintf("eval-%s-%d-%s", jobID, index, benchmarkID)
+	name = invalidNameCharacters.ReplaceAllString(strings.ToLower(name), "-")
+	if len(name) > maxNameLength {
+		name = name[:maxNameLength]
+	}
+	return strings.TrimRight(name, "-")
+}
@@ -0,0 +1,353 @@
+package k8s
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/abstractions"
+	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/config"
+	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/logging"
+	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/runtimes/runtime_env"
+	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/storage/memory"
+	"github.ibm.com/julpayne/eval-hub-backend-svc/pkg/api"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sruntime "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+const testNamespace = "evaluations"
+
+func newTestRuntime(t *testing.T, runtimeConfig *config.KubernetesRuntimeConfig) (*Runtime, *fake.Clientset) {
+	t.Helper()
+	client := fake.NewClientset()
+	return startRuntime(t, runtimeConfig, client, nil), client
+}
+
+// startRuntime starts a runtime on an existing clientset, as the service does when it restarts
+func startRuntime(t *testing.T, runtimeConfig *config.KubernetesRuntimeConfig, client *fake.Clientset, storage abstractions.Storage) *Runtime {
+	t.Helper()
+	logger, err := logging.NewLogger()
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	runtimeConfig.Namespace = testNamespace
+	runtime, err := newRuntime(logger, runtimeConfig, client, storage)
+	if err != nil {
+		t.Fatalf("newRuntime() returned error: %v", err)
+	}
+	t.Cleanup(func() { runtime.Close() })
+	return runtime
+}
+
+func newJob(id string, benchmarks ...string) *api.EvaluationJobResource {
+	timeout := 30
+	retries := 2
+	limit := 5
+	job := &api.EvaluationJobResource{
+		Resource: api.Resource{ID: id},
+		EvaluationJobConfig: api.EvaluationJobConfig{
+			Model:          api.ModelRef{URL: "http://model:8000/v1", Name: "granite"},
+			TimeoutMinutes: &timeout,
+			RetryAttempts:  &retries,
+		},
+		Status: api.EvaluationJobStatus{EvaluationJobState: api.EvaluationJobState{State: api.StatePending}},
+	}
+	for _, benchmark := range benchmarks {
+		job.Benchmarks = append(job.Benchmarks, api.BenchmarkConfig{
+			Ref:        api.Ref{ID: benchmark},
+			Limit:      &limit,
+			Parameters: map[string]any{"num_fewshot": 3},
+		})
+		job.Status.Benchmarks = append(job.Status.Benchmarks, api.BenchmarkStatus{Name: benchmark, State: api.StatePending})
+	}
+	return job
+}
+
+func runJob(t *testing.T, runtime *Runtime, job *api.EvaluationJobResource) abstractions.Storage {
+	t.Helper()
+	var storage abstractions.Storage = memory.NewStorage()
+	if err := storage.CreateEvaluationJob(job); err != nil {
+		t.Fatalf("CreateEvaluationJob() returned error: %v", err)
+	}
+	if err := runtime.RunEvaluationJob(job, &storage); err != nil {
+		t.Fatalf("RunEvaluationJob() returned error: %v", err)
+	}
+	return storage
+}
+
+func listJobs(t *testing.T, client *fake.Clientset) []batchv1.Job {
+	t.Helper()
+	jobs, err := client.BatchV1().Jobs(testNamespace).List(context.Background(), metav1.ListOptions{})
+	if err != nil {
+		t.Fatalf("Failed to list the Jobs: %v", err)
+	}
+	return jobs.Items
+}
+
+func updateJobStatus(t *testing.T, client *fake.Clientset, job batchv1.Job, status batchv1.JobStatus) {
+	t.Helper()
+	job.Status = status
+	if _, err := client.BatchV1().Jobs(testNamespace).UpdateStatus(context.Background(), &job, metav1.UpdateOptions{}); err != nil {
+		t.Fatalf("Failed to update the Job status: %v", err)
+	}
+}
+
+// waitFor polls the stored evaluation job until the condition holds
+func waitFor(t *testing.T, storage abstractions.Storage, id string, condition func(*api.EvaluationJobResource) bool) *api.EvaluationJobResource {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		job, err := storage.GetEvaluationJob(id)
+		if err != nil {
+			t.Fatalf("GetEvaluationJob() returned error: %v", err)
+		}
+		if condition(job) {
+			return job
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("The evaluation job did not reach the expected status, got %+v", job.Status)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func benchmarkStatus(job *api.EvaluationJobResource, name string) *api.BenchmarkStatus {
+	for i := range job.Status.Benchmarks {
+		if job.Status.Benchmarks[i].Name == name {
+			return &job.Status.Benchmarks[i]
+		}
+	}
+	return nil
+}
+
+func TestRunEvaluationJob(t *testing.T) {
+	t.Run("creates a Job per benchmark with the benchmark environment", func(t *testing.T) {
+		runtime, client := newTestRuntime(t, &config.KubernetesRuntimeConfig{Image: "quay.io/eval/lm-eval:latest"})
+
+		runJob(t, runtime, newJob("job-1", "mmlu", "arc_easy"))
+
+		jobs := listJobs(t, client)
+		if len(jobs) != 2 {
+			t.Fatalf("Expected 2 Jobs, got %d", len(jobs))
+		}
+		for _, job := range jobs {
+			if job.Labels[labelJobID] != "job-1" || job.Spec.Template.Labels[labelManagedBy] != managedByEvalHub {
+				t.Errorf("Expected the Job and its pods to be labelled, got %v and %v", job.Labels, job.Spec.Template.Labels)
+			}
+			if *job.Spec.BackoffLimit != 2 || *job.Spec.ActiveDeadlineSeconds != 30*60 {
+				t.Errorf("Expected the retries and timeout to be set, got %d and %d", *job.Spec.BackoffLimit, *job.Spec.ActiveDeadlineSeconds)
+			}
+			container := job.Spec.Template.Spec.Containers[0]
+			if container.Image != "quay.io/eval/lm-eval:latest" {
+				t.Errorf("Expected the configured image, got %s", container.Image)
+			}
+			env := make(map[string]string)
+			for _, v := range container.Env {
+				env[v.Name] = v.Value
+			}
+			benchmarkID := job.Annotations[annotationBenchmarkID]
+			if env[runtime_env.EnvBenchmarkID] != benchmarkID || env[runtime_env.EnvModelURL] != "http://model:8000/v1" ||
+				env[runtime_env.EnvBenchmarkLimit] != "5" || env[runtime_env.EnvBenchmarkParameters] != `{"num_fewshot":3}` {
+				t.Errorf("Expected the benchmark environment, got %v", env)
+			}
+		}
+	})
+
+	t.Run("Job and pod statuses are mapped to the benchmark and job states", func(t *testing.T) {
+		runtime, client := newTestRuntime(t, &config.KubernetesRuntimeConfig{Image: "image"})
+		storage := runJob(t, runtime, newJob("job-2", "mmlu", "arc"))
+		jobs := listJobs(t, client)
+		byBenchmark := make(map[string]batchv1.Job)
+		for _, job := range jobs {
+			byBenchmark[job.Annotations[annotationBenchmarkID]] = job
+		}
+
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "mmlu-pod",
+				Namespace:   testNamespace,
+				Labels:      byBenchmark["mmlu"].Spec.Template.Labels,
+				Annotations: byBenchmark["mmlu"].Spec.Template.Annotations,
+			},
+			Status: corev1.PodStatus{
+				Phase: corev1.PodPending,
+				ContainerStatuses: []corev1.ContainerStatus{{
+					State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "ImagePullBackOff", Message: "not found"}},
+				}},
+			},
+		}
+		if _, err := client.CoreV1().Pods(testNamespace).Create(context.Background(), pod, metav1.CreateOptions{}); err != nil {
+			t.Fatalf("Failed to create the pod: %v", err)
+		}
+		waitFor(t, storage, "job-2", func(job *api.EvaluationJobResource) bool {
+			status := benchmarkStatus(job, "mmlu")
+			return status != nil && strings.Contains(status.Message, "ImagePullBackOff")
+		})
+
+		ready := int32(1)
+		updateJobStatus(t, client, byBenchmark["mmlu"], batchv1.JobStatus{Ready: &ready, StartTime: &metav1.Time{Time: time.Now()}})
+		job := waitFor(t, storage, "job-2", func(job *api.EvaluationJobResource) bool {
+			return job.Status.State == api.StateRunning
+		})
+		if status := benchmarkStatus(job, "mmlu"); status.State != api.StateRunning || status.StartedAt == nil {
+			t.Errorf("Expected mmlu to be running with a start time, got %+v", status)
+		}
+
+		updateJobStatus(t, client, byBenchmark["mmlu"], batchv1.JobStatus{
+			Conditions: []batchv1.JobCondition{{Type: batchv1.JobComplete, Status: corev1.ConditionTrue}},
+		})
+		updateJobStatus(t, client, byBenchmark["arc"], batchv1.JobStatus{
+			Conditions: []batchv1.JobCondition{{Type: batchv1.JobFailed, Status: corev1.ConditionTrue, Reason: "BackoffLimitExceeded"}},
+		})
+		job = waitFor(t, storage, "job-2", func(job *api.EvaluationJobResource) bool {
+			return job.Status.State == api.StateFailed
+		})
+		if status := benchmarkStatus(job, "mmlu"); status.State != api.StateCompleted || status.CompletedAt == nil {
+			t.Errorf("Expected mmlu to be completed, got %+v", status)
+		}
+		if status := benchmarkStatus(job, "arc"); status.State != api.StateFailed || !strings.Contains(status.Message, "BackoffLimitExceeded") {
+			t.Errorf("Expected arc to fail with the Job reason, got %+v", status)
+		}
+	})
+
+	t.Run("all completed benchmarks complete the job", func(t *testing.T) {
+		runtime, client := newTestRuntime(t, &config.KubernetesRuntimeConfig{Image: "image"})
+		storage := runJob(t, runtime, newJob("job-3", "mmlu"))
+
+		updateJobStatus(t, client, listJobs(t, client)[0], batchv1.JobStatus{
+			Conditions: []batchv1.JobCondition{{Type: batchv1.JobComplete, Status: corev1.ConditionTrue}},
+		})
+
+		waitFor(t, storage, "job-3", func(job *api.EvaluationJobResource) bool {
+			return job.Status.State == api.StateCompleted
+		})
+	})
+
+	t.Run("the created Jobs are deleted when a Job can not be created", func(t *testing.T) {
+		runtime, client := newTestRuntime(t, &config.KubernetesRuntimeConfig{Image: "image"})
+		created := 0
+		client.PrependReactor("create", "jobs", func(action k8stesting.Action) (bool, k8sruntime.Object, error) {
+			created++
+			if created == 2 {
+				return true, nil, os.ErrPermission
+			}
+			return false, nil, nil
+		})
+
+		var storage abstractions.Storage = memory.NewStorage()
+		if err := runtime.RunEvaluationJob(newJob("job-4", "mmlu", "arc"), &storage); err == nil {
+			t.Fatal("Expected an error when a Job can not be created")
+		}
+		if jobs := listJobs(t, client); len(jobs) != 0 {
+			t.Errorf("Expected the created Jobs to be deleted, got %d", len(jobs))
+		}
+	})
+}
+
+func TestRestoreRuns(t *testing.T) {
+	client := fake.NewClientset()
+	root := memory.NewStorage()
+	var storage abstractions.Storage = root.WithTenant("acme")
+	job := newJob("job-1", "mmlu", "arc")
+	storage.CreateEvaluationJob(job)
+	before := startRuntime(t, &config.KubernetesRuntimeConfig{Image: "image"}, client, root)
+	if err := before.RunEvaluationJob(job, &storage); err != nil {
+		t.Fatalf("RunEvaluationJob() returned error: %v", err)
+	}
+	before.Close()
+
+	// mmlu completes while the service is down, the restarted runtime picks the state up on its sync
+	byBenchmark := make(map[string]batchv1.Job)
+	for _, job := range listJobs(t, client) {
+		byBenchmark[job.Annotations[annotationBenchmarkID]] = job
+	}
+	updateJobStatus(t, client, byBenchmark["mmlu"], batchv1.JobStatus{
+		Conditions: []batchv1.JobCondition{{Type: batchv1.JobComplete, Status: corev1.ConditionTrue}},
+	})
+	after := startRuntime(t, &config.KubernetesRuntimeConfig{Image: "image"}, client, root)
+	waitFor(t, storage, "job-1", func(job *api.EvaluationJobResource) bool {
+		return benchmarkStatus(job, "mmlu").State == api.StateCompleted
+	})
+
+	updateJobStatus(t, client, byBenchmark["arc"], batchv1.JobStatus{
+		Conditions: []batchv1.JobCondition{{Type: batchv1.JobComplete, Status: corev1.ConditionTrue}},
+	})
+	waitFor(t, storage, "job-1", func(job *api.EvaluationJobResource) bool {
+		return job.Status.State == api.StateCompleted
+	})
+	after.lock.Lock()
+	defer after.lock.Unlock()
+	if len(after.runs) != 0 {
+		t.Errorf("Expected the finished jobs not to be tracked, got %d", len(after.runs))
+	}
+}
+
+func TestCancelEvaluationJob(t *testing.T) {
+	runtime, client := newTestRuntime(t, &config.KubernetesRuntimeConfig{Image: "image"})
+	job := newJob("job-1", "mmlu", "arc")
+	storage := runJob(t, runtime, job)
+	runJob(t, runtime, newJob("job-2", "mmlu"))
+
+	if err := runtime.CancelEvaluationJob(job, &storage); err != nil {
+		t.Fatalf("CancelEvaluationJob() returned error: %v", err)
+	}
+
+	jobs := listJobs(t, client)
+	if len(jobs) != 1 || jobs[0].Labels[labelJobID] != "job-2" {
+		t.Errorf("Expected only the Jobs of job-1 to be deleted, got %d Jobs", len(jobs))
+	}
+	if got, _ := storage.GetEvaluationJob("job-1"); got.Status.State != api.StatePending {
+		t.Errorf("Expected the runtime not to update the cancelled job, got %s", got.Status.State)
+	}
+}
+
+func TestJobTemplate(t *testing.T) {
+	templatePath := filepath.Join(t.TempDir(), "job.yaml")
+	os.WriteFile(templatePath, []byte(`apiVersion: batch/v1
+kind: Job
+metadata:
+  labels:
+    team: evals
+spec:
+  backoffLimit: 0
+  template:
+    spec:
+      restartPolicy: Never
+      containers:
+        - name: lm-eval
+          image: lm-eval
+          args: ["--tasks", "{{ .Benchmark.ID }}", "--model_args", "base_url={{ .Model.URL }}"]
+`), 0o644)
+	runtime, _ := newTestRuntime(t, &config.KubernetesRuntimeConfig{JobTemplate: templatePath})
+
+	job, err := runtime.renderJob(newJob("3f2b6c1e-7c1d-4a53-9f3e-0d6f3c1c9f00", "MMLU/Pro"), 0)
+	if err != nil {
+		t.Fatalf("renderJob() returned error: %v", err)
+	}
+	if job.Name != "eval-3f2b6c1e-7c1d-4a53-9f3e-0d6f3c1c9f00-0-mmlu-pro" || len(job.Name) > maxNameLength {
+		t.Errorf("Expected a valid Job name, got %s", job.Name)
+	}
+	if job.Labels["team"] != "evals" || job.Labels[labelJobID] == "" {
+		t.Errorf("Expected the template labels to be kept, got %v", job.Labels)
+	}
+	if *job.Spec.BackoffLimit != 0 {
+		t.Errorf("Expected the template backoff limit to be kept, got %d", *job.Spec.BackoffLimit)
+	}
+	if args := job.Spec.Template.Spec.Containers[0].Args; args[1] != "MMLU/Pro" || args[3] != "base_url=http://model:8000/v1" {
+		t.Errorf("Expected the template to be rendered, got %v", args)
+	}
+
+	os.WriteFile(templatePath, []byte("kind: Pod\napiVersion: v1\n"), 0o644)
+	runtime, _ = newTestRuntime(t, &config.KubernetesRuntimeConfig{JobTemplate: templatePath})
+	if _, err := runtime.renderJob(newJob("job-1", "mmlu"), 0); err == nil {
+		t.Error("Expected an error for a template that is not a Job")
+	}
+}
@@ -0,0 +1,439 @@
+package local
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/abstractions"
+	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/config"
+	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/runtimes/runtime_env"
+	"github.ibm.com/julpayne/eval-hub-backend-svc/pkg/api"
+)
+
+const (
+	defaultWorkers    = 4
+	defaultQueueSize  = 100
+	defaultRetryDelay = 5 * time.Second
+	maxRetryDelay     = 5 * time.Minute
+	// processWaitDelay is how long a process that was killed has to release its output
+	processWaitDelay = 10 * time.Second
+)
+
+var unsafeFileNameCharacters = regexp.MustCompile(`[^A-Za-z0-9._-]`)
+
+// ErrQueueFull is returned when a job has more benchmarks than there is room for in the queue, it
+// is an abstractions.ErrRuntimeBusy
+var ErrQueueFull = fmt.Errorf("the local runtime queue is full: %w", abstractions.ErrRuntimeBusy)
+
+// CommandData is the data available to the command templates
+type CommandData struct {
+	JobID      string
+	Model      api.ModelRef
+	Benchmark  api.BenchmarkConfig
+	Experiment api.ExperimentConfig
+	// OutputDir is a directory that belongs to the benchmark run
+	OutputDir string
+	// Env holds the environment variables that are also set on the process
+	Env map[string]string
+}
+
+// Runtime runs every benchmark of an evaluation job as a local process. The benchmarks are queued
+// and run by a fixed pool of workers, the states of the benchmarks and of the job are written to
+// the storage that is passed with the job.
+type Runtime struct {
+	logger     *slog.Logger
+	command    []*template.Template
+	workDir    string
+	retryDelay time.Duration
+	// timeoutUnit is the unit of the TimeoutMinutes of the jobs
+	timeoutUnit time.Duration
+	queue       chan *task
+	wg          sync.WaitGroup
+	lock        sync.Mutex
+	// runs holds the evaluation jobs that have benchmarks that have not finished, by job ID
+	runs map[string]*jobRun
+	// ctx is cancelled when the runtime is closed, it stops the workers and the running processes
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// jobRun tracks the benchmarks of an evaluation job until they have all finished
+type jobRun struct {
+	evaluation api.EvaluationJobResource
+	storage    abstractions.Storage
+	ctx        context.Context
+	cancel     context.CancelFunc
+	lock       sync.Mutex
+	remaining  int
+	// cancelled is set when the job is cancelled, the states are no longer written from then on
+	cancelled bool
+}
+
+// task is a single benchmark of an evaluation job
+type task struct {
+	job       *jobRun
+	benchmark api.BenchmarkConfig
+}
+
+// NewRuntime creates a local runtime and starts its workers
+func NewRuntime(logger *slog.Logger, runtimeConfig *config.LocalRuntimeConfig) (*Runtime, error) {
+	if (runtimeConfig == nil) || (len(runtimeConfig.Command) == 0) {
+		return nil, fmt.Errorf("the local runtime requires a command")
+	}
+
+	command := make([]*template.Template, 0, len(runtimeConfig.Command))
+	for i, arg := range runtimeConfig.Command {
+		tmpl, err := template.New(fmt.Sprintf("arg%d", i)).Funcs(template.FuncMap{"json": toJSON}).Option("missingkey=error").Parse(arg)
+		if err != nil {
+			return nil, fmt.Errorf("invalid local runtime command argument %q: %w", arg, err)
+		}
+		command = append(command, tmpl)
+	}
+
+	workers := runtimeConfig.Workers
+	if workers <= 0 {
+		workers = defaultWorkers
+	}
+	queueSize := runtimeConfig.QueueSize
+	if queueSize <= 0 {
+		queueSize = defaultQueueSize
+	}
+	workDir := runtimeConfig.WorkDir
+	if workDir == "" {
+		workDir = filepath.Join(os.TempDir(), "eval-hub")
+	}
+	retryDelay := runtimeConfig.RetryDelay
+	if retryDelay <= 0 {
+		retryDelay = defaultRetryDelay
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	r := &Runtime{
+		logger:      logger.With("runtime", config.RuntimeTypeLocal),
+		command:     command,
+		workDir:     workDir,
+		retryDelay:  retryDelay,
+		timeoutUnit: time.Minute,
+		queue:       make(chan *task, queueSize),
+		runs:        make(map[string]*jobRun),
+		ctx:         ctx,
+		cancel:      cancel,
+	}
+	for i := 0; i < workers; i++ {
+		r.wg.Add(1)
+		go r.worker()
+	}
+	r.logger.Info("Started the local runtime", "workers", workers, "queue_size", queueSize, "work_dir", workDir)
+	return r, nil
+}
+
+// RunEvaluationJob queues the benchmarks of the evaluation job. Every attempt of a benchmark times
+// out TimeoutMinutes after a worker has started it, so the time spent in the queue does not count,
+// and every benchmark is retried up to RetryAttempts times.
+func (r *Runtime) RunEvaluationJob(evaluation *api.EvaluationJobResource, storage *abstractions.Storage) error {
+	if len(evaluation.Benchmarks) == 0 {
+		return fmt.Errorf("the evaluation job %s has no benchmarks to run", evaluation.ID)
+	}
+
+	ctx, cancel := context.WithCancel(r.ctx)
+	job := &jobRun{
+		evaluation: *evaluation,
+		storage:    *storage,
+		ctx:        ctx,
+		cancel:     cancel,
+		remaining:  len(evaluation.Benchmarks),
+	}
+
+	// the benchmarks are only queued under the lock and the workers only take tasks from the queue,
+	// so the room that is checked here can not be taken by another job and either every benchmark
+	// of the job is queued or none is
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if len(evaluation.Benchmarks) > (cap(r.queue) - len(r.queue)) {
+		cancel()
+		return ErrQueueFull
+	}
+	r.runs[evaluation.ID] = job
+	for _, benchmark := range evaluation.Benchmarks {
+		r.queue <- &task{job: job, benchmark: benchmark}
+	}
+	return nil
+}
+
+// MaxBenchmarks returns the size of the queue, the benchmarks of a job are queued together so a job
+// with more benchmarks would never fit
+func (r *Runtime) MaxBenchmarks() int {
+	return cap(r.queue)
+}
+
+// CancelEvaluationJob kills the running processes of the evaluation job and drops its queued
+// benchmarks, the runtime does not update the states of the job once it has been cancelled.
+func (r *Runtime) CancelEvaluationJob(evaluation *api.EvaluationJobResource, storage *abstractions.Storage) error {
+	r.lock.Lock()
+	job := r.runs[evaluation.ID]
+	delete(r.runs, evaluation.ID)
+	r.lock.Unlock()
+	if job == nil {
+		return nil
+	}
+
+	job.lock.Lock()
+	job.cancelled = true
+	job.lock.Unlock()
+	job.cancel()
+	r.logger.Info("Cancelled the evaluation job", "job_id", evaluation.ID)
+	return nil
+}
+
+// Close stops the workers, the running processes are killed and their benchmarks fail
+func (r *Runtime) Close() error {
+	r.cancel()
+	r.wg.Wait()
+	return nil
+}
+
+func (r *Runtime) worker() {
+	defer r.wg.Done()
+	for {
+		select {
+		case <-r.ctx.Done():
+			return
+		case task := <-r.queue:
+			r.runBenchmark(task)
+		}
+	}
+}
+
+// runBenchmark runs the benchmark until it succeeds or it has used all its attempts
+func (r *Runtime) runBenchmark(task *task) {
+	job := task.job
+	logger := r.logger.With("job_id", job.evaluation.ID, "benchmark_id", task.benchmark.ID)
+
+	jobDir := filepath.Join(r.workDir, safeFileName(string(job.evaluation.Tenant)), safeFileName(job.evaluation.ID))
+	benchmarkName := safeFileName(task.benchmark.ID)
+	logPath := filepath.Join(jobDir, benchmarkName+".log")
+	outputDir := filepath.Join(jobDir, benchmarkName)
+
+	startedAt := time.Now().UTC()
+	status := api.BenchmarkStatus{
+		Name:      task.benchmark.ID,
+		State:     api.StateRunning,
+		StartedAt: &startedAt,
+		Logs:      &api.BenchmarkStatusLogs{Path: logPath},
+	}
+
+	if err := job.ctx.Err(); err != nil {
+		r.finishBenchmark(logger, job, status, jobError(err))
+		return
+	}
+	r.startBenchmark(logger, job, status)
+
+	attempts := 1
+	if job.evaluation.RetryAttempts != nil {
+		attempts += *job.evaluation.RetryAttempts
+	}
+	retryDelay := r.retryDelay
+
+	var err error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		if attempt > 1 {
+			status.Message = fmt.Sprintf("Retrying after a failed attempt: %v (attempt %d of %d)", err, attempt, attempts)
+			job.lock.Lock()
+			r.updateBenchmark(logger, job, status)
+			job.lock.Unlock()
+			select {
+			case <-job.ctx.Done():
+				r.finishBenchmark(logger, job, status, jobError(job.ctx.Err()))
+				return
+			case <-time.After(retryDelay):
+			}
+			retryDelay = min(2*retryDelay, maxRetryDelay)
+		}
+
+		err = r.runAttempt(job, task.benchmark, outputDir, logPath, attempt)
+		if err == nil {
+			break
+		}
+		logger.Warn("Benchmark attempt failed", "attempt", attempt, "attempts", attempts, "error", err.Error())
+		if job.ctx.Err() != nil {
+			err = jobError(job.ctx.Err())
+			break
+		}
+	}
+	r.finishBenchmark(logger, job, status, err)
+}
+
+// runAttempt runs a single attempt of the benchmark, the process is killed when the attempt runs
+// longer than the timeout of the job
+func (r *Runtime) runAttempt(job *jobRun, benchmark api.BenchmarkConfig, outputDir string, logPath string, attempt int) error {
+	if job.evaluation.TimeoutMinutes == nil {
+		return r.runProcess(job.ctx, job, benchmark, outputDir, logPath, attempt)
+	}
+	timeout := time.Duration(*job.evaluation.TimeoutMinutes) * r.timeoutUnit
+	ctx, cancel := context.WithTimeout(job.ctx, timeout)
+	defer cancel()
+	err := r.runProcess(ctx, job, benchmark, outputDir, logPath, attempt)
+	if (err != nil) && (job.ctx.Err() == nil) && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("the attempt timed out after %d minutes", *job.evaluation.TimeoutMinutes)
+	}
+	return err
+}
+
+// runProcess runs the process of an attempt of the benchmark until it exits or the context ends,
+// the output of the process is appended to the benchmark's log file
+func (r *Runtime) runProcess(ctx context.Context, job *jobRun, benchmark api.BenchmarkConfig, outputDir string, logPath string, attempt int) error {
+	if err := os.MkdirAll(outputDir, 0o755); err != nil {
+		return fmt.Errorf("failed to create the output directory: %w", err)
+	}
+	logFile, err := os.OpenFile(logPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open the log file: %w", err)
+	}
+	defer logFile.Close()
+
+	env, err := runtime_env.BenchmarkEnv(&job.evaluation, &benchmark)
+	if err != nil {
+		return err
+	}
+	env[runtime_env.EnvOutputDir] = outputDir
+
+	args, err := r.renderCommand(CommandData{
+		JobID:      job.evaluation.ID,
+		Model:      job.evaluation.Model,
+		Benchmark:  benchmark,
+		Experiment: job.evaluation.Experiment,
+		OutputDir:  outputDir,
+		Env:        env,
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(logFile, "=== attempt %d started at %s: %s\n", attempt, time.Now().UTC().Format(time.RFC3339), strings.Join(args, " "))
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Dir = outputDir
+	cmd.Env = os.Environ()
+	for _, name := range slices.Sorted(maps.Keys(env)) {
+		cmd.Env = append(cmd.Env, name+"="+env[name])
+	}
+	cmd.Stdout = logFile
+	cmd.Stderr = logFile
+	cmd.WaitDelay = processWaitDelay
+
+	err = cmd.Run()
+	if err != nil {
+		fmt.Fprintf(logFile, "=== attempt %d failed: %v\n", attempt, err)
+		return err
+	}
+	fmt.Fprintf(logFile, "=== attempt %d completed\n", attempt)
+	return nil
+}
+
+// renderCommand renders the command templates, arguments that render to an empty string are
+// dropped so that optional flags can be written as {{if .Benchmark.Limit}}--limit={{.Benchmark.Limit}}{{end}}
+func (r *Runtime) renderCommand(data CommandData) ([]string, error) {
+	args := make([]string, 0, len(r.command))
+	for _, tmpl := range r.command {
+		var arg bytes.Buffer
+		if err := tmpl.Execute(&arg, data); err != nil {
+			return nil, fmt.Errorf("failed to render the command: %w", err)
+		}
+		if arg.Len() > 0 {
+			args = append(args, arg.String())
+		}
+	}
+	if len(args) == 0 {
+		return nil, fmt.Errorf("the command rendered to an empty command line")
+	}
+	return args, nil
+}
+
+// startBenchmark records that the benchmark is running, the storage derives the state of the job
+// from the states of its benchmarks
+func (r *Runtime) startBenchmark(logger *slog.Logger, job *jobRun, status api.BenchmarkStatus) {
+	job.lock.Lock()
+	defer job.lock.Unlock()
+	r.updateBenchmark(logger, job, status)
+}
+
+// finishBenchmark records the final state of the benchmark, the job is no longer tracked once its
+// last benchmark has finished
+func (r *Runtime) finishBenchmark(logger *slog.Logger, job *jobRun, status api.BenchmarkStatus, err error) {
+	job.lock.Lock()
+	defer job.lock.Unlock()
+
+	completedAt := time.Now().UTC()
+	status.CompletedAt = &completedAt
+	if status.StartedAt == nil {
+		status.StartedAt = &completedAt
+	}
+	if err != nil {
+		status.State = api.StateFailed
+		status.Message = err.Error()
+	} else {
+		status.State = api.StateCompleted
+		status.Message = "Benchmark completed"
+	}
+	r.updateBenchmark(logger, job, status)
+
+	job.remaining--
+	if job.remaining > 0 {
+		return
+	}
+	job.cancel()
+	r.forget(job)
+}
+
+// forget stops tracking the job
+func (r *Runtime) forget(job *jobRun) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if r.runs[job.evaluation.ID] == job {
+		delete(r.runs, job.evaluation.ID)
+	}
+}
+
+// updateBenchmark must be called with the job lock held
+func (r *Runtime) updateBenchmark(logger *slog.Logger, job *jobRun, status api.BenchmarkStatus) {
+	if job.cancelled {
+		return
+	}
+	if err := job.storage.UpdateBenchmarkStatusForJob(job.evaluation.ID, status); err != nil {
+		logger.Error("Failed to update the benchmark status", "state", status.State, "error", err.Error())
+	}
+}
+
+// jobError describes why the context of the job ended, it ends when the job is cancelled or the
+// runtime is closed
+func jobError(err error) error {
+	return fmt.Errorf("the evaluation job was stopped: %w", err)
+}
+
+func safeFileName(name string) string {
+	if name == "" {
+		return "_"
+	}
+	return unsafeFileNameCharacters.ReplaceAllString(name, "_")
+}
+
+func toJSON(value any) (string, error) {
+	content, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	return string(content), nil
+}
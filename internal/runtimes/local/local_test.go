@@ -0,0 +1,298 @@
+package local
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/abstractions"
+	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/config"
+	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/logging"
+	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/storage/memory"
+	"github.ibm.com/julpayne/eval-hub-backend-svc/pkg/api"
+)
+
+func newTestRuntime(t *testing.T, command ...string) *Runtime {
+	t.Helper()
+	logger, err := logging.NewLogger()
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	runtime, err := NewRuntime(logger, &config.LocalRuntimeConfig{
+		Command:    command,
+		Workers:    2,
+		WorkDir:    t.TempDir(),
+		RetryDelay: time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("NewRuntime() returned error: %v", err)
+	}
+	t.Cleanup(func() { runtime.Close() })
+	return runtime
+}
+
+// runJob stores and runs a job and waits until it is no longer pending or running
+func runJob(t *testing.T, runtime *Runtime, job *api.EvaluationJobResource) *api.EvaluationJobResource {
+	t.Helper()
+	var storage abstractions.Storage = memory.NewStorage()
+	if err := storage.CreateEvaluationJob(job); err != nil {
+		t.Fatalf("CreateEvaluationJob() returned error: %v", err)
+	}
+	if err := runtime.RunEvaluationJob(job, &storage); err != nil {
+		t.Fatalf("RunEvaluationJob() returned error: %v", err)
+	}
+
+	deadline := time.Now().Add(10 * time.Second)
+	for time.Now().Before(deadline) {
+		got, err := storage.GetEvaluationJob(job.ID)
+		if err != nil {
+			t.Fatalf("GetEvaluationJob() returned error: %v", err)
+		}
+		if (got.Status.State != api.StatePending) && (got.Status.State != api.StateRunning) {
+			return got
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("The job %s did not finish in time", job.ID)
+	return nil
+}
+
+func newJob(id string, retries int, benchmarks ...string) *api.EvaluationJobResource {
+	timeout := 1
+	limit := 5
+	job := &api.EvaluationJobResource{
+		Resource: api.Resource{ID: id},
+		EvaluationJobConfig: api.EvaluationJobConfig{
+			Model:          api.ModelRef{URL: "http://model:8000/v1", Name: "granite"},
+			TimeoutMinutes: &timeout,
+			RetryAttempts:  &retries,
+		},
+		Status: api.EvaluationJobStatus{EvaluationJobState: api.EvaluationJobState{State: api.StatePending}},
+	}
+	for _, benchmark := range benchmarks {
+		job.Benchmarks = append(job.Benchmarks, api.BenchmarkConfig{Ref: api.Ref{ID: benchmark}, Limit: &limit})
+		job.Status.Benchmarks = append(job.Status.Benchmarks, api.BenchmarkStatus{Name: benchmark, State: api.StatePending})
+	}
+	return job
+}
+
+func readLog(t *testing.T, status api.BenchmarkStatus) string {
+	t.Helper()
+	if status.Logs == nil {
+		t.Fatalf("Expected the benchmark %s to have a log file", status.Name)
+	}
+	content, err := os.ReadFile(status.Logs.Path)
+	if err != nil {
+		t.Fatalf("Failed to read the log file: %v", err)
+	}
+	return string(content)
+}
+
+func TestRunEvaluationJob(t *testing.T) {
+	t.Run("successful benchmarks complete the job", func(t *testing.T) {
+		runtime := newTestRuntime(t, "sh", "-c", "echo $0 $1 $EVAL_HUB_MODEL_NAME $EVAL_HUB_BENCHMARK_LIMIT",
+			"{{.Benchmark.ID}}", "{{if .Benchmark.Limit}}--limit={{.Benchmark.Limit}}{{end}}")
+
+		job := runJob(t, runtime, newJob("job-1", 0, "mmlu", "arc"))
+
+		if job.Status.State != api.StateCompleted {
+			t.Fatalf("Expected the job to be completed, got %+v", job.Status.EvaluationJobState)
+		}
+		for _, benchmark := range job.Status.Benchmarks {
+			if (benchmark.State != api.StateCompleted) || (benchmark.StartedAt == nil) || (benchmark.CompletedAt == nil) {
+				t.Errorf("Expected a completed benchmark with timestamps, got %+v", benchmark)
+			}
+			expected := benchmark.Name + " --limit=5 granite 5"
+			if log := readLog(t, benchmark); !strings.Contains(log, expected) {
+				t.Errorf("Expected the log to contain %q, got %q", expected, log)
+			}
+		}
+	})
+
+	t.Run("failed benchmarks are retried and then fail the job", func(t *testing.T) {
+		runtime := newTestRuntime(t, "sh", "-c", "echo running; exit 3")
+
+		job := runJob(t, runtime, newJob("job-2", 2, "mmlu"))
+
+		if job.Status.State != api.StateFailed {
+			t.Fatalf("Expected the job to be failed, got %+v", job.Status.EvaluationJobState)
+		}
+		benchmark := job.Status.Benchmarks[0]
+		if (benchmark.State != api.StateFailed) || !strings.Contains(benchmark.Message, "exit status 3") {
+			t.Errorf("Expected the benchmark to fail with the exit status, got %+v", benchmark)
+		}
+		if attempts := strings.Count(readLog(t, benchmark), "failed: exit status 3"); attempts != 3 {
+			t.Errorf("Expected 3 attempts, got %d", attempts)
+		}
+	})
+
+	t.Run("a benchmark that succeeds on retry completes", func(t *testing.T) {
+		marker := t.TempDir() + "/attempted"
+		runtime := newTestRuntime(t, "sh", "-c", "if [ -f "+marker+" ]; then exit 0; fi; touch "+marker+"; exit 1")
+
+		job := runJob(t, runtime, newJob("job-3", 1, "mmlu"))
+
+		if job.Status.State != api.StateCompleted {
+			t.Errorf("Expected the job to be completed, got %+v", job.Status.EvaluationJobState)
+		}
+	})
+
+	t.Run("jobs that time out fail", func(t *testing.T) {
+		runtime := newTestRuntime(t, "true")
+		job := newJob("job-4", 0, "mmlu")
+		job.TimeoutMinutes = new(int)
+
+		got := runJob(t, runtime, job)
+
+		if got.Status.State != api.StateFailed || !strings.Contains(got.Status.Benchmarks[0].Message, "timed out") {
+			t.Errorf("Expected the job to time out, got %+v", got.Status)
+		}
+	})
+
+	t.Run("the time spent in the queue does not count in the timeout", func(t *testing.T) {
+		logger, _ := logging.NewLogger()
+		runtime, err := NewRuntime(logger, &config.LocalRuntimeConfig{Command: []string{"sleep", "0.2"}, Workers: 1, WorkDir: t.TempDir()})
+		if err != nil {
+			t.Fatalf("NewRuntime() returned error: %v", err)
+		}
+		t.Cleanup(func() { runtime.Close() })
+		runtime.timeoutUnit = 500 * time.Millisecond
+
+		// the last benchmark waits for the three others to run, longer than the timeout of an attempt
+		got := runJob(t, runtime, newJob("job-5", 0, "mmlu", "arc", "hellaswag", "gsm8k"))
+
+		if got.Status.State != api.StateCompleted {
+			t.Errorf("Expected the queued benchmarks to complete, got %+v", got.Status)
+		}
+	})
+}
+
+func TestRunEvaluationJobQueueFull(t *testing.T) {
+	logger, _ := logging.NewLogger()
+	runtime, err := NewRuntime(logger, &config.LocalRuntimeConfig{Command: []string{"sleep", "30"}, Workers: 1, QueueSize: 5, WorkDir: t.TempDir()})
+	if err != nil {
+		t.Fatalf("NewRuntime() returned error: %v", err)
+	}
+	t.Cleanup(func() { runtime.Close() })
+	var storage abstractions.Storage = memory.NewStorage()
+	busy := newJob("busy", 0, "mmlu")
+	storage.CreateEvaluationJob(busy)
+	if err := runtime.RunEvaluationJob(busy, &storage); err != nil {
+		t.Fatalf("RunEvaluationJob() returned error: %v", err)
+	}
+	for len(runtime.queue) > 0 {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// the jobs are submitted concurrently, a job that does not fit is rejected without queuing any
+	// of its benchmarks
+	var wg sync.WaitGroup
+	var lock sync.Mutex
+	rejected := []string{}
+	for i := range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			job := newJob(fmt.Sprintf("job-%d", i), 0, "mmlu", "arc")
+			if err := runtime.RunEvaluationJob(job, &storage); errors.Is(err, ErrQueueFull) {
+				lock.Lock()
+				rejected = append(rejected, job.ID)
+				lock.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if (len(rejected) != 8) || (len(runtime.queue) != 4) {
+		t.Fatalf("Expected 2 jobs to be queued and 8 to be rejected, got %d rejected and %d queued tasks", len(rejected), len(runtime.queue))
+	}
+	runtime.lock.Lock()
+	defer runtime.lock.Unlock()
+	for _, id := range rejected {
+		if runtime.runs[id] != nil {
+			t.Errorf("Expected the rejected job %s not to be tracked", id)
+		}
+	}
+}
+
+func TestClose(t *testing.T) {
+	runtime := newTestRuntime(t, "sleep", "30")
+	var storage abstractions.Storage = memory.NewStorage()
+	job := newJob("job-1", 3, "mmlu")
+	storage.CreateEvaluationJob(job)
+	if err := runtime.RunEvaluationJob(job, &storage); err != nil {
+		t.Fatalf("RunEvaluationJob() returned error: %v", err)
+	}
+	for {
+		got, _ := storage.GetEvaluationJob("job-1")
+		if got.Status.State == api.StateRunning {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	started := time.Now()
+	runtime.Close()
+
+	if time.Since(started) > 5*time.Second {
+		t.Errorf("Expected the process to be killed, closing took %v", time.Since(started))
+	}
+	got, _ := storage.GetEvaluationJob("job-1")
+	if got.Status.State != api.StateFailed {
+		t.Errorf("Expected the job to fail when the runtime is closed, got %+v", got.Status)
+	}
+}
+
+func TestNewRuntime(t *testing.T) {
+	logger, _ := logging.NewLogger()
+	if _, err := NewRuntime(logger, &config.LocalRuntimeConfig{}); err == nil {
+		t.Error("Expected an error without a command")
+	}
+	if _, err := NewRuntime(logger, &config.LocalRuntimeConfig{Command: []string{"{{.Model"}}); err == nil {
+		t.Error("Expected an error for an invalid template")
+	}
+	runtime, err := NewRuntime(logger, &config.LocalRuntimeConfig{Command: []string{"true"}, QueueSize: 3})
+	if err != nil {
+		t.Fatalf("NewRuntime() returned error: %v", err)
+	}
+	defer runtime.Close()
+	if max := runtime.MaxBenchmarks(); max != 3 {
+		t.Errorf("Expected the jobs to be limited to the queue size, got %d", max)
+	}
+}
+
+func TestCancelEvaluationJob(t *testing.T) {
+	runtime := newTestRuntime(t, "sleep", "30")
+	var storage abstractions.Storage = memory.NewStorage()
+	job := newJob("job-1", 3, "mmlu")
+	storage.CreateEvaluationJob(job)
+	if err := runtime.RunEvaluationJob(job, &storage); err != nil {
+		t.Fatalf("RunEvaluationJob() returned error: %v", err)
+	}
+	for {
+		got, _ := storage.GetEvaluationJob("job-1")
+		if got.Status.State == api.StateRunning {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if err := runtime.CancelEvaluationJob(job, &storage); err != nil {
+		t.Fatalf("CancelEvaluationJob() returned error: %v", err)
+	}
+	storage.UpdateEvaluationJobStatus("job-1", api.EvaluationJobState{State: api.StateCancelled})
+
+	// closing waits for the killed process, the runtime must not have overwritten the state
+	runtime.Close()
+	got, _ := storage.GetEvaluationJob("job-1")
+	if got.Status.State != api.StateCancelled || got.Status.Benchmarks[0].State != api.StateRunning {
+		t.Errorf("Expected the runtime to stop updating the job, got %+v", got.Status)
+	}
+	if err := runtime.CancelEvaluationJob(newJob("unknown", 0, "mmlu"), &storage); err != nil {
+		t.Errorf("Expected unknown jobs to be ignored, got %v", err)
+	}
+}
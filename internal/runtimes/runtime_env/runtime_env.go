@@ -0,0 +1,46 @@
+package runtime_env
+
+import (
+	"encoding/json"
+	"strconv"
+
+	"github.ibm.com/julpayne/eval-hub-backend-svc/pkg/api"
+)
+
+// The environment variables that describe a benchmark run to the process that runs it, every
+// runtime sets the same variables so that the evaluation images do not depend on the runtime.
+const (
+	EnvJobID               = "EVAL_HUB_JOB_ID"
+	EnvBenchmarkID         = "EVAL_HUB_BENCHMARK_ID"
+	EnvModelURL            = "EVAL_HUB_MODEL_URL"
+	EnvModelName           = "EVAL_HUB_MODEL_NAME"
+	EnvBenchmarkLimit      = "EVAL_HUB_BENCHMARK_LIMIT"
+	EnvBenchmarkParameters = "EVAL_HUB_BENCHMARK_PARAMETERS"
+	EnvExperimentName      = "EVAL_HUB_EXPERIMENT_NAME"
+	EnvOutputDir           = "EVAL_HUB_OUTPUT_DIR"
+)
+
+// BenchmarkEnv returns the environment variables for a benchmark of an evaluation job, the
+// parameters are passed as a JSON object and optional values are omitted when they are not set.
+func BenchmarkEnv(evaluation *api.EvaluationJobResource, benchmark *api.BenchmarkConfig) (map[string]string, error) {
+	env := map[string]string{
+		EnvJobID:       evaluation.ID,
+		EnvBenchmarkID: benchmark.ID,
+		EnvModelURL:    evaluation.Model.URL,
+		EnvModelName:   evaluation.Model.Name,
+	}
+	if benchmark.Limit != nil {
+		env[EnvBenchmarkLimit] = strconv.Itoa(*benchmark.Limit)
+	}
+	if len(benchmark.Parameters) > 0 {
+		parameters, err := json.Marshal(benchmark.Parameters)
+		if err != nil {
+			return nil, err
+		}
+		env[EnvBenchmarkParameters] = string(parameters)
+	}
+	if evaluation.Experiment.Name != "" {
+		env[EnvExperimentName] = evaluation.Experiment.Name
+	}
+	return env, nil
+}
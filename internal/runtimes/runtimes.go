@@ -0,0 +1,38 @@
+package runtimes
+
+import (
+	"fmt"
+	"log/slog"
+
+	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/abstractions"
+	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/config"
+	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/runtimes/k8s"
+	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/runtimes/local"
+)
+
+// NewRuntime creates the runtime selected by the type in the runtime configuration, no runtime
+// is created when no type is configured and the evaluation jobs then stay pending. The storage is
+// used by the runtimes that resume tracking the jobs started before a restart.
+func NewRuntime(logger *slog.Logger, runtimeConfig *config.RuntimeConfig, storage abstractions.Storage) (abstractions.Runtime, error) {
+	if (runtimeConfig == nil) || (runtimeConfig.Type == "") {
+		logger.Warn("No runtime is configured, evaluation jobs will not be run")
+		return nil, nil
+	}
+
+	switch runtimeConfig.Type {
+	case config.RuntimeTypeLocal:
+		runtime, err := local.NewRuntime(logger, runtimeConfig.Local)
+		if err != nil {
+			return nil, err
+		}
+		return runtime, nil
+	case config.RuntimeTypeKubernetes:
+		runtime, err := k8s.NewRuntime(logger, runtimeConfig.Kubernetes, storage)
+		if err != nil {
+			return nil, err
+		}
+		return runtime, nil
+	default:
+		return nil, fmt.Errorf("unsupported runtime type %q", runtimeConfig.Type)
+	}
+}
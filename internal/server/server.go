@@ -8,10 +8,19 @@ import (
 	"strings"
 	"time"
 
+	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/abstractions"
+	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/apierrors"
+	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/auth"
 	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/config"
 	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/execution_context"
 	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/handlers"
+	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/logging"
 	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/metrics"
+	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/mlflow"
+	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/rbac"
+	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/registry"
+	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/tenancy"
+	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/validation"
 
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
@@ -21,51 +30,120 @@ type Server struct {
 	port          int
 	logger        *slog.Logger
 	serviceConfig *config.Config
+	storage       abstractions.Storage
+	runtime       abstractions.Runtime
+	tracker       abstractions.ExperimentTracker
+	registry      *registry.Registry
+	authenticator *auth.Authenticator
+	authorizer    *rbac.Authorizer
+	validator     *validation.Validator
 }
 
-func NewServer(logger *slog.Logger, serviceConfig *config.Config) (*Server, error) {
+// NewServer creates the server, the runtime is optional and evaluation jobs stay pending without one.
+// Without a registry there are no providers and benchmarks.
+func NewServer(logger *slog.Logger, serviceConfig *config.Config, storage abstractions.Storage, runtime abstractions.Runtime, providers *registry.Registry) (*Server, error) {
 	if logger == nil {
 		return nil, fmt.Errorf("logger is required for the server")
 	}
 	if (serviceConfig == nil) || (serviceConfig.Service == nil) {
 		return nil, fmt.Errorf("service config is required for the server")
 	}
+	if storage == nil {
+		return nil, fmt.Errorf("storage is required for the server")
+	}
+
+	tracker, err := mlflow.NewTracker(logger, serviceConfig.MLflow)
+	if err != nil {
+		return nil, err
+	}
+	authenticator, err := auth.NewAuthenticator(logger, serviceConfig.Auth, serviceConfig.Runners)
+	if err != nil {
+		return nil, err
+	}
+	authorizer, err := rbac.NewAuthorizer(serviceConfig.Auth)
+	if err != nil {
+		return nil, err
+	}
+	validator, err := validation.NewValidator(logger, serviceConfig.Validation)
+	if err != nil {
+		return nil, err
+	}
+	if providers == nil {
+		if providers, err = registry.NewRegistry(logger, nil); err != nil {
+			return nil, err
+		}
+	}
 
 	return &Server{
 		port:          serviceConfig.Service.Port,
 		logger:        logger,
 		serviceConfig: serviceConfig,
+		storage:       storage,
+		runtime:       runtime,
+		tracker:       tracker,
+		registry:      providers,
+		authenticator: authenticator,
+		authorizer:    authorizer,
+		validator:     validator,
 	}, nil
 }
 
+// newExecutionContext creates the execution context of a request with the services of the server
+func (s *Server) newExecutionContext(r *http.Request) *execution_context.ExecutionContext {
+	ctx := execution_context.NewExecutionContext(r, s.logger, s.serviceConfig)
+	ctx.MLflowClient = s.tracker
+	return ctx
+}
+
 func (s *Server) setupRoutes() (http.Handler, error) {
 	router := http.NewServeMux()
-	h := handlers.New()
+	h := handlers.New(s.storage, s.runtime, s.registry)
 
 	// Health and status endpoints
 	router.HandleFunc("/api/v1/health", h.HandleHealth)
 	router.HandleFunc("/api/v1/status", h.HandleStatus)
 
+	// The evaluation endpoints are scoped to the tenant of the request, every route declares the
+	// permission each of its methods requires
+	evaluations := http.NewServeMux()
+	router.Handle("/api/v1/evaluations/", tenancy.Middleware(s.serviceConfig.Tenancy, evaluations))
+	readOnly := rbac.Permissions{http.MethodGet: rbac.PermissionRead}
+
 	// Evaluation jobs endpoints
-	router.HandleFunc("/api/v1/evaluations/jobs", func(w http.ResponseWriter, r *http.Request) {
-		ctx := execution_context.NewExecutionContext(r, s.logger, s.serviceConfig)
+	evaluations.HandleFunc("/api/v1/evaluations/jobs", rbac.Require(rbac.Permissions{
+		http.MethodGet:  rbac.PermissionRead,
+		http.MethodPost: rbac.PermissionSubmit,
+	}, func(w http.ResponseWriter, r *http.Request) {
+		ctx := s.newExecutionContext(r)
 		switch r.Method {
 		case http.MethodPost:
 			h.HandleCreateEvaluation(ctx, w, r)
 		case http.MethodGet:
 			h.HandleListEvaluations(ctx, w, r)
 		default:
-			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			apierrors.Write(w, r, apierrors.MethodNotAllowed(r.Method))
 		}
-	})
+	}))
 	// Handle summary endpoint first (more specific)
-	router.HandleFunc("/api/v1/evaluations/jobs/", func(w http.ResponseWriter, r *http.Request) {
-		ctx := execution_context.NewExecutionContext(r, s.logger, s.serviceConfig)
+	evaluations.HandleFunc("/api/v1/evaluations/jobs/", rbac.Require(rbac.Permissions{
+		http.MethodGet:    rbac.PermissionRead,
+		http.MethodDelete: rbac.PermissionCancel,
+		http.MethodPost:   rbac.PermissionReportResults,
+	}, func(w http.ResponseWriter, r *http.Request) {
+		ctx := s.newExecutionContext(r)
 		path := r.URL.Path
 		if strings.HasSuffix(path, "/summary") && r.Method == http.MethodGet {
 			h.HandleGetEvaluationSummary(ctx, w, r)
 			return
 		}
+		if strings.Contains(path, "/benchmarks/") && strings.HasSuffix(strings.TrimSuffix(path, "/"), "/results") {
+			h.HandleCreateBenchmarkResult(ctx, w, r)
+			return
+		}
+		if strings.HasSuffix(strings.TrimSuffix(path, "/"), "/callbacks") {
+			h.HandleListEvaluationCallbacks(ctx, w, r)
+			return
+		}
 		// Handle individual job endpoints
 		switch r.Method {
 		case http.MethodGet:
@@ -73,30 +151,56 @@ func (s *Server) setupRoutes() (http.Handler, error) {
 		case http.MethodDelete:
 			h.HandleCancelEvaluation(ctx, w, r)
 		default:
-			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			apierrors.Write(w, r, apierrors.MethodNotAllowed(r.Method))
 		}
-	})
+	}))
+
+	// Comparison endpoint
+	evaluations.HandleFunc("/api/v1/evaluations/compare", rbac.Require(readOnly, func(w http.ResponseWriter, r *http.Request) {
+		ctx := s.newExecutionContext(r)
+		h.HandleCompareEvaluations(ctx, w, r)
+	}))
+
+	// Leaderboard endpoint
+	evaluations.HandleFunc("/api/v1/evaluations/leaderboard", rbac.Require(readOnly, func(w http.ResponseWriter, r *http.Request) {
+		ctx := s.newExecutionContext(r)
+		h.HandleGetLeaderboard(ctx, w, r)
+	}))
+
+	// Callback deliveries endpoint
+	evaluations.HandleFunc("/api/v1/evaluations/callbacks", rbac.Require(readOnly, func(w http.ResponseWriter, r *http.Request) {
+		ctx := s.newExecutionContext(r)
+		h.HandleListCallbacks(ctx, w, r)
+	}))
 
 	// Benchmarks endpoint
-	router.HandleFunc("/api/v1/evaluations/benchmarks", func(w http.ResponseWriter, r *http.Request) {
-		ctx := execution_context.NewExecutionContext(r, s.logger, s.serviceConfig)
+	evaluations.HandleFunc("/api/v1/evaluations/benchmarks", rbac.Require(readOnly, func(w http.ResponseWriter, r *http.Request) {
+		ctx := s.newExecutionContext(r)
 		h.HandleListBenchmarks(ctx, w, r)
-	})
+	}))
 
 	// Collections endpoints
-	router.HandleFunc("/api/v1/evaluations/collections", func(w http.ResponseWriter, r *http.Request) {
-		ctx := execution_context.NewExecutionContext(r, s.logger, s.serviceConfig)
+	evaluations.HandleFunc("/api/v1/evaluations/collections", rbac.Require(rbac.Permissions{
+		http.MethodGet:  rbac.PermissionRead,
+		http.MethodPost: rbac.PermissionManageCollections,
+	}, func(w http.ResponseWriter, r *http.Request) {
+		ctx := s.newExecutionContext(r)
 		switch r.Method {
 		case http.MethodPost:
 			h.HandleCreateCollection(ctx, w, r)
 		case http.MethodGet:
 			h.HandleListCollections(ctx, w, r)
 		default:
-			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			apierrors.Write(w, r, apierrors.MethodNotAllowed(r.Method))
 		}
-	})
-	router.HandleFunc("/api/v1/evaluations/collections/", func(w http.ResponseWriter, r *http.Request) {
-		ctx := execution_context.NewExecutionContext(r, s.logger, s.serviceConfig)
+	}))
+	evaluations.HandleFunc("/api/v1/evaluations/collections/", rbac.Require(rbac.Permissions{
+		http.MethodGet:    rbac.PermissionRead,
+		http.MethodPut:    rbac.PermissionManageCollections,
+		http.MethodPatch:  rbac.PermissionManageCollections,
+		http.MethodDelete: rbac.PermissionManageCollections,
+	}, func(w http.ResponseWriter, r *http.Request) {
+		ctx := s.newExecutionContext(r)
 		switch r.Method {
 		case http.MethodGet:
 			h.HandleGetCollection(ctx, w, r)
@@ -107,25 +211,25 @@ func (s *Server) setupRoutes() (http.Handler, error) {
 		case http.MethodDelete:
 			h.HandleDeleteCollection(ctx, w, r)
 		default:
-			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			apierrors.Write(w, r, apierrors.MethodNotAllowed(r.Method))
 		}
-	})
+	}))
 
 	// Providers endpoints
-	router.HandleFunc("/api/v1/evaluations/providers", func(w http.ResponseWriter, r *http.Request) {
-		ctx := execution_context.NewExecutionContext(r, s.logger, s.serviceConfig)
+	evaluations.HandleFunc("/api/v1/evaluations/providers", rbac.Require(readOnly, func(w http.ResponseWriter, r *http.Request) {
+		ctx := s.newExecutionContext(r)
 		h.HandleListProviders(ctx, w, r)
-	})
-	router.HandleFunc("/api/v1/evaluations/providers/", func(w http.ResponseWriter, r *http.Request) {
-		ctx := execution_context.NewExecutionContext(r, s.logger, s.serviceConfig)
+	}))
+	evaluations.HandleFunc("/api/v1/evaluations/providers/", rbac.Require(readOnly, func(w http.ResponseWriter, r *http.Request) {
+		ctx := s.newExecutionContext(r)
 		h.HandleGetProvider(ctx, w, r)
-	})
+	}))
 
 	// System metrics endpoint
-	router.HandleFunc("/api/v1/metrics/system", func(w http.ResponseWriter, r *http.Request) {
-		ctx := execution_context.NewExecutionContext(r, s.logger, s.serviceConfig)
+	router.HandleFunc("/api/v1/metrics/system", rbac.Require(readOnly, func(w http.ResponseWriter, r *http.Request) {
+		ctx := s.newExecutionContext(r)
 		h.HandleGetSystemMetrics(ctx, w, r)
-	})
+	}))
 
 	// OpenAPI documentation endpoints
 	router.HandleFunc("/openapi.yaml", h.HandleOpenAPI)
@@ -134,8 +238,10 @@ func (s *Server) setupRoutes() (http.Handler, error) {
 	// Prometheus metrics endpoint
 	router.Handle("/metrics", promhttp.Handler())
 
-	// Wrap router with metrics middleware
-	return metrics.Middleware(router), nil
+	// Wrap router with the request ID, the metrics, the authentication, the authorization and the
+	// validation middlewares, the requests are authenticated before their roles and their tenant are
+	// resolved from the claims of their principal and only the authenticated requests are validated
+	return logging.RequestIDMiddleware(metrics.Middleware(s.authenticator.Middleware(s.authorizer.Middleware(s.validator.Middleware(router))))), nil
 }
 
 // SetupRoutes exposes the route setup for testing
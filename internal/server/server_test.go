@@ -2,14 +2,23 @@ package server
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
 	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/config"
 	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/logging"
+	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/registry"
+	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/storage/memory"
+	"github.ibm.com/julpayne/eval-hub-backend-svc/pkg/api"
 )
 
 func TestNewServer(t *testing.T) {
@@ -70,23 +79,29 @@ func TestServerSetupRoutes(t *testing.T) {
 		{http.MethodGet, "/openapi.yaml", http.StatusOK},
 		{http.MethodGet, "/docs", http.StatusOK},
 		// Evaluation endpoints
-		{http.MethodPost, "/api/v1/evaluations/jobs", http.StatusAccepted},
+		{http.MethodPost, "/api/v1/evaluations/jobs", http.StatusBadRequest},
 		{http.MethodGet, "/api/v1/evaluations/jobs", http.StatusOK},
-		{http.MethodGet, "/api/v1/evaluations/jobs/test-id", http.StatusOK},
-		{http.MethodDelete, "/api/v1/evaluations/jobs/test-id", http.StatusOK},
-		{http.MethodGet, "/api/v1/evaluations/jobs/test-id/summary", http.StatusOK},
+		{http.MethodGet, "/api/v1/evaluations/jobs/test-id", http.StatusNotFound},
+		{http.MethodDelete, "/api/v1/evaluations/jobs/test-id", http.StatusNotFound},
+		{http.MethodGet, "/api/v1/evaluations/jobs/test-id/summary", http.StatusNotFound},
+		{http.MethodPost, "/api/v1/evaluations/jobs/test-id/benchmarks/mmlu/results", http.StatusUnauthorized},
+		{http.MethodGet, "/api/v1/evaluations/jobs/test-id/benchmarks/mmlu/results", http.StatusMethodNotAllowed},
+		{http.MethodGet, "/api/v1/evaluations/compare", http.StatusBadRequest},
+		{http.MethodGet, "/api/v1/evaluations/compare?jobs=a,b", http.StatusNotFound},
+		{http.MethodGet, "/api/v1/evaluations/leaderboard", http.StatusBadRequest},
+		{http.MethodGet, "/api/v1/evaluations/leaderboard?benchmark=mmlu&metric=acc", http.StatusOK},
 		// Benchmarks
 		{http.MethodGet, "/api/v1/evaluations/benchmarks", http.StatusOK},
 		// Collections
 		{http.MethodGet, "/api/v1/evaluations/collections", http.StatusOK},
-		{http.MethodPost, "/api/v1/evaluations/collections", http.StatusCreated},
-		{http.MethodGet, "/api/v1/evaluations/collections/test-collection", http.StatusOK},
-		{http.MethodPut, "/api/v1/evaluations/collections/test-collection", http.StatusOK},
-		{http.MethodPatch, "/api/v1/evaluations/collections/test-collection", http.StatusOK},
-		{http.MethodDelete, "/api/v1/evaluations/collections/test-collection", http.StatusOK},
+		{http.MethodPost, "/api/v1/evaluations/collections", http.StatusBadRequest},
+		{http.MethodGet, "/api/v1/evaluations/collections/test-collection", http.StatusNotFound},
+		{http.MethodPut, "/api/v1/evaluations/collections/test-collection", http.StatusBadRequest},
+		{http.MethodPatch, "/api/v1/evaluations/collections/test-collection", http.StatusBadRequest},
+		{http.MethodDelete, "/api/v1/evaluations/collections/test-collection", http.StatusNotFound},
 		// Providers
 		{http.MethodGet, "/api/v1/evaluations/providers", http.StatusOK},
-		{http.MethodGet, "/api/v1/evaluations/providers/test-provider", http.StatusOK},
+		{http.MethodGet, "/api/v1/evaluations/providers/test-provider", http.StatusNotFound},
 		// System metrics
 		{http.MethodGet, "/api/v1/metrics/system", http.StatusOK},
 		// Error cases
@@ -108,6 +123,156 @@ func TestServerSetupRoutes(t *testing.T) {
 	}
 }
 
+func TestServerErrorResponses(t *testing.T) {
+	srv, err := createServer(8080)
+	if err != nil {
+		t.Fatalf("NewServer() returned error: %v", err)
+	}
+	handler, err := srv.setupRoutes()
+	if err != nil {
+		t.Fatalf("setupRoutes() returned error: %v", err)
+	}
+
+	t.Run("the errors are JSON with the ID of the request", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPut, "/api/v1/evaluations/jobs", nil)
+		req.Header.Set(logging.RequestIDHeader, "req-42")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		var response api.Error
+		if err := json.Unmarshal(w.Body.Bytes(), &response); (err != nil) || (w.Code != http.StatusMethodNotAllowed) {
+			t.Fatalf("Expected a JSON 405, got %d: %s", w.Code, w.Body.String())
+		}
+		if (response.Code != "method_not_allowed") || (response.RequestID != "req-42") || (w.Header().Get(logging.RequestIDHeader) != "req-42") {
+			t.Errorf("Expected the code and the ID of the request, got %s", w.Body.String())
+		}
+	})
+
+	t.Run("a request ID is generated", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/v1/health", nil))
+		var response api.Error
+		json.Unmarshal(w.Body.Bytes(), &response)
+		if (response.RequestID == "") || (response.RequestID != w.Header().Get(logging.RequestIDHeader)) {
+			t.Errorf("Expected the generated request ID in the response, got %q and %q", response.RequestID, w.Header().Get(logging.RequestIDHeader))
+		}
+	})
+
+	t.Run("problem details are returned when accepted", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/evaluations/jobs", strings.NewReader(`{"model": {"url": "http://m"}, "benchmarks": [{"id": "a"}]}`))
+		req.Header.Set("Accept", "application/problem+json")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		var problem api.ProblemError
+		json.Unmarshal(w.Body.Bytes(), &problem)
+		if (w.Code != http.StatusUnprocessableEntity) || (w.Header().Get("Content-Type") != "application/problem+json") {
+			t.Fatalf("Expected a problem details 422, got %d %s", w.Code, w.Header().Get("Content-Type"))
+		}
+		if (problem.Code != "validation_error") || (len(problem.Errors) != 1) || (fmt.Sprint(problem.Errors[0].Loc) != "[body model name]") {
+			t.Errorf("Expected the location of the invalid field, got %s", w.Body.String())
+		}
+	})
+}
+
+func TestServerTenancy(t *testing.T) {
+	logger, err := logging.NewLogger()
+	if err != nil {
+		t.Fatalf("NewLogger() returned error: %v", err)
+	}
+	serviceConfig := &config.Config{Service: &config.ServiceConfig{Port: 8080}, Tenancy: &config.TenancyConfig{Header: "X-Org", Required: true}}
+	srv, err := NewServer(logger, serviceConfig, memory.NewStorage(), nil, nil)
+	if err != nil {
+		t.Fatalf("NewServer() returned error: %v", err)
+	}
+	handler, err := srv.setupRoutes()
+	if err != nil {
+		t.Fatalf("setupRoutes() returned error: %v", err)
+	}
+
+	testCases := []struct {
+		path   string
+		tenant string
+		status int
+	}{
+		{"/api/v1/evaluations/jobs", "acme", http.StatusOK},
+		{"/api/v1/evaluations/jobs", "", http.StatusBadRequest},
+		{"/api/v1/evaluations/collections", "not a tenant", http.StatusBadRequest},
+		// the routes outside of the evaluations are not scoped
+		{"/api/v1/health", "", http.StatusOK},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.path+" "+tc.tenant, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+			if tc.tenant != "" {
+				req.Header.Set("X-Org", tc.tenant)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+			if w.Code != tc.status {
+				t.Errorf("Expected status %d, got %d", tc.status, w.Code)
+			}
+		})
+	}
+}
+
+func TestServerAuthenticationAndAuthorization(t *testing.T) {
+	logger, err := logging.NewLogger()
+	if err != nil {
+		t.Fatalf("NewLogger() returned error: %v", err)
+	}
+	hash := sha256.Sum256([]byte("acme-key"))
+	serviceConfig := &config.Config{
+		Service: &config.ServiceConfig{Port: 8080},
+		Tenancy: &config.TenancyConfig{Claim: "tenant"},
+		Auth: &config.AuthConfig{
+			Enabled: true,
+			Exempt:  []string{"/api/v1/health"},
+			APIKeys: []config.APIKeyConfig{{Name: "ci", SHA256: hex.EncodeToString(hash[:]), Claims: map[string]any{"tenant": "acme", "roles": []any{"viewer"}}}},
+		},
+	}
+	storage := memory.NewStorage()
+	storage.WithTenant("other").CreateCollection(&api.CollectionResource{Resource: api.Resource{ID: "col-1"}})
+	srv, err := NewServer(logger, serviceConfig, storage, nil, nil)
+	if err != nil {
+		t.Fatalf("NewServer() returned error: %v", err)
+	}
+	handler, err := srv.setupRoutes()
+	if err != nil {
+		t.Fatalf("setupRoutes() returned error: %v", err)
+	}
+
+	testCases := []struct {
+		name    string
+		method  string
+		path    string
+		headers map[string]string
+		status  int
+	}{
+		{"exempt route", http.MethodGet, "/api/v1/health", nil, http.StatusOK},
+		{"anonymous request", http.MethodGet, "/api/v1/evaluations/jobs", nil, http.StatusUnauthorized},
+		{"API key", http.MethodGet, "/api/v1/evaluations/jobs", map[string]string{"X-API-Key": "acme-key"}, http.StatusOK},
+		// the tenant of the API key takes precedence over the header
+		{"resource of another tenant", http.MethodGet, "/api/v1/evaluations/collections/col-1", map[string]string{"X-API-Key": "acme-key", "X-Tenant": "other"}, http.StatusNotFound},
+		// a viewer can not submit jobs or manage collections
+		{"permission not granted", http.MethodPost, "/api/v1/evaluations/jobs", map[string]string{"X-API-Key": "acme-key"}, http.StatusForbidden},
+		{"collection change", http.MethodDelete, "/api/v1/evaluations/collections/col-1", map[string]string{"X-API-Key": "acme-key"}, http.StatusForbidden},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, tc.path, nil)
+			for name, value := range tc.headers {
+				req.Header.Set(name, value)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+			if w.Code != tc.status {
+				t.Errorf("Expected status %d, got %d", tc.status, w.Code)
+			}
+		})
+	}
+}
+
 func TestServerShutdown(t *testing.T) {
 	t.Run("shutdown returns nil when server is nil", func(t *testing.T) {
 		srv := &Server{
@@ -164,5 +329,102 @@ func createServer(port int) (*Server, error) {
 	if err != nil {
 		return nil, err
 	}
-	return NewServer(logger, &config.Config{Service: &config.ServiceConfig{Port: port}})
+	return NewServer(logger, &config.Config{Service: &config.ServiceConfig{Port: port}}, memory.NewStorage(), nil, nil)
+}
+
+func TestServerValidation(t *testing.T) {
+	logger, err := logging.NewLogger()
+	if err != nil {
+		t.Fatalf("NewLogger() returned error: %v", err)
+	}
+	// in strict mode the responses that do not match the OpenAPI document fail with 500
+	serviceConfig := &config.Config{
+		Service:    &config.ServiceConfig{Port: 8080},
+		Runners:    &config.RunnersConfig{Token: "runner-token"},
+		Validation: &config.ValidationConfig{Enabled: true, SpecFile: "../../api/openapi.yaml", Strict: true},
+	}
+	dir := t.TempDir()
+	catalog := "providers:\n  - id: lm_evaluation_harness\nbenchmarks:\n  - id: mmlu\n    provider_id: lm_evaluation_harness\n  - id: arc\n    provider_id: lm_evaluation_harness\n"
+	if err := os.WriteFile(filepath.Join(dir, "registry.yaml"), []byte(catalog), 0o644); err != nil {
+		t.Fatalf("Failed to write the registry: %v", err)
+	}
+	providers, err := registry.NewRegistry(logger, &config.RegistryConfig{Dir: dir})
+	if err != nil {
+		t.Fatalf("NewRegistry() returned error: %v", err)
+	}
+	srv, err := NewServer(logger, serviceConfig, memory.NewStorage(), nil, providers)
+	if err != nil {
+		t.Fatalf("NewServer() returned error: %v", err)
+	}
+	handler, err := srv.setupRoutes()
+	if err != nil {
+		t.Fatalf("setupRoutes() returned error: %v", err)
+	}
+	call := func(t *testing.T, method string, path string, body string) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer runner-token")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	job := `{"model": {"url": "http://model:8000/v1", "name": "granite"}, "benchmarks": [{"id": "mmlu", "limit": 5}, {"id": "arc"}], "experiment": {"name": "exp"}}`
+	w := call(t, http.MethodPost, "/api/v1/evaluations/jobs", job)
+	var created api.EvaluationJobResource
+	if err := json.Unmarshal(w.Body.Bytes(), &created); (err != nil) || (w.Code != http.StatusAccepted) {
+		t.Fatalf("Expected the job to be created, got %d: %s", w.Code, w.Body.String())
+	}
+	w = call(t, http.MethodPost, "/api/v1/evaluations/jobs", job)
+	var other api.EvaluationJobResource
+	json.Unmarshal(w.Body.Bytes(), &other)
+	w = call(t, http.MethodPost, "/api/v1/evaluations/collections", `{"name": "knowledge", "benchmarks": ["mmlu"]}`)
+	var collection api.CollectionResource
+	if err := json.Unmarshal(w.Body.Bytes(), &collection); (err != nil) || (w.Code != http.StatusCreated) {
+		t.Fatalf("Expected the collection to be created, got %d: %s", w.Code, w.Body.String())
+	}
+	jobPath := "/api/v1/evaluations/jobs/" + created.ID
+	collectionPath := "/api/v1/evaluations/collections/" + collection.ID
+
+	testCases := []struct {
+		method string
+		path   string
+		body   string
+		status int
+	}{
+		{http.MethodGet, "/api/v1/health", "", http.StatusOK},
+		{http.MethodGet, "/api/v1/status", "", http.StatusOK},
+		{http.MethodGet, "/api/v1/metrics/system", "", http.StatusOK},
+		{http.MethodGet, "/api/v1/evaluations/jobs?limit=10&state=pending", "", http.StatusOK},
+		{http.MethodGet, jobPath, "", http.StatusOK},
+		{http.MethodGet, jobPath + "/summary", "", http.StatusOK},
+		{http.MethodGet, jobPath + "/callbacks", "", http.StatusOK},
+		{http.MethodPost, jobPath + "/benchmarks/mmlu/results", `{"attempt_id": "a1", "state": "completed", "metrics": {"acc": 0.8}}`, http.StatusCreated},
+		{http.MethodGet, "/api/v1/evaluations/compare?jobs=" + created.ID + "," + other.ID, "", http.StatusOK},
+		{http.MethodGet, "/api/v1/evaluations/leaderboard?benchmark=mmlu&metric=acc", "", http.StatusOK},
+		{http.MethodGet, "/api/v1/evaluations/callbacks", "", http.StatusOK},
+		{http.MethodGet, "/api/v1/evaluations/benchmarks", "", http.StatusOK},
+		{http.MethodGet, "/api/v1/evaluations/providers", "", http.StatusOK},
+		{http.MethodGet, "/api/v1/evaluations/providers/lm_evaluation_harness", "", http.StatusOK},
+		{http.MethodGet, "/api/v1/evaluations/collections", "", http.StatusOK},
+		{http.MethodGet, collectionPath, "", http.StatusOK},
+		{http.MethodPut, collectionPath, `{"name": "knowledge", "benchmarks": ["mmlu", "arc"]}`, http.StatusOK},
+		{http.MethodPatch, collectionPath, `[{"op": "replace", "path": "/description", "value": "knowledge benchmarks"}]`, http.StatusOK},
+		{http.MethodDelete, collectionPath, "", http.StatusNoContent},
+		{http.MethodDelete, jobPath, "", http.StatusOK},
+		{http.MethodGet, "/api/v1/evaluations/jobs/unknown", "", http.StatusNotFound},
+		// the requests that do not match the document are rejected before the handlers
+		{http.MethodGet, "/api/v1/evaluations/jobs?limit=0", "", http.StatusUnprocessableEntity},
+		{http.MethodPost, "/api/v1/evaluations/jobs", `{"model": {"url": "http://m", "name": "m"}, "benchmarks": [{"id": "mmlu", "limit": "5"}], "extra": true}`, http.StatusUnprocessableEntity},
+		// the malformed bodies are left to the handlers
+		{http.MethodPost, "/api/v1/evaluations/jobs", `{`, http.StatusBadRequest},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.method+" "+tc.path, func(t *testing.T) {
+			if w := call(t, tc.method, tc.path, tc.body); w.Code != tc.status {
+				t.Errorf("Expected status %d, got %d: %s", tc.status, w.Code, w.Body.String())
+			}
+		})
+	}
 }
@@ -0,0 +1,199 @@
+package state_machine
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.ibm.com/julpayne/eval-hub-backend-svc/pkg/api"
+)
+
+// The transitions allowed for evaluation jobs and benchmarks, a state can always move to itself
+// so that the message can be updated. Completed, failed and cancelled are final.
+var (
+	jobTransitions = map[api.State][]api.State{
+		api.StatePending: {api.StateRunning, api.StateCompleted, api.StateFailed, api.StateCancelled},
+		api.StateRunning: {api.StateCompleted, api.StateFailed, api.StateCancelled},
+	}
+	benchmarkTransitions = map[api.State][]api.State{
+		api.StatePending: {api.StateRunning, api.StateCompleted, api.StateFailed, api.StateCancelled},
+		api.StateRunning: {api.StateCompleted, api.StateFailed, api.StateCancelled},
+	}
+)
+
+const (
+	subjectJob       = "evaluation job"
+	subjectBenchmark = "benchmark"
+)
+
+// TransitionError is returned when a job or a benchmark is moved to a state that it can not reach
+// from its current state
+type TransitionError struct {
+	// Subject is what is being moved, the evaluation job or one of its benchmarks
+	Subject string
+	ID      string
+	From    api.State
+	To      api.State
+}
+
+func (e *TransitionError) Error() string {
+	return fmt.Sprintf("%s %s can not move from %s to %s", e.Subject, e.ID, e.From, e.To)
+}
+
+// IsTransitionError reports whether the error, or one it wraps, is a TransitionError
+func IsTransitionError(err error) bool {
+	var target *TransitionError
+	return errors.As(err, &target)
+}
+
+// IsTerminal reports whether a job or benchmark in the state has finished
+func IsTerminal(state api.State) bool {
+	return (state == api.StateCompleted) || (state == api.StateFailed) || (state == api.StateCancelled)
+}
+
+// IsValid reports whether the state is one of the known states
+func IsValid(state api.State) bool {
+	switch state {
+	case api.StatePending, api.StateRunning, api.StateCompleted, api.StateFailed, api.StateCancelled:
+		return true
+	}
+	return false
+}
+
+// CanTransitionJob reports whether an evaluation job can move from one state to the other
+func CanTransitionJob(from api.State, to api.State) bool {
+	return canTransition(jobTransitions, from, to)
+}
+
+// CanTransitionBenchmark reports whether a benchmark can move from one state to the other
+func CanTransitionBenchmark(from api.State, to api.State) bool {
+	return canTransition(benchmarkTransitions, from, to)
+}
+
+func canTransition(transitions map[api.State][]api.State, from api.State, to api.State) bool {
+	if !IsValid(to) {
+		return false
+	}
+	return (from == to) || slices.Contains(transitions[from], to)
+}
+
+// ApplyJobState moves the evaluation job to the state, the job's StartedAt is stamped when it
+// leaves pending and its CompletedAt when it reaches a final state
+func ApplyJobState(jobID string, status *api.EvaluationJobStatus, state api.EvaluationJobState, now time.Time) error {
+	if !CanTransitionJob(status.State, state.State) {
+		return &TransitionError{Subject: subjectJob, ID: jobID, From: status.State, To: state.State}
+	}
+	status.EvaluationJobState = state
+	stamp(state.State, &status.StartedAt, &status.CompletedAt, now)
+	return nil
+}
+
+// ApplyBenchmarkStatus adds or replaces the status of a benchmark of the evaluation job and then
+// derives the state of the job from the states of its benchmarks. The timestamps and the logs that
+// the update does not set are kept. A benchmark that is not listed yet starts from pending. The
+// status is only changed when both the benchmark and the job transitions are allowed.
+func ApplyBenchmarkStatus(jobID string, status *api.EvaluationJobStatus, benchmark api.BenchmarkStatus, now time.Time) error {
+	index := slices.IndexFunc(status.Benchmarks, func(existing api.BenchmarkStatus) bool {
+		return existing.Name == benchmark.Name
+	})
+	previous := api.BenchmarkStatus{Name: benchmark.Name, State: api.StatePending}
+	if index >= 0 {
+		previous = status.Benchmarks[index]
+	}
+	if !CanTransitionBenchmark(previous.State, benchmark.State) {
+		return &TransitionError{Subject: subjectBenchmark, ID: jobID + "/" + benchmark.Name, From: previous.State, To: benchmark.State}
+	}
+
+	if benchmark.StartedAt == nil {
+		benchmark.StartedAt = previous.StartedAt
+	}
+	if benchmark.CompletedAt == nil {
+		benchmark.CompletedAt = previous.CompletedAt
+	}
+	if benchmark.Logs == nil {
+		benchmark.Logs = previous.Logs
+	}
+	stamp(benchmark.State, &benchmark.StartedAt, &benchmark.CompletedAt, now)
+
+	// the update is applied to a copy that replaces the status once the job transition is allowed
+	updated := *status
+	updated.Benchmarks = slices.Clone(status.Benchmarks)
+	if index < 0 {
+		updated.Benchmarks = append(updated.Benchmarks, benchmark)
+	} else {
+		updated.Benchmarks[index] = benchmark
+	}
+
+	if !IsTerminal(updated.State) {
+		if derived := DeriveJobState(&updated); derived.State != updated.State {
+			if err := ApplyJobState(jobID, &updated, derived, now); err != nil {
+				return err
+			}
+		}
+	}
+	*status = updated
+	return nil
+}
+
+// DeriveJobState returns the state of the evaluation job given the states of its benchmarks. The
+// job runs from the time the first benchmark leaves pending and it finishes with its last
+// benchmark: it fails when a benchmark failed, the runtimes only fail a benchmark once its retries
+// are exhausted, it is cancelled when a benchmark was cancelled and it completes otherwise.
+func DeriveJobState(status *api.EvaluationJobStatus) api.EvaluationJobState {
+	if len(status.Benchmarks) == 0 {
+		return status.EvaluationJobState
+	}
+
+	var failed, cancelled []string
+	finished, started := 0, 0
+	for _, benchmark := range status.Benchmarks {
+		if benchmark.State != api.StatePending {
+			started++
+		}
+		switch benchmark.State {
+		case api.StateCompleted:
+			finished++
+		case api.StateFailed:
+			finished++
+			failed = append(failed, benchmark.Name)
+		case api.StateCancelled:
+			finished++
+			cancelled = append(cancelled, benchmark.Name)
+		}
+	}
+
+	total := len(status.Benchmarks)
+	switch {
+	case (finished == total) && (len(failed) > 0):
+		return api.EvaluationJobState{
+			State:   api.StateFailed,
+			Message: fmt.Sprintf("%d of %d benchmarks failed: %s", len(failed), total, strings.Join(failed, ", ")),
+		}
+	case (finished == total) && (len(cancelled) > 0):
+		return api.EvaluationJobState{
+			State:   api.StateCancelled,
+			Message: fmt.Sprintf("%d of %d benchmarks were cancelled: %s", len(cancelled), total, strings.Join(cancelled, ", ")),
+		}
+	case finished == total:
+		return api.EvaluationJobState{State: api.StateCompleted, Message: "Evaluation job completed"}
+	case started > 0:
+		return api.EvaluationJobState{State: api.StateRunning, Message: "Evaluation job is running"}
+	default:
+		return api.EvaluationJobState{State: api.StatePending, Message: status.Message}
+	}
+}
+
+// stamp sets the start time when the state is no longer pending and the completion time when the
+// state is final, times that are already set are kept
+func stamp(state api.State, startedAt **time.Time, completedAt **time.Time, now time.Time) {
+	if (state != api.StatePending) && (*startedAt == nil) {
+		t := now
+		*startedAt = &t
+	}
+	if IsTerminal(state) && (*completedAt == nil) {
+		t := now
+		*completedAt = &t
+	}
+}
@@ -0,0 +1,141 @@
+package state_machine
+
+import (
+	"testing"
+	"time"
+
+	"github.ibm.com/julpayne/eval-hub-backend-svc/pkg/api"
+)
+
+func TestTransitions(t *testing.T) {
+	testCases := []struct {
+		from    api.State
+		to      api.State
+		allowed bool
+	}{
+		{api.StatePending, api.StateRunning, true},
+		{api.StatePending, api.StateCancelled, true},
+		{api.StateRunning, api.StateRunning, true},
+		{api.StateRunning, api.StateCompleted, true},
+		{api.StateRunning, api.StatePending, false},
+		{api.StateCompleted, api.StateRunning, false},
+		{api.StateFailed, api.StateCompleted, false},
+		{api.StateCancelled, api.StateFailed, false},
+		{api.StateCompleted, api.StateCompleted, true},
+		{api.StatePending, "unknown", false},
+	}
+	for _, tc := range testCases {
+		if got := CanTransitionJob(tc.from, tc.to); got != tc.allowed {
+			t.Errorf("CanTransitionJob(%s, %s) = %v, expected %v", tc.from, tc.to, got, tc.allowed)
+		}
+		if got := CanTransitionBenchmark(tc.from, tc.to); got != tc.allowed {
+			t.Errorf("CanTransitionBenchmark(%s, %s) = %v, expected %v", tc.from, tc.to, got, tc.allowed)
+		}
+	}
+}
+
+func TestApplyJobState(t *testing.T) {
+	now := time.Now().UTC()
+	status := &api.EvaluationJobStatus{EvaluationJobState: api.EvaluationJobState{State: api.StatePending}}
+
+	if err := ApplyJobState("job-1", status, api.EvaluationJobState{State: api.StateRunning}, now); err != nil {
+		t.Fatalf("ApplyJobState() returned error: %v", err)
+	}
+	if status.StartedAt == nil || status.CompletedAt != nil {
+		t.Errorf("Expected only the start time to be stamped, got %v and %v", status.StartedAt, status.CompletedAt)
+	}
+
+	later := now.Add(time.Minute)
+	if err := ApplyJobState("job-1", status, api.EvaluationJobState{State: api.StateCompleted}, later); err != nil {
+		t.Fatalf("ApplyJobState() returned error: %v", err)
+	}
+	if !status.StartedAt.Equal(now) || !status.CompletedAt.Equal(later) {
+		t.Errorf("Expected the start time to be kept and the completion time stamped, got %v and %v", status.StartedAt, status.CompletedAt)
+	}
+
+	err := ApplyJobState("job-1", status, api.EvaluationJobState{State: api.StateRunning}, later)
+	if !IsTransitionError(err) {
+		t.Fatalf("Expected a transition error, got %v", err)
+	}
+	if status.State != api.StateCompleted {
+		t.Errorf("Expected the state to be unchanged, got %s", status.State)
+	}
+}
+
+func newStatus(states map[string]api.State) *api.EvaluationJobStatus {
+	status := &api.EvaluationJobStatus{EvaluationJobState: api.EvaluationJobState{State: api.StatePending}}
+	for _, name := range []string{"mmlu", "arc", "hellaswag"} {
+		if state, found := states[name]; found {
+			status.Benchmarks = append(status.Benchmarks, api.BenchmarkStatus{Name: name, State: state})
+		}
+	}
+	return status
+}
+
+func TestDeriveJobState(t *testing.T) {
+	testCases := []struct {
+		name     string
+		states   map[string]api.State
+		expected api.State
+	}{
+		{"all pending", map[string]api.State{"mmlu": api.StatePending, "arc": api.StatePending}, api.StatePending},
+		{"one running", map[string]api.State{"mmlu": api.StateRunning, "arc": api.StatePending}, api.StateRunning},
+		{"one finished", map[string]api.State{"mmlu": api.StateCompleted, "arc": api.StatePending}, api.StateRunning},
+		{"failed while others run", map[string]api.State{"mmlu": api.StateFailed, "arc": api.StateRunning}, api.StateRunning},
+		{"all completed", map[string]api.State{"mmlu": api.StateCompleted, "arc": api.StateCompleted}, api.StateCompleted},
+		{"any failed", map[string]api.State{"mmlu": api.StateCompleted, "arc": api.StateFailed, "hellaswag": api.StateCancelled}, api.StateFailed},
+		{"any cancelled", map[string]api.State{"mmlu": api.StateCompleted, "arc": api.StateCancelled}, api.StateCancelled},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := DeriveJobState(newStatus(tc.states)); got.State != tc.expected {
+				t.Errorf("Expected %s, got %+v", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestApplyBenchmarkStatus(t *testing.T) {
+	now := time.Now().UTC()
+	status := newStatus(map[string]api.State{"mmlu": api.StatePending, "arc": api.StatePending})
+
+	logs := &api.BenchmarkStatusLogs{Path: "/logs/mmlu.log"}
+	if err := ApplyBenchmarkStatus("job-1", status, api.BenchmarkStatus{Name: "mmlu", State: api.StateRunning, Logs: logs}, now); err != nil {
+		t.Fatalf("ApplyBenchmarkStatus() returned error: %v", err)
+	}
+	if status.State != api.StateRunning || status.StartedAt == nil {
+		t.Errorf("Expected the job to be running since its first benchmark started, got %+v", status.EvaluationJobState)
+	}
+
+	later := now.Add(time.Minute)
+	ApplyBenchmarkStatus("job-1", status, api.BenchmarkStatus{Name: "mmlu", State: api.StateCompleted}, later)
+	mmlu := status.Benchmarks[0]
+	if !mmlu.StartedAt.Equal(now) || !mmlu.CompletedAt.Equal(later) || mmlu.Logs != logs {
+		t.Errorf("Expected the start time and logs to be kept and the completion time stamped, got %+v", mmlu)
+	}
+
+	err := ApplyBenchmarkStatus("job-1", status, api.BenchmarkStatus{Name: "mmlu", State: api.StateRunning}, later)
+	if !IsTransitionError(err) {
+		t.Errorf("Expected a transition error for a completed benchmark, got %v", err)
+	}
+
+	ApplyBenchmarkStatus("job-1", status, api.BenchmarkStatus{Name: "arc", State: api.StateFailed}, later)
+	if status.State != api.StateFailed || status.CompletedAt == nil {
+		t.Errorf("Expected the job to fail with its last benchmark, got %+v", status.EvaluationJobState)
+	}
+
+	// benchmarks of a job that has finished can still be recorded but do not change the job
+	ApplyBenchmarkStatus("job-1", status, api.BenchmarkStatus{Name: "hellaswag", State: api.StateCompleted}, later)
+	if len(status.Benchmarks) != 3 || status.State != api.StateFailed {
+		t.Errorf("Expected the new benchmark to be added to the failed job, got %+v", status)
+	}
+
+
+	// the benchmark is not recorded when the job can not move to the derived state
+	running := newStatus(map[string]api.State{"mmlu": api.StatePending})
+	running.State = api.StateRunning
+	err = ApplyBenchmarkStatus("job-2", running, api.BenchmarkStatus{Name: "arc", State: api.StatePending}, later)
+	if !IsTransitionError(err) || len(running.Benchmarks) != 1 || running.State != api.StateRunning {
+		t.Errorf("Expected a transition error that leaves the status unchanged, got %v, %+v", err, running)
+	}
+}
@@ -0,0 +1,529 @@
+package memory
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/abstractions"
+	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/aggregation"
+	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/callbacks"
+	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/state_machine"
+	"github.ibm.com/julpayne/eval-hub-backend-svc/pkg/api"
+)
+
+// store holds the resources for all the tenants, it is shared by all the tenant views
+type store struct {
+	lock        sync.RWMutex
+	jobs        map[api.Tenant]map[string]*api.EvaluationJobResource
+	collections map[api.Tenant]map[string]*api.CollectionResource
+	callbacks   map[api.Tenant]map[string]*api.CallbackDeliveryResource
+}
+
+// Storage is an in-memory implementation of abstractions.Storage intended for development and
+// testing. Resources are copied on the way in and on the way out so that callers never share
+// state with the store.
+type Storage struct {
+	store  *store
+	tenant api.Tenant
+}
+
+var _ abstractions.Storage = (*Storage)(nil)
+
+// NewStorage creates an empty in-memory storage scoped to the default tenant
+func NewStorage() *Storage {
+	return &Storage{
+		store: &store{
+			jobs:        make(map[api.Tenant]map[string]*api.EvaluationJobResource),
+			collections: make(map[api.Tenant]map[string]*api.CollectionResource),
+			callbacks:   make(map[api.Tenant]map[string]*api.CallbackDeliveryResource),
+		},
+		tenant: abstractions.DefaultTenant,
+	}
+}
+
+func (s *Storage) WithTenant(tenant api.Tenant) abstractions.Storage {
+	return &Storage{store: s.store, tenant: tenant}
+}
+
+func (s *Storage) CreateEvaluationJob(evaluation *api.EvaluationJobResource) error {
+	if evaluation.ID == "" {
+		return fmt.Errorf("the %s ID is required", abstractions.ResourceEvaluationJob)
+	}
+
+	s.store.lock.Lock()
+	defer s.store.lock.Unlock()
+
+	jobs := s.store.jobs[s.tenant]
+	if jobs == nil {
+		jobs = make(map[string]*api.EvaluationJobResource)
+		s.store.jobs[s.tenant] = jobs
+	}
+	if _, found := jobs[evaluation.ID]; found {
+		return &abstractions.ConflictError{Resource: abstractions.ResourceEvaluationJob, ID: evaluation.ID}
+	}
+
+	stampNew(&evaluation.Resource, s.tenant)
+	stored, err := clone(evaluation)
+	if err != nil {
+		return err
+	}
+	jobs[evaluation.ID] = stored
+	return nil
+}
+
+func (s *Storage) GetEvaluationJob(id string) (*api.EvaluationJobResource, error) {
+	s.store.lock.RLock()
+	defer s.store.lock.RUnlock()
+
+	job, found := s.store.jobs[s.tenant][id]
+	if !found {
+		return nil, &abstractions.NotFoundError{Resource: abstractions.ResourceEvaluationJob, ID: id}
+	}
+	return clone(job)
+}
+
+func (s *Storage) GetEvaluationJobs(query *abstractions.JobQuery) (*api.EvaluationJobResourceList, error) {
+	if err := query.Validate(); err != nil {
+		return nil, err
+	}
+	var after *api.EvaluationJobResource
+	if query.Page.After != nil {
+		var err error
+		if after, err = query.Sort.PositionJob(query.Page.After); err != nil {
+			return nil, err
+		}
+	}
+
+	s.store.lock.RLock()
+	defer s.store.lock.RUnlock()
+
+	tenants := []api.Tenant{s.tenant}
+	if query.AllTenants {
+		tenants = slices.Collect(maps.Keys(s.store.jobs))
+	}
+	matches := []*api.EvaluationJobResource{}
+	for _, tenant := range tenants {
+		for _, job := range s.store.jobs[tenant] {
+			if query.Matches(job) {
+				matches = append(matches, job)
+			}
+		}
+	}
+	slices.SortFunc(matches, query.Sort.Compare)
+
+	list := &api.EvaluationJobResourceList{Page: pageOf(&query.Page, len(matches))}
+	matches = paginate(matches, &query.Page, func(job *api.EvaluationJobResource) bool { return query.Sort.Compare(job, after) > 0 })
+	list.Items = make([]api.EvaluationJobResource, 0, len(matches))
+	for _, job := range matches {
+		item, err := clone(job)
+		if err != nil {
+			return nil, err
+		}
+		list.Items = append(list.Items, *item)
+	}
+	return list, nil
+}
+
+func (s *Storage) DeleteEvaluationJob(id string) error {
+	s.store.lock.Lock()
+	defer s.store.lock.Unlock()
+
+	if _, found := s.store.jobs[s.tenant][id]; !found {
+		return &abstractions.NotFoundError{Resource: abstractions.ResourceEvaluationJob, ID: id}
+	}
+	delete(s.store.jobs[s.tenant], id)
+	for deliveryID, delivery := range s.store.callbacks[s.tenant] {
+		if delivery.JobID == id {
+			delete(s.store.callbacks[s.tenant], deliveryID)
+		}
+	}
+	return nil
+}
+
+func (s *Storage) UpdateBenchmarkStatusForJob(id string, status api.BenchmarkStatus) error {
+	stored, err := clone(&status)
+	if err != nil {
+		return err
+	}
+	_, _, err = s.updateJob(id, func(job *api.EvaluationJobResource, now time.Time) (bool, error) {
+		return true, state_machine.ApplyBenchmarkStatus(id, &job.Status, *stored, now)
+	})
+	return err
+}
+
+func (s *Storage) UpdateEvaluationJobStatus(id string, state api.EvaluationJobState) error {
+	_, _, err := s.updateJob(id, func(job *api.EvaluationJobResource, now time.Time) (bool, error) {
+		return true, state_machine.ApplyJobState(id, &job.Status, state, now)
+	})
+	return err
+}
+
+func (s *Storage) RecordBenchmarkResult(id string, result api.EvaluationJobBenchmarkResult) (*api.EvaluationJobResource, bool, error) {
+	stored, err := clone(&result)
+	if err != nil {
+		return nil, false, err
+	}
+	return s.updateJob(id, func(job *api.EvaluationJobResource, now time.Time) (bool, error) {
+		return aggregation.RecordResult(job, *stored, now)
+	})
+}
+
+// updateJob applies an update to a copy of a job and stores the copy with the callback delivery of
+// a state change, so that a failed update leaves the stored job unchanged. Nothing is written when
+// the update reports no change. The returned job is a copy.
+func (s *Storage) updateJob(id string, update func(job *api.EvaluationJobResource, now time.Time) (bool, error)) (*api.EvaluationJobResource, bool, error) {
+	s.store.lock.Lock()
+	defer s.store.lock.Unlock()
+
+	job, found := s.store.jobs[s.tenant][id]
+	if !found {
+		return nil, false, &abstractions.NotFoundError{Resource: abstractions.ResourceEvaluationJob, ID: id}
+	}
+	updated, err := clone(job)
+	if err != nil {
+		return nil, false, err
+	}
+	now := time.Now().UTC()
+	changed, err := update(updated, now)
+	if err != nil {
+		return nil, false, err
+	}
+	if !changed {
+		return updated, false, nil
+	}
+	updated.UpdatedAt = now
+	if err := s.addCallbackDelivery(updated, job.Status.State, now); err != nil {
+		return nil, false, err
+	}
+	s.store.jobs[s.tenant][id] = updated
+	returned, err := clone(updated)
+	if err != nil {
+		return nil, false, err
+	}
+	return returned, true, nil
+}
+
+func (s *Storage) GetLeaderboard(query *abstractions.LeaderboardQuery) ([]api.LeaderboardEntry, error) {
+	if err := query.Validate(); err != nil {
+		return nil, err
+	}
+
+	s.store.lock.RLock()
+	defer s.store.lock.RUnlock()
+
+	jobs := make([]*api.EvaluationJobResource, 0, len(s.store.jobs[s.tenant]))
+	for _, job := range s.store.jobs[s.tenant] {
+		jobs = append(jobs, job)
+	}
+	return aggregation.Leaderboard(jobs, query), nil
+}
+
+// addCallbackDelivery queues the delivery of a state change, the caller holds the write lock
+func (s *Storage) addCallbackDelivery(job *api.EvaluationJobResource, previous api.State, now time.Time) error {
+	delivery := callbacks.NewDelivery(job, previous, now)
+	if delivery == nil {
+		return nil
+	}
+	stored, err := clone(delivery)
+	if err != nil {
+		return err
+	}
+	deliveries := s.store.callbacks[s.tenant]
+	if deliveries == nil {
+		deliveries = make(map[string]*api.CallbackDeliveryResource)
+		s.store.callbacks[s.tenant] = deliveries
+	}
+	deliveries[stored.ID] = stored
+	return nil
+}
+
+func (s *Storage) GetCallbackDeliveries(query abstractions.Query) (*api.CallbackDeliveryResourceList, error) {
+	page, err := query.Page()
+	if err != nil {
+		return nil, err
+	}
+	for key := range query.Filters() {
+		switch key {
+		case abstractions.QueryJobID, abstractions.QueryState:
+		default:
+			return nil, fmt.Errorf("unsupported query filter %q for %s resources", key, abstractions.ResourceCallback)
+		}
+	}
+
+	s.store.lock.RLock()
+	defer s.store.lock.RUnlock()
+
+	matches := make([]*api.CallbackDeliveryResource, 0, len(s.store.callbacks[s.tenant]))
+	for _, delivery := range s.store.callbacks[s.tenant] {
+		if jobID, ok := query[abstractions.QueryJobID]; ok && delivery.JobID != jobID {
+			continue
+		}
+		if state, ok := query[abstractions.QueryState]; ok && string(delivery.State) != state {
+			continue
+		}
+		matches = append(matches, delivery)
+	}
+	slices.SortFunc(matches, func(a, b *api.CallbackDeliveryResource) int {
+		return compareResources(&a.Resource, &b.Resource)
+	})
+
+	list := &api.CallbackDeliveryResourceList{Page: pageOf(page, len(matches))}
+	matches = paginate(matches, page, func(delivery *api.CallbackDeliveryResource) bool { return page.After.IsAfter(&delivery.Resource) })
+	list.Items = make([]api.CallbackDeliveryResource, 0, len(matches))
+	for _, delivery := range matches {
+		item, err := clone(delivery)
+		if err != nil {
+			return nil, err
+		}
+		list.Items = append(list.Items, *item)
+	}
+	return list, nil
+}
+
+func (s *Storage) UpdateCallbackDelivery(delivery *api.CallbackDeliveryResource) error {
+	s.store.lock.Lock()
+	defer s.store.lock.Unlock()
+
+	existing, found := s.store.callbacks[s.tenant][delivery.ID]
+	if !found {
+		return &abstractions.NotFoundError{Resource: abstractions.ResourceCallback, ID: delivery.ID}
+	}
+
+	// the identity of the resource is owned by the storage
+	delivery.Tenant = existing.Tenant
+	delivery.CreatedAt = existing.CreatedAt
+	delivery.UpdatedAt = time.Now().UTC()
+	stored, err := clone(delivery)
+	if err != nil {
+		return err
+	}
+	s.store.callbacks[s.tenant][delivery.ID] = stored
+	return nil
+}
+
+func (s *Storage) ClaimCallbackDeliveries(now time.Time, lease time.Duration, limit int) ([]api.CallbackDeliveryResource, error) {
+	s.store.lock.Lock()
+	defer s.store.lock.Unlock()
+
+	due := []*api.CallbackDeliveryResource{}
+	for _, deliveries := range s.store.callbacks {
+		for _, delivery := range deliveries {
+			if (delivery.State == api.CallbackDeliveryPending) && (delivery.NextAttemptAt != nil) && !delivery.NextAttemptAt.After(now) {
+				due = append(due, delivery)
+			}
+		}
+	}
+	slices.SortFunc(due, func(a, b *api.CallbackDeliveryResource) int {
+		if c := a.NextAttemptAt.Compare(*b.NextAttemptAt); c != 0 {
+			return c
+		}
+		return compareResources(&a.Resource, &b.Resource)
+	})
+
+	claimed := make([]api.CallbackDeliveryResource, 0, min(len(due), limit))
+	for _, delivery := range due[:min(len(due), limit)] {
+		expires := now.Add(lease)
+		delivery.NextAttemptAt = &expires
+		item, err := clone(delivery)
+		if err != nil {
+			return nil, err
+		}
+		claimed = append(claimed, *item)
+	}
+	return claimed, nil
+}
+
+func (s *Storage) CreateCollection(collection *api.CollectionResource) error {
+	if collection.ID == "" {
+		return fmt.Errorf("the %s ID is required", abstractions.ResourceCollection)
+	}
+
+	s.store.lock.Lock()
+	defer s.store.lock.Unlock()
+
+	collections := s.store.collections[s.tenant]
+	if collections == nil {
+		collections = make(map[string]*api.CollectionResource)
+		s.store.collections[s.tenant] = collections
+	}
+	if _, found := collections[collection.ID]; found {
+		return &abstractions.ConflictError{Resource: abstractions.ResourceCollection, ID: collection.ID}
+	}
+
+	stampNew(&collection.Resource, s.tenant)
+	stored, err := clone(collection)
+	if err != nil {
+		return err
+	}
+	collections[collection.ID] = stored
+	return nil
+}
+
+func (s *Storage) GetCollection(id string) (*api.CollectionResource, error) {
+	s.store.lock.RLock()
+	defer s.store.lock.RUnlock()
+
+	collection, found := s.store.collections[s.tenant][id]
+	if !found {
+		return nil, &abstractions.NotFoundError{Resource: abstractions.ResourceCollection, ID: id}
+	}
+	return clone(collection)
+}
+
+func (s *Storage) GetCollections(query abstractions.Query) (*api.CollectionResourceList, error) {
+	page, err := query.Page()
+	if err != nil {
+		return nil, err
+	}
+	for key := range query.Filters() {
+		if key != abstractions.QueryName {
+			return nil, fmt.Errorf("unsupported query filter %q for %s resources", key, abstractions.ResourceCollection)
+		}
+	}
+
+	s.store.lock.RLock()
+	defer s.store.lock.RUnlock()
+
+	matches := make([]*api.CollectionResource, 0, len(s.store.collections[s.tenant]))
+	for _, collection := range s.store.collections[s.tenant] {
+		if name, ok := query[abstractions.QueryName]; ok && collection.Name != name {
+			continue
+		}
+		matches = append(matches, collection)
+	}
+	slices.SortFunc(matches, func(a, b *api.CollectionResource) int {
+		return compareResources(&a.Resource, &b.Resource)
+	})
+
+	list := &api.CollectionResourceList{Page: pageOf(page, len(matches))}
+	matches = paginate(matches, page, func(collection *api.CollectionResource) bool { return page.After.IsAfter(&collection.Resource) })
+	list.Items = make([]api.CollectionResource, 0, len(matches))
+	for _, collection := range matches {
+		item, err := clone(collection)
+		if err != nil {
+			return nil, err
+		}
+		list.Items = append(list.Items, *item)
+	}
+	return list, nil
+}
+
+func (s *Storage) UpdateCollection(collection *api.CollectionResource) error {
+	s.store.lock.Lock()
+	defer s.store.lock.Unlock()
+
+	existing, found := s.store.collections[s.tenant][collection.ID]
+	if !found {
+		return &abstractions.NotFoundError{Resource: abstractions.ResourceCollection, ID: collection.ID}
+	}
+
+	// the identity of the resource is owned by the storage
+	collection.Tenant = existing.Tenant
+	collection.CreatedAt = existing.CreatedAt
+	collection.UpdatedAt = time.Now().UTC()
+	stored, err := clone(collection)
+	if err != nil {
+		return err
+	}
+	s.store.collections[s.tenant][collection.ID] = stored
+	return nil
+}
+
+func (s *Storage) PatchCollection(id string, patch func(config *api.CollectionConfig) error) (*api.CollectionResource, error) {
+	s.store.lock.Lock()
+	defer s.store.lock.Unlock()
+
+	existing, found := s.store.collections[s.tenant][id]
+	if !found {
+		return nil, &abstractions.NotFoundError{Resource: abstractions.ResourceCollection, ID: id}
+	}
+	collection, err := clone(existing)
+	if err != nil {
+		return nil, err
+	}
+	if err := patch(&collection.CollectionConfig); err != nil {
+		return nil, err
+	}
+	collection.UpdatedAt = time.Now().UTC()
+	stored, err := clone(collection)
+	if err != nil {
+		return nil, err
+	}
+	s.store.collections[s.tenant][id] = stored
+	return collection, nil
+}
+
+func (s *Storage) DeleteCollection(id string) error {
+	s.store.lock.Lock()
+	defer s.store.lock.Unlock()
+
+	if _, found := s.store.collections[s.tenant][id]; !found {
+		return &abstractions.NotFoundError{Resource: abstractions.ResourceCollection, ID: id}
+	}
+	delete(s.store.collections[s.tenant], id)
+	return nil
+}
+
+func (s *Storage) Close() error {
+	return nil
+}
+
+// stampNew sets the tenant and the timestamps of a resource that is about to be created
+func stampNew(resource *api.Resource, tenant api.Tenant) {
+	now := time.Now().UTC()
+	resource.Tenant = tenant
+	if resource.CreatedAt.IsZero() {
+		resource.CreatedAt = now
+	}
+	resource.UpdatedAt = now
+}
+
+// pageOf returns the page of a listing with the given number of matches
+func pageOf(page *abstractions.PageQuery, total int) api.Page {
+	limit := page.Limit
+	if limit == 0 {
+		limit = total
+	}
+	return api.Page{Limit: limit, TotalCount: total}
+}
+
+// paginate applies the pagination to the ordered matches, isAfter reports whether a match is after
+// the position of the page
+func paginate[T any](matches []*T, page *abstractions.PageQuery, isAfter func(*T) bool) []*T {
+	if page.After != nil {
+		matches = matches[sort.Search(len(matches), func(i int) bool { return isAfter(matches[i]) }):]
+	}
+	matches = matches[min(page.Offset, len(matches)):]
+	if (page.Limit > 0) && (len(matches) > page.Limit) {
+		matches = matches[:page.Limit]
+	}
+	return matches
+}
+
+// compareResources orders resources by creation time and then by ID so that listings are stable
+func compareResources(a, b *api.Resource) int {
+	if c := a.CreatedAt.Compare(b.CreatedAt); c != 0 {
+		return c
+	}
+	return strings.Compare(a.ID, b.ID)
+}
+
+// clone makes a deep copy of a resource by round tripping it through its JSON representation,
+// which is also the representation that is returned to the clients
+func clone[T any](value *T) (*T, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	copied := new(T)
+	if err := json.Unmarshal(data, copied); err != nil {
+		return nil, err
+	}
+	return copied, nil
+}
@@ -0,0 +1,497 @@
+package memory
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/abstractions"
+	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/state_machine"
+	"github.ibm.com/julpayne/eval-hub-backend-svc/pkg/api"
+)
+
+func newJob(id string, modelName string, state api.State) *api.EvaluationJobResource {
+	return &api.EvaluationJobResource{
+		Resource: api.Resource{ID: id},
+		EvaluationJobConfig: api.EvaluationJobConfig{
+			Model:      api.ModelRef{URL: "http://model", Name: modelName},
+			Benchmarks: []api.BenchmarkConfig{{Ref: api.Ref{ID: "mmlu"}}},
+		},
+		Status: api.EvaluationJobStatus{
+			EvaluationJobState: api.EvaluationJobState{State: state},
+		},
+	}
+}
+
+func TestEvaluationJobs(t *testing.T) {
+	t.Run("create and get returns a copy with tenant and timestamps", func(t *testing.T) {
+		storage := NewStorage()
+		job := newJob("job-1", "granite", api.StatePending)
+		if err := storage.CreateEvaluationJob(job); err != nil {
+			t.Fatalf("CreateEvaluationJob() returned error: %v", err)
+		}
+
+		got, err := storage.GetEvaluationJob("job-1")
+		if err != nil {
+			t.Fatalf("GetEvaluationJob() returned error: %v", err)
+		}
+		if got.Tenant != abstractions.DefaultTenant {
+			t.Errorf("Expected tenant %s, got %s", abstractions.DefaultTenant, got.Tenant)
+		}
+		if got.CreatedAt.IsZero() || got.UpdatedAt.IsZero() {
+			t.Error("Expected the timestamps to be set")
+		}
+
+		got.Model.Name = "changed"
+		again, _ := storage.GetEvaluationJob("job-1")
+		if again.Model.Name != "granite" {
+			t.Errorf("Expected stored model name to be unchanged, got %s", again.Model.Name)
+		}
+	})
+
+	t.Run("create with an existing ID returns a conflict", func(t *testing.T) {
+		storage := NewStorage()
+		if err := storage.CreateEvaluationJob(newJob("job-1", "granite", api.StatePending)); err != nil {
+			t.Fatalf("CreateEvaluationJob() returned error: %v", err)
+		}
+		err := storage.CreateEvaluationJob(newJob("job-1", "granite", api.StatePending))
+		if !abstractions.IsConflict(err) {
+			t.Errorf("Expected a conflict error, got %v", err)
+		}
+	})
+
+	t.Run("missing jobs return not found", func(t *testing.T) {
+		storage := NewStorage()
+		if _, err := storage.GetEvaluationJob("missing"); !abstractions.IsNotFound(err) {
+			t.Errorf("Expected a not found error from get, got %v", err)
+		}
+		if err := storage.DeleteEvaluationJob("missing"); !abstractions.IsNotFound(err) {
+			t.Errorf("Expected a not found error from delete, got %v", err)
+		}
+		if err := storage.UpdateEvaluationJobStatus("missing", api.EvaluationJobState{State: api.StateRunning}); !abstractions.IsNotFound(err) {
+			t.Errorf("Expected a not found error from update, got %v", err)
+		}
+	})
+
+	t.Run("list honours the query filters", func(t *testing.T) {
+		storage := NewStorage()
+		storage.CreateEvaluationJob(newJob("job-1", "granite", api.StatePending))
+		storage.CreateEvaluationJob(newJob("job-2", "granite", api.StateRunning))
+		storage.CreateEvaluationJob(newJob("job-3", "llama", api.StateRunning))
+
+		testCases := []struct {
+			query    *abstractions.JobQuery
+			expected []string
+		}{
+			{&abstractions.JobQuery{}, []string{"job-1", "job-2", "job-3"}},
+			{&abstractions.JobQuery{States: []api.State{api.StateRunning}}, []string{"job-2", "job-3"}},
+			{&abstractions.JobQuery{ModelName: "granite"}, []string{"job-1", "job-2"}},
+			{&abstractions.JobQuery{States: []api.State{api.StateRunning}, ModelName: "granite"}, []string{"job-2"}},
+		}
+		for _, tc := range testCases {
+			list, err := storage.GetEvaluationJobs(tc.query)
+			if err != nil {
+				t.Fatalf("GetEvaluationJobs(%v) returned error: %v", tc.query, err)
+			}
+			if list.TotalCount != len(tc.expected) || len(list.Items) != len(tc.expected) {
+				t.Fatalf("Expected %d jobs for %v, got %d", len(tc.expected), tc.query, len(list.Items))
+			}
+			for i, id := range tc.expected {
+				if list.Items[i].ID != id {
+					t.Errorf("Expected job %s at index %d for %v, got %s", id, i, tc.query, list.Items[i].ID)
+				}
+			}
+		}
+
+		if _, err := storage.GetEvaluationJobs(&abstractions.JobQuery{States: []api.State{"unknown"}}); err == nil {
+			t.Error("Expected an error for an invalid state")
+		}
+	})
+
+	t.Run("benchmark status is added and then replaced", func(t *testing.T) {
+		storage := NewStorage()
+		storage.CreateEvaluationJob(newJob("job-1", "granite", api.StatePending))
+
+		storage.UpdateBenchmarkStatusForJob("job-1", api.BenchmarkStatus{Name: "mmlu", State: api.StateRunning})
+		storage.UpdateBenchmarkStatusForJob("job-1", api.BenchmarkStatus{Name: "mmlu", State: api.StateCompleted})
+
+		job, _ := storage.GetEvaluationJob("job-1")
+		if len(job.Status.Benchmarks) != 1 {
+			t.Fatalf("Expected 1 benchmark status, got %d", len(job.Status.Benchmarks))
+		}
+		if job.Status.Benchmarks[0].State != api.StateCompleted {
+			t.Errorf("Expected benchmark state completed, got %s", job.Status.Benchmarks[0].State)
+		}
+	})
+
+	t.Run("benchmark results are recorded once per attempt", func(t *testing.T) {
+		storage := NewStorage()
+		storage.CreateEvaluationJob(newJob("job-1", "granite", api.StatePending))
+		result := api.EvaluationJobBenchmarkResult{ID: "mmlu", State: api.StateCompleted, AttemptID: "a1", Metrics: map[string]any{"acc": 0.5}}
+
+		job, recorded, err := storage.RecordBenchmarkResult("job-1", result)
+		if err != nil || !recorded {
+			t.Fatalf("Expected the result to be recorded, got %v", err)
+		}
+		if (job.Status.State != api.StateCompleted) || (job.Results.CompletedEvaluations != 1) {
+			t.Errorf("Expected the job to complete with its result, got %+v", job)
+		}
+		if _, recorded, err := storage.RecordBenchmarkResult("job-1", result); err != nil || recorded {
+			t.Errorf("Expected the same attempt not to be recorded again, got %v, %v", recorded, err)
+		}
+		result.AttemptID = "a2"
+		if _, _, err := storage.RecordBenchmarkResult("job-1", result); !abstractions.IsConflict(err) {
+			t.Errorf("Expected a conflict for another attempt, got %v", err)
+		}
+		if _, _, err := storage.RecordBenchmarkResult("missing", result); !abstractions.IsNotFound(err) {
+			t.Errorf("Expected a not found error, got %v", err)
+		}
+	})
+}
+
+func TestTenantIsolation(t *testing.T) {
+	storage := NewStorage()
+	tenantA := storage.WithTenant("tenant-a")
+	tenantB := storage.WithTenant("tenant-b")
+
+	if err := tenantA.CreateEvaluationJob(newJob("job-1", "granite", api.StatePending)); err != nil {
+		t.Fatalf("CreateEvaluationJob() returned error: %v", err)
+	}
+	if err := tenantA.CreateCollection(&api.CollectionResource{Resource: api.Resource{ID: "col-1"}}); err != nil {
+		t.Fatalf("CreateCollection() returned error: %v", err)
+	}
+
+	if _, err := tenantB.GetEvaluationJob("job-1"); !abstractions.IsNotFound(err) {
+		t.Errorf("Expected another tenant's job to be not found, got %v", err)
+	}
+	if err := tenantB.DeleteEvaluationJob("job-1"); !abstractions.IsNotFound(err) {
+		t.Errorf("Expected another tenant's job delete to be not found, got %v", err)
+	}
+	if err := tenantB.UpdateCollection(&api.CollectionResource{Resource: api.Resource{ID: "col-1"}}); !abstractions.IsNotFound(err) {
+		t.Errorf("Expected another tenant's collection update to be not found, got %v", err)
+	}
+	list, _ := tenantB.GetEvaluationJobs(&abstractions.JobQuery{})
+	if list.TotalCount != 0 {
+		t.Errorf("Expected no jobs for another tenant, got %d", list.TotalCount)
+	}
+
+	// the same ID can be used by a different tenant
+	if err := tenantB.CreateEvaluationJob(newJob("job-1", "llama", api.StatePending)); err != nil {
+		t.Errorf("Expected the same ID to be usable by another tenant, got %v", err)
+	}
+	job, _ := tenantA.GetEvaluationJob("job-1")
+	if job.Model.Name != "granite" || job.Tenant != "tenant-a" {
+		t.Errorf("Expected tenant-a's job to be unchanged, got %s/%s", job.Tenant, job.Model.Name)
+	}
+
+	list, _ = tenantB.GetEvaluationJobs(&abstractions.JobQuery{AllTenants: true})
+	if (list.TotalCount != 2) || (list.Items[0].Tenant == list.Items[1].Tenant) {
+		t.Errorf("Expected the jobs of all the tenants, got %+v", list.Items)
+	}
+}
+
+func TestCollections(t *testing.T) {
+	t.Run("update keeps the identity of the resource", func(t *testing.T) {
+		storage := NewStorage()
+		storage.CreateCollection(&api.CollectionResource{
+			Resource:         api.Resource{ID: "col-1"},
+			CollectionConfig: api.CollectionConfig{Name: "original", Benchmarks: []string{"mmlu"}},
+		})
+		created, _ := storage.GetCollection("col-1")
+
+		err := storage.UpdateCollection(&api.CollectionResource{
+			Resource:         api.Resource{ID: "col-1", Tenant: "other"},
+			CollectionConfig: api.CollectionConfig{Name: "updated", Benchmarks: []string{"mmlu", "arc"}},
+		})
+		if err != nil {
+			t.Fatalf("UpdateCollection() returned error: %v", err)
+		}
+
+		updated, _ := storage.GetCollection("col-1")
+		if updated.Name != "updated" || len(updated.Benchmarks) != 2 {
+			t.Errorf("Expected the collection to be updated, got %+v", updated.CollectionConfig)
+		}
+		if updated.Tenant != abstractions.DefaultTenant {
+			t.Errorf("Expected tenant %s, got %s", abstractions.DefaultTenant, updated.Tenant)
+		}
+		if !updated.CreatedAt.Equal(created.CreatedAt) {
+			t.Errorf("Expected created_at %v to be kept, got %v", created.CreatedAt, updated.CreatedAt)
+		}
+	})
+
+	t.Run("list filters by name and delete removes", func(t *testing.T) {
+		storage := NewStorage()
+		storage.CreateCollection(&api.CollectionResource{Resource: api.Resource{ID: "col-1"}, CollectionConfig: api.CollectionConfig{Name: "a"}})
+		storage.CreateCollection(&api.CollectionResource{Resource: api.Resource{ID: "col-2"}, CollectionConfig: api.CollectionConfig{Name: "b"}})
+
+		list, err := storage.GetCollections(abstractions.Query{abstractions.QueryName: "b"})
+		if err != nil {
+			t.Fatalf("GetCollections() returned error: %v", err)
+		}
+		if len(list.Items) != 1 || list.Items[0].ID != "col-2" {
+			t.Errorf("Expected only col-2, got %+v", list.Items)
+		}
+
+		if err := storage.DeleteCollection("col-1"); err != nil {
+			t.Fatalf("DeleteCollection() returned error: %v", err)
+		}
+		if _, err := storage.GetCollection("col-1"); !abstractions.IsNotFound(err) {
+			t.Errorf("Expected a not found error after delete, got %v", err)
+		}
+	})
+
+	t.Run("concurrent patches are not lost", func(t *testing.T) {
+		storage := NewStorage()
+		storage.CreateCollection(&api.CollectionResource{Resource: api.Resource{ID: "col-1"}, CollectionConfig: api.CollectionConfig{Name: "a"}})
+		var wg sync.WaitGroup
+		for i := 0; i < 20; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				_, err := storage.PatchCollection("col-1", func(config *api.CollectionConfig) error {
+					config.Benchmarks = append(config.Benchmarks, fmt.Sprintf("b-%d", i))
+					return nil
+				})
+				if err != nil {
+					t.Errorf("PatchCollection() returned error: %v", err)
+				}
+			}(i)
+		}
+		wg.Wait()
+
+		patched, _ := storage.GetCollection("col-1")
+		if len(patched.Benchmarks) != 20 {
+			t.Errorf("Expected the 20 patches to be applied, got %v", patched.Benchmarks)
+		}
+		_, err := storage.PatchCollection("col-1", func(config *api.CollectionConfig) error {
+			config.Name = "b"
+			return fmt.Errorf("invalid")
+		})
+		if unchanged, _ := storage.GetCollection("col-1"); (err == nil) || (unchanged.Name != "a") {
+			t.Errorf("Expected a failed patch not to be stored, got %+v and error %v", unchanged.CollectionConfig, err)
+		}
+		if _, err := storage.PatchCollection("missing", func(*api.CollectionConfig) error { return nil }); !abstractions.IsNotFound(err) {
+			t.Errorf("Expected a not found error, got %v", err)
+		}
+	})
+}
+
+func TestConcurrentAccess(t *testing.T) {
+	storage := NewStorage()
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			id := fmt.Sprintf("job-%d", i)
+			if err := storage.CreateEvaluationJob(newJob(id, "granite", api.StatePending)); err != nil {
+				t.Errorf("CreateEvaluationJob(%s) returned error: %v", id, err)
+			}
+			storage.UpdateEvaluationJobStatus(id, api.EvaluationJobState{State: api.StateRunning})
+			storage.GetEvaluationJobs(&abstractions.JobQuery{})
+		}(i)
+	}
+	wg.Wait()
+
+	list, _ := storage.GetEvaluationJobs(&abstractions.JobQuery{States: []api.State{api.StateRunning}})
+	if list.TotalCount != 20 {
+		t.Errorf("Expected 20 running jobs, got %d", list.TotalCount)
+	}
+}
+
+func TestStateTransitions(t *testing.T) {
+	storage := NewStorage()
+	storage.CreateEvaluationJob(newJob("job-1", "granite", api.StatePending))
+
+	if err := storage.UpdateEvaluationJobStatus("job-1", api.EvaluationJobState{State: api.StateCompleted}); err != nil {
+		t.Fatalf("UpdateEvaluationJobStatus() returned error: %v", err)
+	}
+	err := storage.UpdateEvaluationJobStatus("job-1", api.EvaluationJobState{State: api.StateRunning})
+	if !state_machine.IsTransitionError(err) {
+		t.Errorf("Expected a transition error, got %v", err)
+	}
+	job, _ := storage.GetEvaluationJob("job-1")
+	if job.Status.State != api.StateCompleted || job.Status.CompletedAt == nil {
+		t.Errorf("Expected the job to stay completed with a completion time, got %+v", job.Status)
+	}
+
+	// a benchmark update that the job can not follow leaves the stored job unchanged
+	storage.CreateEvaluationJob(newJob("job-2", "granite", api.StateRunning))
+	err = storage.UpdateBenchmarkStatusForJob("job-2", api.BenchmarkStatus{Name: "mmlu", State: api.StatePending})
+	if !state_machine.IsTransitionError(err) {
+		t.Errorf("Expected a transition error, got %v", err)
+	}
+	job, _ = storage.GetEvaluationJob("job-2")
+	if job.Status.State != api.StateRunning || len(job.Status.Benchmarks) != 0 {
+		t.Errorf("Expected the rejected update to leave the job unchanged, got %+v", job.Status)
+	}
+}
+
+func TestPagination(t *testing.T) {
+	storage := NewStorage()
+	created := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := range 5 {
+		job := newJob(fmt.Sprintf("job-%d", i), "granite", api.StatePending)
+		job.CreatedAt = created.Add(time.Duration(i) * time.Second)
+		storage.CreateEvaluationJob(job)
+	}
+	ids := func(list *api.EvaluationJobResourceList) string {
+		ids := []string{}
+		for _, job := range list.Items {
+			ids = append(ids, job.ID)
+		}
+		return strings.Join(ids, ",")
+	}
+
+	first, err := storage.GetEvaluationJobs(&abstractions.JobQuery{Page: abstractions.PageQuery{Limit: 2}})
+	if err != nil {
+		t.Fatalf("GetEvaluationJobs() returned error: %v", err)
+	}
+	if (ids(first) != "job-0,job-1") || (first.TotalCount != 5) || (first.Limit != 2) {
+		t.Errorf("Expected the first 2 of 5 jobs, got %s of %d", ids(first), first.TotalCount)
+	}
+
+	after, _ := abstractions.Query{abstractions.QueryAfter: abstractions.PositionAfter(&first.Items[1].Resource)}.Page()
+	next, _ := storage.GetEvaluationJobs(&abstractions.JobQuery{Page: abstractions.PageQuery{Limit: 2, After: after.After}})
+	if ids(next) != "job-2,job-3" {
+		t.Errorf("Expected the jobs after job-1, got %s", ids(next))
+	}
+
+	offset, _ := storage.GetEvaluationJobs(&abstractions.JobQuery{ModelName: "granite", Page: abstractions.PageQuery{Offset: 3}})
+	if (ids(offset) != "job-3,job-4") || (offset.TotalCount != 5) {
+		t.Errorf("Expected the last 2 jobs, got %s", ids(offset))
+	}
+
+	for _, query := range []abstractions.Query{{abstractions.QueryLimit: "0"}, {abstractions.QueryOffset: "x"}, {abstractions.QueryAfter: "job-1"}} {
+		if _, err := storage.GetCollections(query); err == nil {
+			t.Errorf("Expected an error for %v", query)
+		}
+	}
+}
+
+func TestJobQuery(t *testing.T) {
+	storage := NewStorage()
+	created := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	jobs := []struct {
+		id         string
+		model      api.ModelRef
+		benchmarks []string
+		collection string
+		experiment api.ExperimentConfig
+	}{
+		{"job-a", api.ModelRef{Name: "granite", URL: "http://granite"}, []string{"mmlu", "arc"}, "col-1", api.ExperimentConfig{Name: "exp-1", Tags: map[string]string{"team": "nlp"}}},
+		{"job-b", api.ModelRef{Name: "llama", URL: "http://llama"}, []string{"mmlu"}, "", api.ExperimentConfig{Name: "exp-1", Tags: map[string]string{"team": "vision"}}},
+		{"job-c", api.ModelRef{Name: "granite", URL: "http://granite-2"}, []string{"hellaswag"}, "col-1", api.ExperimentConfig{Name: "exp-2", Tags: map[string]string{"priority": "high"}}},
+	}
+	for i, j := range jobs {
+		job := newJob(j.id, j.model.Name, api.StatePending)
+		job.CreatedAt = created.Add(time.Duration(i) * time.Hour)
+		job.Model = j.model
+		job.Benchmarks = nil
+		for _, id := range j.benchmarks {
+			job.Benchmarks = append(job.Benchmarks, api.BenchmarkConfig{Ref: api.Ref{ID: id}})
+		}
+		job.Collection = api.Ref{ID: j.collection}
+		job.Experiment = j.experiment
+		if err := storage.CreateEvaluationJob(job); err != nil {
+			t.Fatalf("CreateEvaluationJob() returned error: %v", err)
+		}
+	}
+	ids := func(list *api.EvaluationJobResourceList) string {
+		ids := []string{}
+		for _, job := range list.Items {
+			ids = append(ids, job.ID)
+		}
+		return strings.Join(ids, ",")
+	}
+	at := func(hours int) *time.Time {
+		value := created.Add(time.Duration(hours) * time.Hour)
+		return &value
+	}
+
+	testCases := []struct {
+		name     string
+		query    *abstractions.JobQuery
+		expected string
+	}{
+		{"model URL", &abstractions.JobQuery{ModelURL: "http://granite"}, "job-a"},
+		{"benchmark", &abstractions.JobQuery{BenchmarkID: "mmlu"}, "job-a,job-b"},
+		{"collection", &abstractions.JobQuery{CollectionID: "col-1"}, "job-a,job-c"},
+		{"experiment name", &abstractions.JobQuery{ExperimentName: "exp-1"}, "job-a,job-b"},
+		{"experiment tag value", &abstractions.JobQuery{ExperimentTags: map[string]string{"team": "nlp"}}, "job-a"},
+		{"experiment tag key", &abstractions.JobQuery{ExperimentTags: map[string]string{"team": ""}}, "job-a,job-b"},
+		{"combined", &abstractions.JobQuery{ModelName: "granite", BenchmarkID: "hellaswag", ExperimentName: "exp-2"}, "job-c"},
+		{"created from", &abstractions.JobQuery{CreatedFrom: at(1)}, "job-b,job-c"},
+		{"created range", &abstractions.JobQuery{CreatedFrom: at(0), CreatedTo: at(1)}, "job-a"},
+		{"updated to", &abstractions.JobQuery{UpdatedTo: at(0)}, ""},
+		{"sort descending", &abstractions.JobQuery{Sort: abstractions.JobSort{Field: abstractions.JobSortCreatedAt, Descending: true}}, "job-c,job-b,job-a"},
+		{"sort by model", &abstractions.JobQuery{Sort: abstractions.JobSort{Field: abstractions.JobSortModelName, Descending: true}}, "job-b,job-c,job-a"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			list, err := storage.GetEvaluationJobs(tc.query)
+			if err != nil {
+				t.Fatalf("GetEvaluationJobs() returned error: %v", err)
+			}
+			if ids(list) != tc.expected {
+				t.Errorf("Expected %q, got %q", tc.expected, ids(list))
+			}
+		})
+	}
+
+	t.Run("sorted pages continue after the position", func(t *testing.T) {
+		sort := abstractions.JobSort{Field: abstractions.JobSortModelName, Descending: true}
+		first, _ := storage.GetEvaluationJobs(&abstractions.JobQuery{Sort: sort, Page: abstractions.PageQuery{Limit: 2}})
+		after, err := abstractions.Query{abstractions.QueryAfter: sort.PositionAfter(&first.Items[1])}.Page()
+		if err != nil {
+			t.Fatalf("Page() returned error: %v", err)
+		}
+		next, err := storage.GetEvaluationJobs(&abstractions.JobQuery{Sort: sort, Page: abstractions.PageQuery{Limit: 2, After: after.After}})
+		if err != nil {
+			t.Fatalf("GetEvaluationJobs() returned error: %v", err)
+		}
+		if (ids(first) != "job-b,job-c") || (ids(next) != "job-a") || (next.TotalCount != 3) {
+			t.Errorf("Expected job-b,job-c then job-a, got %s then %s", ids(first), ids(next))
+		}
+	})
+
+	t.Run("invalid queries are rejected", func(t *testing.T) {
+		for _, query := range []*abstractions.JobQuery{
+			{CreatedFrom: at(1), CreatedTo: at(1)},
+			{Sort: abstractions.JobSort{Field: "name"}},
+			{Sort: abstractions.JobSort{Field: abstractions.JobSortUpdatedAt}, Page: abstractions.PageQuery{After: &abstractions.Position{ID: "job-a", Value: "yesterday"}}},
+		} {
+			if _, err := storage.GetEvaluationJobs(query); err == nil {
+				t.Errorf("Expected an error for %+v", query)
+			}
+		}
+	})
+}
+
+func TestLeaderboard(t *testing.T) {
+	storage := NewStorage()
+	for i, acc := range []float64{0.5, 0.75, 0.25} {
+		id := fmt.Sprintf("job-%d", i)
+		storage.CreateEvaluationJob(newJob(id, []string{"granite", "granite", "llama"}[i], api.StatePending))
+		result := api.EvaluationJobBenchmarkResult{ID: "mmlu", State: api.StateCompleted, AttemptID: "1", Metrics: map[string]any{"acc": acc}}
+		if _, _, err := storage.RecordBenchmarkResult(id, result); err != nil {
+			t.Fatalf("RecordBenchmarkResult() returned error: %v", err)
+		}
+	}
+	other := storage.WithTenant("other")
+	other.CreateEvaluationJob(newJob("job-other", "mistral", api.StatePending))
+	other.RecordBenchmarkResult("job-other", api.EvaluationJobBenchmarkResult{ID: "mmlu", State: api.StateCompleted, AttemptID: "1", Metrics: map[string]any{"acc": 1.0}})
+
+	entries, err := storage.GetLeaderboard(&abstractions.LeaderboardQuery{Benchmarks: []string{"mmlu"}, Metric: "acc", Mode: abstractions.LeaderboardBest, Limit: 10})
+	if err != nil {
+		t.Fatalf("GetLeaderboard() returned error: %v", err)
+	}
+	if (len(entries) != 2) || (entries[0].JobID != "job-1") || (entries[0].Evaluations != 2) || (entries[1].Model.Name != "llama") || (entries[1].Rank != 2) {
+		t.Errorf("Expected the best score of every model of the tenant, got %+v", entries)
+	}
+	if _, err := storage.GetLeaderboard(&abstractions.LeaderboardQuery{Metric: "acc", Limit: 10}); err == nil {
+		t.Error("Expected an error for a query without a benchmark")
+	}
+}
@@ -0,0 +1,77 @@
+package migrations
+
+import (
+	"testing"
+	"testing/fstest"
+)
+
+func TestLoadMigrations(t *testing.T) {
+	t.Run("embedded migrations are valid and ordered", func(t *testing.T) {
+		migrations, err := loadMigrations(migrationFiles, "sql")
+		if err != nil {
+			t.Fatalf("loadMigrations() returned error: %v", err)
+		}
+		if len(migrations) == 0 {
+			t.Fatal("Expected at least one embedded migration")
+		}
+		for i := 1; i < len(migrations); i++ {
+			if migrations[i].Version <= migrations[i-1].Version {
+				t.Errorf("Expected migration versions to be ascending, got %d after %d", migrations[i].Version, migrations[i-1].Version)
+			}
+		}
+	})
+
+	testCases := []struct {
+		name  string
+		files fstest.MapFS
+		valid bool
+	}{
+		{
+			name: "pairs are sorted by version",
+			files: fstest.MapFS{
+				"sql/0010_second.up.sql":   {Data: []byte("SELECT 2")},
+				"sql/0010_second.down.sql": {Data: []byte("SELECT -2")},
+				"sql/0002_first.up.sql":    {Data: []byte("SELECT 1")},
+				"sql/0002_first.down.sql":  {Data: []byte("SELECT -1")},
+			},
+			valid: true,
+		},
+		{
+			name: "missing down migration",
+			files: fstest.MapFS{
+				"sql/0001_first.up.sql": {Data: []byte("SELECT 1")},
+			},
+		},
+		{
+			name: "invalid file name",
+			files: fstest.MapFS{
+				"sql/first.sql": {Data: []byte("SELECT 1")},
+			},
+		},
+		{
+			name: "version used twice",
+			files: fstest.MapFS{
+				"sql/0001_first.up.sql":   {Data: []byte("SELECT 1")},
+				"sql/0001_first.down.sql": {Data: []byte("SELECT -1")},
+				"sql/0001_other.up.sql":   {Data: []byte("SELECT 1")},
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			migrations, err := loadMigrations(tc.files, "sql")
+			if !tc.valid {
+				if err == nil {
+					t.Error("Expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("loadMigrations() returned error: %v", err)
+			}
+			if len(migrations) != 2 || migrations[0].Name != "first" || migrations[1].Up != "SELECT 2" {
+				t.Errorf("Unexpected migrations %+v", migrations)
+			}
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package migrations_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/config"
+	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/logging"
+	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/storage/postgres"
+	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/storage/postgres/migrations"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// TestMigrator needs a PostgreSQL server, see EVAL_HUB_TEST_DATABASE_URL in the postgres package
+func TestMigrator(t *testing.T) {
+	databaseURL := os.Getenv("EVAL_HUB_TEST_DATABASE_URL")
+	if databaseURL == "" {
+		t.Skip("EVAL_HUB_TEST_DATABASE_URL is not set, skipping PostgreSQL tests")
+	}
+	logger, err := logging.NewLogger()
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	ctx := context.Background()
+	schemaName := fmt.Sprintf("eval_hub_migrations_test_%d", time.Now().UnixNano())
+	pool, err := postgres.NewPool(ctx, logger, &config.DatabaseConfig{URL: databaseURL, Schema: schemaName})
+	if err != nil {
+		t.Fatalf("NewPool() returned error: %v", err)
+	}
+	t.Cleanup(func() {
+		pool.Exec(context.Background(), "DROP SCHEMA "+pgx.Identifier{schemaName}.Sanitize()+" CASCADE")
+		pool.Close()
+	})
+
+	migrator, err := migrations.NewMigrator(logger, pool)
+	if err != nil {
+		t.Fatalf("migrations.NewMigrator() returned error: %v", err)
+	}
+	total := len(migrator.Migrations())
+
+	applied, err := migrator.Up(ctx)
+	if err != nil || applied != total {
+		t.Fatalf("Expected %d migrations to be applied, got %d (%v)", total, applied, err)
+	}
+	if applied, _ := migrator.Up(ctx); applied != 0 {
+		t.Errorf("Expected no pending migrations, got %d", applied)
+	}
+
+	statuses, err := migrator.Status(ctx)
+	if err != nil {
+		t.Fatalf("Status() returned error: %v", err)
+	}
+	for _, status := range statuses {
+		if status.AppliedAt == nil {
+			t.Errorf("Expected migration %d to be applied", status.Version)
+		}
+	}
+
+	rolledBack, err := migrator.Down(ctx, total)
+	if err != nil || rolledBack != total {
+		t.Fatalf("Expected %d migrations to be rolled back, got %d (%v)", total, rolledBack, err)
+	}
+	statuses, _ = migrator.Status(ctx)
+	for _, status := range statuses {
+		if status.AppliedAt != nil {
+			t.Errorf("Expected migration %d to be pending", status.Version)
+		}
+	}
+}
@@ -0,0 +1,3 @@
+DROP TABLE collections;
+
+DROP TABLE evaluation_jobs;
@@ -0,0 +1,26 @@
+CREATE TABLE evaluation_jobs (
+    tenant     TEXT        NOT NULL,
+    id         TEXT        NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL,
+    state      TEXT        NOT NULL,
+    model_name TEXT        NOT NULL,
+    config     JSONB       NOT NULL,
+    status     JSONB       NOT NULL,
+    results    JSONB,
+    PRIMARY KEY (tenant, id)
+);
+
+CREATE INDEX evaluation_jobs_created_idx ON evaluation_jobs (tenant, created_at, id);
+
+CREATE TABLE collections (
+    tenant     TEXT        NOT NULL,
+    id         TEXT        NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL,
+    name       TEXT        NOT NULL,
+    config     JSONB       NOT NULL,
+    PRIMARY KEY (tenant, id)
+);
+
+CREATE INDEX collections_created_idx ON collections (tenant, created_at, id);
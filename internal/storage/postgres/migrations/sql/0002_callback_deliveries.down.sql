@@ -0,0 +1 @@
+DROP TABLE callback_deliveries;
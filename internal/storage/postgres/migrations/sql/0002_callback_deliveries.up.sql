@@ -0,0 +1,15 @@
+CREATE TABLE callback_deliveries (
+    tenant          TEXT        NOT NULL,
+    id              TEXT        NOT NULL,
+    job_id          TEXT        NOT NULL,
+    created_at      TIMESTAMPTZ NOT NULL,
+    updated_at      TIMESTAMPTZ NOT NULL,
+    state           TEXT        NOT NULL,
+    next_attempt_at TIMESTAMPTZ,
+    delivery        JSONB       NOT NULL,
+    PRIMARY KEY (tenant, id)
+);
+
+CREATE INDEX callback_deliveries_created_idx ON callback_deliveries (tenant, created_at, id);
+
+CREATE INDEX callback_deliveries_due_idx ON callback_deliveries (next_attempt_at) WHERE state = 'pending';
@@ -0,0 +1,5 @@
+DROP INDEX evaluation_jobs_model_idx;
+
+DROP INDEX evaluation_jobs_updated_idx;
+
+DROP INDEX evaluation_jobs_config_idx;
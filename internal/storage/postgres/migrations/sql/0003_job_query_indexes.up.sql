@@ -0,0 +1,5 @@
+CREATE INDEX evaluation_jobs_config_idx ON evaluation_jobs USING GIN (config jsonb_path_ops);
+
+CREATE INDEX evaluation_jobs_updated_idx ON evaluation_jobs (tenant, updated_at, created_at, id);
+
+CREATE INDEX evaluation_jobs_model_idx ON evaluation_jobs (tenant, model_name, created_at, id);
@@ -0,0 +1 @@
+DROP INDEX evaluation_jobs_results_idx;
@@ -0,0 +1 @@
+CREATE INDEX evaluation_jobs_results_idx ON evaluation_jobs USING GIN (results jsonb_path_ops);
@@ -0,0 +1 @@
+ALTER TABLE evaluation_jobs DROP COLUMN owner;
@@ -0,0 +1 @@
+ALTER TABLE evaluation_jobs ADD COLUMN owner TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,747 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/abstractions"
+	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/aggregation"
+	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/callbacks"
+	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/config"
+	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/state_machine"
+	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/storage/postgres/migrations"
+	"github.ibm.com/julpayne/eval-hub-backend-svc/pkg/api"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const jobColumns = "tenant, id, created_at, updated_at, owner, config, status, results"
+
+const collectionColumns = "tenant, id, created_at, updated_at, config"
+
+const callbackColumns = "tenant, id, created_at, updated_at, state, next_attempt_at, delivery"
+
+// Storage is a PostgreSQL implementation of abstractions.Storage. The user supplied configuration,
+// the status and the results of a resource are stored as JSONB documents next to the columns that
+// are needed for scoping, filtering and ordering.
+type Storage struct {
+	pool   *pgxpool.Pool
+	logger *slog.Logger
+	tenant api.Tenant
+}
+
+var _ abstractions.Storage = (*Storage)(nil)
+
+// NewStorage connects to the database described by the configuration, when auto migration is
+// enabled the pending schema migrations are applied first. The returned storage is scoped to the
+// default tenant.
+func NewStorage(logger *slog.Logger, databaseConfig *config.DatabaseConfig) (*Storage, error) {
+	ctx := context.Background()
+	pool, err := NewPool(ctx, logger, databaseConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	if databaseConfig.AutoMigrate {
+		migrator, err := migrations.NewMigrator(logger, pool)
+		if err != nil {
+			pool.Close()
+			return nil, err
+		}
+		if _, err := migrator.Up(ctx); err != nil {
+			pool.Close()
+			return nil, err
+		}
+	}
+
+	return &Storage{
+		pool:   pool,
+		logger: logger,
+		tenant: abstractions.DefaultTenant,
+	}, nil
+}
+
+// NewPool creates a connection pool for the database described by the configuration. When a schema
+// is configured it is created if needed and used as the search path of every connection.
+func NewPool(ctx context.Context, logger *slog.Logger, databaseConfig *config.DatabaseConfig) (*pgxpool.Pool, error) {
+	if databaseConfig == nil {
+		return nil, fmt.Errorf("database config is required for the postgres storage")
+	}
+
+	poolConfig, err := pgxpool.ParseConfig(databaseConfig.ConnectionURL())
+	if err != nil {
+		return nil, fmt.Errorf("invalid database configuration: %w", err)
+	}
+	if databaseConfig.Schema != "" {
+		poolConfig.ConnConfig.RuntimeParams["search_path"] = databaseConfig.Schema
+	}
+
+	pool, err := pgxpool.NewWithConfig(ctx, poolConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to the database: %w", err)
+	}
+
+	if databaseConfig.Schema != "" {
+		if _, err := pool.Exec(ctx, "CREATE SCHEMA IF NOT EXISTS "+pgx.Identifier{databaseConfig.Schema}.Sanitize()); err != nil {
+			pool.Close()
+			return nil, fmt.Errorf("failed to create the database schema %s: %w", databaseConfig.Schema, err)
+		}
+	}
+
+	logger.Info("Connected to the database", "host", poolConfig.ConnConfig.Host, "database", poolConfig.ConnConfig.Database, "schema", databaseConfig.Schema)
+	return pool, nil
+}
+
+func (s *Storage) WithTenant(tenant api.Tenant) abstractions.Storage {
+	return &Storage{pool: s.pool, logger: s.logger, tenant: tenant}
+}
+
+func (s *Storage) Close() error {
+	s.pool.Close()
+	return nil
+}
+
+func (s *Storage) CreateEvaluationJob(evaluation *api.EvaluationJobResource) error {
+	if evaluation.ID == "" {
+		return fmt.Errorf("the %s ID is required", abstractions.ResourceEvaluationJob)
+	}
+
+	stampNew(&evaluation.Resource, s.tenant)
+	tag, err := s.pool.Exec(context.Background(),
+		`INSERT INTO evaluation_jobs (tenant, id, created_at, updated_at, owner, state, model_name, config, status, results)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		 ON CONFLICT (tenant, id) DO NOTHING`,
+		evaluation.Tenant, evaluation.ID, evaluation.CreatedAt, evaluation.UpdatedAt, evaluation.Owner,
+		evaluation.Status.State, evaluation.Model.Name, evaluation.EvaluationJobConfig, evaluation.Status, evaluation.Results)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return &abstractions.ConflictError{Resource: abstractions.ResourceEvaluationJob, ID: evaluation.ID}
+	}
+	return nil
+}
+
+func (s *Storage) GetEvaluationJob(id string) (*api.EvaluationJobResource, error) {
+	row := s.pool.QueryRow(context.Background(),
+		"SELECT "+jobColumns+" FROM evaluation_jobs WHERE tenant = $1 AND id = $2", s.tenant, id)
+	job, err := scanJob(row)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, &abstractions.NotFoundError{Resource: abstractions.ResourceEvaluationJob, ID: id}
+	}
+	return job, err
+}
+
+func (s *Storage) GetEvaluationJobs(query *abstractions.JobQuery) (*api.EvaluationJobResourceList, error) {
+	if err := query.Validate(); err != nil {
+		return nil, err
+	}
+	order := ordering{column: jobSortColumns[query.Sort.Field], descending: query.Sort.Descending}
+	if (query.Page.After != nil) && (order.column != "") {
+		after, err := query.Sort.PositionJob(query.Page.After)
+		if err != nil {
+			return nil, err
+		}
+		order.after = query.Sort.Value(after)
+	}
+
+	list := &api.EvaluationJobResourceList{Items: []api.EvaluationJobResource{}}
+	where, args := jobWhere(s.tenant, query)
+	page, err := s.listPage("evaluation_jobs", jobColumns, where, args, &query.Page, order, func(rows pgx.Rows) error {
+		item, err := scanJob(rows)
+		if err != nil {
+			return err
+		}
+		list.Items = append(list.Items, *item)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	list.Page = *page
+	return list, nil
+}
+
+func (s *Storage) DeleteEvaluationJob(id string) error {
+	ctx := context.Background()
+	return pgx.BeginFunc(ctx, s.pool, func(tx pgx.Tx) error {
+		tag, err := tx.Exec(ctx, "DELETE FROM evaluation_jobs WHERE tenant = $1 AND id = $2", s.tenant, id)
+		if err != nil {
+			return err
+		}
+		if tag.RowsAffected() == 0 {
+			return &abstractions.NotFoundError{Resource: abstractions.ResourceEvaluationJob, ID: id}
+		}
+		_, err = tx.Exec(ctx, "DELETE FROM callback_deliveries WHERE tenant = $1 AND job_id = $2", s.tenant, id)
+		return err
+	})
+}
+
+func (s *Storage) UpdateBenchmarkStatusForJob(id string, status api.BenchmarkStatus) error {
+	_, _, err := s.updateJob(id, func(job *api.EvaluationJobResource, now time.Time) (bool, error) {
+		return true, state_machine.ApplyBenchmarkStatus(id, &job.Status, status, now)
+	})
+	return err
+}
+
+func (s *Storage) UpdateEvaluationJobStatus(id string, state api.EvaluationJobState) error {
+	_, _, err := s.updateJob(id, func(job *api.EvaluationJobResource, now time.Time) (bool, error) {
+		return true, state_machine.ApplyJobState(id, &job.Status, state, now)
+	})
+	return err
+}
+
+func (s *Storage) RecordBenchmarkResult(id string, result api.EvaluationJobBenchmarkResult) (*api.EvaluationJobResource, bool, error) {
+	return s.updateJob(id, func(job *api.EvaluationJobResource, now time.Time) (bool, error) {
+		return aggregation.RecordResult(job, result, now)
+	})
+}
+
+// leaderboardQuery ranks the models with the rules of aggregation.Leaderboard. The scores are the
+// means of the metric over the completed results of the benchmarks in the results of every job,
+// the containment of the completed benchmarks uses the GIN index on the results. The placeholders
+// are the order of the scores of a model, and the order of the scores and of the completion times
+// of the ranking.
+const leaderboardQuery = `
+WITH scores AS (
+	SELECT j.id, j.model_name, j.config->'model'->>'url' AS model_url,
+	       avg(m.value::text::float8) AS score, max(m.completed_at) AS completed_at,
+	       COALESCE((j.results->>'completed_evaluations')::float8, 0) / (j.results->>'total_evaluations')::float8 AS completeness
+	FROM evaluation_jobs AS j
+	CROSS JOIN LATERAL (
+		SELECT (r->>'completed_at')::timestamptz AS completed_at,
+		       COALESCE(r->'metrics'->$3::text, r->'metrics' #> string_to_array($3::text, '.')) AS value
+		FROM jsonb_array_elements(j.results->'benchmarks') AS r
+		WHERE r->>'id' = ANY($4) AND r->>'state' = 'completed' AND r ? 'completed_at'
+	) AS m
+	WHERE j.tenant = $1 AND j.results @> $2 AND (j.results->>'total_evaluations')::float8 > 0
+	  AND jsonb_typeof(m.value) = 'number'
+	GROUP BY j.tenant, j.id
+	HAVING count(*) = $5
+), models AS (
+	SELECT DISTINCT ON (model_name, model_url) id, model_name, model_url, score, completed_at, completeness,
+	       count(*) OVER (PARTITION BY model_name, model_url) AS evaluations
+	FROM scores
+	WHERE completeness >= $6
+	ORDER BY model_name, model_url, %s, id
+)
+SELECT id, model_name, model_url, score, completed_at, completeness, evaluations
+FROM models
+ORDER BY score %s, completed_at %s, model_name, model_url
+LIMIT $7`
+
+func (s *Storage) GetLeaderboard(query *abstractions.LeaderboardQuery) ([]api.LeaderboardEntry, error) {
+	if err := query.Validate(); err != nil {
+		return nil, err
+	}
+	scoreOrder, completionOrder := "DESC", "ASC"
+	if query.Ascending {
+		scoreOrder = "ASC"
+	}
+	if query.TieBreak == abstractions.LeaderboardMostRecent {
+		completionOrder = "DESC"
+	}
+	modelOrder := fmt.Sprintf("completed_at DESC, score %s", scoreOrder)
+	if query.Mode == abstractions.LeaderboardBest {
+		modelOrder = fmt.Sprintf("score %s, completed_at %s", scoreOrder, completionOrder)
+	}
+	completed := make([]any, 0, len(query.Benchmarks))
+	for _, benchmark := range query.Benchmarks {
+		completed = append(completed, map[string]any{"id": benchmark, "state": api.StateCompleted})
+	}
+
+	ctx := context.Background()
+	rows, err := s.pool.Query(ctx, fmt.Sprintf(leaderboardQuery, modelOrder, scoreOrder, completionOrder),
+		s.tenant, map[string]any{"benchmarks": completed}, query.Metric, query.Benchmarks, len(query.Benchmarks),
+		query.MinCompleteness, query.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []api.LeaderboardEntry{}
+	for rows.Next() {
+		entry := api.LeaderboardEntry{Rank: len(entries) + 1}
+		err := rows.Scan(&entry.JobID, &entry.Model.Name, &entry.Model.URL, &entry.Score, &entry.CompletedAt, &entry.Completeness, &entry.Evaluations)
+		if err != nil {
+			return nil, err
+		}
+		entry.CompletedAt = entry.CompletedAt.UTC()
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
+
+// updateJob applies an update to the status and the results of a job while holding a row lock so
+// that concurrent benchmark updates for the same job are not lost, the callback delivery of a state
+// change is queued in the same transaction. Nothing is written when the update reports no change.
+func (s *Storage) updateJob(id string, update func(job *api.EvaluationJobResource, now time.Time) (bool, error)) (*api.EvaluationJobResource, bool, error) {
+	ctx := context.Background()
+	var job *api.EvaluationJobResource
+	changed := false
+	err := pgx.BeginFunc(ctx, s.pool, func(tx pgx.Tx) error {
+		var err error
+		job, err = scanJob(tx.QueryRow(ctx,
+			"SELECT "+jobColumns+" FROM evaluation_jobs WHERE tenant = $1 AND id = $2 FOR UPDATE", s.tenant, id))
+		if errors.Is(err, pgx.ErrNoRows) {
+			return &abstractions.NotFoundError{Resource: abstractions.ResourceEvaluationJob, ID: id}
+		}
+		if err != nil {
+			return err
+		}
+
+		updatedAt := now()
+		previous := job.Status.State
+		if changed, err = update(job, updatedAt); (err != nil) || !changed {
+			return err
+		}
+		job.UpdatedAt = updatedAt
+
+		_, err = tx.Exec(ctx,
+			"UPDATE evaluation_jobs SET state = $3, status = $4, results = $5, updated_at = $6 WHERE tenant = $1 AND id = $2",
+			s.tenant, id, job.Status.State, job.Status, job.Results, updatedAt)
+		if err != nil {
+			return err
+		}
+
+		delivery := callbacks.NewDelivery(job, previous, updatedAt)
+		if delivery == nil {
+			return nil
+		}
+		_, err = tx.Exec(ctx,
+			`INSERT INTO callback_deliveries (tenant, id, job_id, created_at, updated_at, state, next_attempt_at, delivery)
+			 VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+			delivery.Tenant, delivery.ID, delivery.JobID, delivery.CreatedAt, delivery.UpdatedAt,
+			delivery.State, delivery.NextAttemptAt, delivery)
+		return err
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	return job, changed, nil
+}
+
+func (s *Storage) GetCallbackDeliveries(query abstractions.Query) (*api.CallbackDeliveryResourceList, error) {
+	list := &api.CallbackDeliveryResourceList{Items: []api.CallbackDeliveryResource{}}
+	page, err := s.list("callback_deliveries", callbackColumns, query, map[string]string{
+		abstractions.QueryJobID: "job_id",
+		abstractions.QueryState: "state",
+	}, abstractions.ResourceCallback, func(rows pgx.Rows) error {
+		item, err := scanCallbackDelivery(rows)
+		if err != nil {
+			return err
+		}
+		list.Items = append(list.Items, *item)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	list.Page = *page
+	return list, nil
+}
+
+func (s *Storage) UpdateCallbackDelivery(delivery *api.CallbackDeliveryResource) error {
+	row := s.pool.QueryRow(context.Background(),
+		`UPDATE callback_deliveries SET state = $3, next_attempt_at = $4, delivery = $5, updated_at = $6
+		 WHERE tenant = $1 AND id = $2
+		 RETURNING tenant, created_at, updated_at`,
+		s.tenant, delivery.ID, delivery.State, delivery.NextAttemptAt, delivery, now())
+	// the identity of the resource is owned by the storage
+	err := row.Scan(&delivery.Tenant, &delivery.CreatedAt, &delivery.UpdatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return &abstractions.NotFoundError{Resource: abstractions.ResourceCallback, ID: delivery.ID}
+	}
+	return err
+}
+
+func (s *Storage) ClaimCallbackDeliveries(now time.Time, lease time.Duration, limit int) ([]api.CallbackDeliveryResource, error) {
+	// SKIP LOCKED lets several replicas claim disjoint batches
+	rows, err := s.pool.Query(context.Background(),
+		`UPDATE callback_deliveries SET next_attempt_at = $2
+		 WHERE (tenant, id) IN (
+		     SELECT tenant, id FROM callback_deliveries
+		     WHERE state = 'pending' AND next_attempt_at <= $1
+		     ORDER BY next_attempt_at, created_at, id
+		     LIMIT $3
+		     FOR UPDATE SKIP LOCKED)
+		 RETURNING `+callbackColumns,
+		now, now.Add(lease), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	claimed := []api.CallbackDeliveryResource{}
+	for rows.Next() {
+		delivery, err := scanCallbackDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+		claimed = append(claimed, *delivery)
+	}
+	return claimed, rows.Err()
+}
+
+func (s *Storage) CreateCollection(collection *api.CollectionResource) error {
+	if collection.ID == "" {
+		return fmt.Errorf("the %s ID is required", abstractions.ResourceCollection)
+	}
+
+	stampNew(&collection.Resource, s.tenant)
+	tag, err := s.pool.Exec(context.Background(),
+		`INSERT INTO collections (tenant, id, created_at, updated_at, name, config)
+		 VALUES ($1, $2, $3, $4, $5, $6)
+		 ON CONFLICT (tenant, id) DO NOTHING`,
+		collection.Tenant, collection.ID, collection.CreatedAt, collection.UpdatedAt, collection.Name, collection.CollectionConfig)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return &abstractions.ConflictError{Resource: abstractions.ResourceCollection, ID: collection.ID}
+	}
+	return nil
+}
+
+func (s *Storage) GetCollection(id string) (*api.CollectionResource, error) {
+	row := s.pool.QueryRow(context.Background(),
+		"SELECT "+collectionColumns+" FROM collections WHERE tenant = $1 AND id = $2", s.tenant, id)
+	collection, err := scanCollection(row)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, &abstractions.NotFoundError{Resource: abstractions.ResourceCollection, ID: id}
+	}
+	return collection, err
+}
+
+func (s *Storage) GetCollections(query abstractions.Query) (*api.CollectionResourceList, error) {
+	list := &api.CollectionResourceList{Items: []api.CollectionResource{}}
+	page, err := s.list("collections", collectionColumns, query, map[string]string{
+		abstractions.QueryName: "name",
+	}, abstractions.ResourceCollection, func(rows pgx.Rows) error {
+		item, err := scanCollection(rows)
+		if err != nil {
+			return err
+		}
+		list.Items = append(list.Items, *item)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	list.Page = *page
+	return list, nil
+}
+
+func (s *Storage) UpdateCollection(collection *api.CollectionResource) error {
+	row := s.pool.QueryRow(context.Background(),
+		`UPDATE collections SET name = $3, config = $4, updated_at = $5
+		 WHERE tenant = $1 AND id = $2
+		 RETURNING tenant, created_at, updated_at`,
+		s.tenant, collection.ID, collection.Name, collection.CollectionConfig, now())
+	// the identity of the resource is owned by the storage
+	err := row.Scan(&collection.Tenant, &collection.CreatedAt, &collection.UpdatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return &abstractions.NotFoundError{Resource: abstractions.ResourceCollection, ID: collection.ID}
+	}
+	return err
+}
+
+// PatchCollection holds a row lock while the patch is applied, so that concurrent patches of the
+// same collection are applied one after the other
+func (s *Storage) PatchCollection(id string, patch func(config *api.CollectionConfig) error) (*api.CollectionResource, error) {
+	ctx := context.Background()
+	var collection *api.CollectionResource
+	err := pgx.BeginFunc(ctx, s.pool, func(tx pgx.Tx) error {
+		var err error
+		collection, err = scanCollection(tx.QueryRow(ctx,
+			"SELECT "+collectionColumns+" FROM collections WHERE tenant = $1 AND id = $2 FOR UPDATE", s.tenant, id))
+		if errors.Is(err, pgx.ErrNoRows) {
+			return &abstractions.NotFoundError{Resource: abstractions.ResourceCollection, ID: id}
+		}
+		if err != nil {
+			return err
+		}
+		if err := patch(&collection.CollectionConfig); err != nil {
+			return err
+		}
+		collection.UpdatedAt = now()
+		_, err = tx.Exec(ctx,
+			"UPDATE collections SET name = $3, config = $4, updated_at = $5 WHERE tenant = $1 AND id = $2",
+			s.tenant, id, collection.Name, collection.CollectionConfig, collection.UpdatedAt)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return collection, nil
+}
+
+func (s *Storage) DeleteCollection(id string) error {
+	tag, err := s.pool.Exec(context.Background(), "DELETE FROM collections WHERE tenant = $1 AND id = $2", s.tenant, id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return &abstractions.NotFoundError{Resource: abstractions.ResourceCollection, ID: id}
+	}
+	return nil
+}
+
+// list runs a listing of the tenant with the query filters and pagination, every row is passed to
+// scan. The page counts all the rows matching the filters.
+func (s *Storage) list(table string, columns string, query abstractions.Query, filterColumns map[string]string, resource string, scan func(rows pgx.Rows) error) (*api.Page, error) {
+	pageQuery, err := query.Page()
+	if err != nil {
+		return nil, err
+	}
+	where, args, err := buildWhere(s.tenant, query.Filters(), filterColumns, resource)
+	if err != nil {
+		return nil, err
+	}
+	return s.listPage(table, columns, where, args, pageQuery, ordering{}, scan)
+}
+
+// listPage runs a listing with the WHERE clause and its arguments in the given order
+func (s *Storage) listPage(table string, columns string, where string, args []any, pageQuery *abstractions.PageQuery, order ordering, scan func(rows pgx.Rows) error) (*api.Page, error) {
+	ctx := context.Background()
+	page := &api.Page{Limit: pageQuery.Limit}
+	if err := s.pool.QueryRow(ctx, "SELECT count(*) FROM "+table+" WHERE "+where, args...).Scan(&page.TotalCount); err != nil {
+		return nil, err
+	}
+	if page.Limit == 0 {
+		page.Limit = page.TotalCount
+	}
+
+	clause, args := pageClause(where, args, pageQuery, order)
+	rows, err := s.pool.Query(ctx, "SELECT "+columns+" FROM "+table+" WHERE "+clause, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		if err := scan(rows); err != nil {
+			return nil, err
+		}
+	}
+	return page, rows.Err()
+}
+
+// ordering orders a listing by the column, when it is set, and then by creation time and ID, all in
+// the same direction
+type ordering struct {
+	column     string
+	descending bool
+	// after is the value of the column at the position of the page
+	after any
+}
+
+// pageClause appends the position, the order and the limits of the page to the WHERE clause, the
+// (tenant, [column,] created_at, id) indexes serve it
+func pageClause(where string, args []any, page *abstractions.PageQuery, order ordering) (string, []any) {
+	keys := "created_at, id"
+	if order.column != "" {
+		keys = order.column + ", " + keys
+	}
+	if page.After != nil {
+		placeholders := []string{}
+		if order.column != "" {
+			args = append(args, order.after)
+			placeholders = append(placeholders, fmt.Sprintf("$%d", len(args)))
+		}
+		args = append(args, page.After.CreatedAt, page.After.ID)
+		placeholders = append(placeholders, fmt.Sprintf("$%d", len(args)-1), fmt.Sprintf("$%d", len(args)))
+		operator := ">"
+		if order.descending {
+			operator = "<"
+		}
+		where += fmt.Sprintf(" AND (%s) %s (%s)", keys, operator, strings.Join(placeholders, ", "))
+	}
+	clause := where + " ORDER BY " + keys
+	if order.descending {
+		clause = where + " ORDER BY " + strings.ReplaceAll(keys, ",", " DESC,") + " DESC"
+	}
+	if page.Limit > 0 {
+		args = append(args, page.Limit)
+		clause += fmt.Sprintf(" LIMIT $%d", len(args))
+	}
+	if page.Offset > 0 {
+		args = append(args, page.Offset)
+		clause += fmt.Sprintf(" OFFSET $%d", len(args))
+	}
+	return clause, args
+}
+
+// jobSortColumns are the columns of the job sort fields, the creation time is always ordered
+var jobSortColumns = map[abstractions.JobSortField]string{
+	abstractions.JobSortUpdatedAt: "updated_at",
+	abstractions.JobSortModelName: "model_name",
+	abstractions.JobSortState:     "state",
+}
+
+// jobWhere converts the job query into a WHERE clause, the tenant is the first argument unless the
+// jobs of all the tenants are listed. The filters on the configuration are a single JSONB
+// containment served by the GIN index of the column.
+func jobWhere(tenant api.Tenant, query *abstractions.JobQuery) (string, []any) {
+	conditions := []string{}
+	args := []any{}
+	add := func(condition string, value any) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if !query.AllTenants {
+		add("tenant = $%d", tenant)
+	}
+
+	if len(query.States) > 0 {
+		states := make([]string, 0, len(query.States))
+		for _, state := range query.States {
+			states = append(states, string(state))
+		}
+		add("state = ANY($%d)", states)
+	}
+	if query.ModelName != "" {
+		add("model_name = $%d", query.ModelName)
+	}
+
+	contains := map[string]any{}
+	if query.ModelURL != "" {
+		contains["model"] = map[string]any{"url": query.ModelURL}
+	}
+	if query.BenchmarkID != "" {
+		contains["benchmarks"] = []any{map[string]any{"id": query.BenchmarkID}}
+	}
+	if query.CollectionID != "" {
+		contains["collection"] = map[string]any{"id": query.CollectionID}
+	}
+	experiment := map[string]any{}
+	if query.ExperimentName != "" {
+		experiment["name"] = query.ExperimentName
+	}
+	tags := map[string]string{}
+	keys := make([]string, 0, len(query.ExperimentTags))
+	for key, value := range query.ExperimentTags {
+		if value != "" {
+			tags[key] = value
+		} else {
+			keys = append(keys, key)
+		}
+	}
+	if len(tags) > 0 {
+		experiment["tags"] = tags
+	}
+	if len(experiment) > 0 {
+		contains["experiment"] = experiment
+	}
+	if len(contains) > 0 {
+		add("config @> $%d", contains)
+	}
+	slices.Sort(keys)
+	for _, key := range keys {
+		add("config->'experiment'->'tags' ? $%d", key)
+	}
+
+	for _, bound := range []struct {
+		condition string
+		value     *time.Time
+	}{
+		{"created_at >= $%d", query.CreatedFrom},
+		{"created_at < $%d", query.CreatedTo},
+		{"updated_at >= $%d", query.UpdatedFrom},
+		{"updated_at < $%d", query.UpdatedTo},
+	} {
+		if bound.value != nil {
+			add(bound.condition, *bound.value)
+		}
+	}
+	if len(conditions) == 0 {
+		return "TRUE", args
+	}
+	return strings.Join(conditions, " AND "), args
+}
+
+// buildWhere converts the query filters into a WHERE clause, the tenant is always the first argument
+func buildWhere(tenant api.Tenant, query abstractions.Query, columns map[string]string, resource string) (string, []any, error) {
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		if _, ok := columns[key]; !ok {
+			return "", nil, fmt.Errorf("unsupported query filter %q for %s resources", key, resource)
+		}
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+
+	conditions := []string{"tenant = $1"}
+	args := []any{tenant}
+	for _, key := range keys {
+		args = append(args, query[key])
+		conditions = append(conditions, fmt.Sprintf("%s = $%d", columns[key], len(args)))
+	}
+	return strings.Join(conditions, " AND "), args, nil
+}
+
+func scanJob(row pgx.Row) (*api.EvaluationJobResource, error) {
+	job := &api.EvaluationJobResource{}
+	err := row.Scan(&job.Tenant, &job.ID, &job.CreatedAt, &job.UpdatedAt, &job.Owner, &job.EvaluationJobConfig, &job.Status, &job.Results)
+	if err != nil {
+		return nil, err
+	}
+	job.CreatedAt = job.CreatedAt.UTC()
+	job.UpdatedAt = job.UpdatedAt.UTC()
+	return job, nil
+}
+
+func scanCollection(row pgx.Row) (*api.CollectionResource, error) {
+	collection := &api.CollectionResource{}
+	err := row.Scan(&collection.Tenant, &collection.ID, &collection.CreatedAt, &collection.UpdatedAt, &collection.CollectionConfig)
+	if err != nil {
+		return nil, err
+	}
+	collection.CreatedAt = collection.CreatedAt.UTC()
+	collection.UpdatedAt = collection.UpdatedAt.UTC()
+	return collection, nil
+}
+
+// scanCallbackDelivery reads a delivery, the columns take precedence over the JSONB document
+func scanCallbackDelivery(row pgx.Row) (*api.CallbackDeliveryResource, error) {
+	delivery := &api.CallbackDeliveryResource{}
+	var tenant api.Tenant
+	var id string
+	var createdAt, updatedAt time.Time
+	var state api.CallbackDeliveryState
+	var nextAttemptAt *time.Time
+	err := row.Scan(&tenant, &id, &createdAt, &updatedAt, &state, &nextAttemptAt, delivery)
+	if err != nil {
+		return nil, err
+	}
+	delivery.Resource = api.Resource{ID: id, Tenant: tenant, CreatedAt: createdAt.UTC(), UpdatedAt: updatedAt.UTC()}
+	delivery.State = state
+	delivery.NextAttemptAt = nil
+	if nextAttemptAt != nil {
+		next := nextAttemptAt.UTC()
+		delivery.NextAttemptAt = &next
+	}
+	return delivery, nil
+}
+
+// stampNew sets the tenant and the timestamps of a resource that is about to be created
+func stampNew(resource *api.Resource, tenant api.Tenant) {
+	resource.Tenant = tenant
+	if resource.CreatedAt.IsZero() {
+		resource.CreatedAt = now()
+	} else {
+		resource.CreatedAt = resource.CreatedAt.UTC().Truncate(time.Microsecond)
+	}
+	resource.UpdatedAt = now()
+}
+
+// now returns the current time with the precision of a TIMESTAMPTZ column
+func now() time.Time {
+	return time.Now().UTC().Truncate(time.Microsecond)
+}
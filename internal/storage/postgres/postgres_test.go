@@ -0,0 +1,458 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/abstractions"
+	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/config"
+	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/logging"
+	"github.ibm.com/julpayne/eval-hub-backend-svc/pkg/api"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// The tests need a PostgreSQL server, point EVAL_HUB_TEST_DATABASE_URL at a local or embedded
+// instance (for example the one created by `make start-postgres create-database`) to run them.
+const testDatabaseURLEnv = "EVAL_HUB_TEST_DATABASE_URL"
+
+// newTestStorage creates a storage in a schema of its own that is dropped when the test ends
+func newTestStorage(t *testing.T) *Storage {
+	t.Helper()
+	databaseURL := os.Getenv(testDatabaseURLEnv)
+	if databaseURL == "" {
+		t.Skipf("%s is not set, skipping PostgreSQL tests", testDatabaseURLEnv)
+	}
+
+	logger, err := logging.NewLogger()
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	schemaName := fmt.Sprintf("eval_hub_test_%d", time.Now().UnixNano())
+	storage, err := NewStorage(logger, &config.DatabaseConfig{URL: databaseURL, Schema: schemaName, AutoMigrate: true})
+	if err != nil {
+		t.Fatalf("NewStorage() returned error: %v", err)
+	}
+	t.Cleanup(func() {
+		storage.pool.Exec(context.Background(), "DROP SCHEMA "+pgx.Identifier{schemaName}.Sanitize()+" CASCADE")
+		storage.Close()
+	})
+	return storage
+}
+
+func newJob(id string, modelName string, state api.State) *api.EvaluationJobResource {
+	limit := 10
+	return &api.EvaluationJobResource{
+		Resource: api.Resource{ID: id},
+		EvaluationJobConfig: api.EvaluationJobConfig{
+			Model:      api.ModelRef{URL: "http://model", Name: modelName},
+			Benchmarks: []api.BenchmarkConfig{{Ref: api.Ref{ID: "mmlu"}, Limit: &limit}},
+		},
+		Status: api.EvaluationJobStatus{
+			EvaluationJobState: api.EvaluationJobState{State: state},
+		},
+	}
+}
+
+func TestEvaluationJobs(t *testing.T) {
+	storage := newTestStorage(t)
+
+	t.Run("create and get round trips the resource", func(t *testing.T) {
+		job := newJob("job-1", "granite", api.StatePending)
+		job.Results = &api.EvaluationJobResults{
+			TotalEvaluations: 1,
+			Benchmarks: []api.EvaluationJobBenchmarkResult{
+				{ID: "mmlu", State: api.StateCompleted, Metrics: map[string]any{"accuracy": 0.5}},
+			},
+		}
+		job.Owner = "alice"
+		if err := storage.CreateEvaluationJob(job); err != nil {
+			t.Fatalf("CreateEvaluationJob() returned error: %v", err)
+		}
+
+		got, err := storage.GetEvaluationJob("job-1")
+		if err != nil {
+			t.Fatalf("GetEvaluationJob() returned error: %v", err)
+		}
+		if got.Tenant != abstractions.DefaultTenant || got.Owner != "alice" {
+			t.Errorf("Expected tenant %s and owner alice, got %s and %q", abstractions.DefaultTenant, got.Tenant, got.Owner)
+		}
+		if !got.CreatedAt.Equal(job.CreatedAt) {
+			t.Errorf("Expected created_at %v, got %v", job.CreatedAt, got.CreatedAt)
+		}
+		if got.Model.Name != "granite" || *got.Benchmarks[0].Limit != 10 {
+			t.Errorf("Expected the configuration to be stored, got %+v", got.EvaluationJobConfig)
+		}
+		if got.Results == nil || got.Results.Benchmarks[0].Metrics["accuracy"] != 0.5 {
+			t.Errorf("Expected the results to be stored, got %+v", got.Results)
+		}
+	})
+
+	t.Run("create with an existing ID returns a conflict", func(t *testing.T) {
+		err := storage.CreateEvaluationJob(newJob("job-1", "granite", api.StatePending))
+		if !abstractions.IsConflict(err) {
+			t.Errorf("Expected a conflict error, got %v", err)
+		}
+	})
+
+	t.Run("status updates are persisted", func(t *testing.T) {
+		if err := storage.UpdateBenchmarkStatusForJob("job-1", api.BenchmarkStatus{Name: "mmlu", State: api.StateRunning}); err != nil {
+			t.Fatalf("UpdateBenchmarkStatusForJob() returned error: %v", err)
+		}
+		if err := storage.UpdateEvaluationJobStatus("job-1", api.EvaluationJobState{State: api.StateRunning, Message: "started"}); err != nil {
+			t.Fatalf("UpdateEvaluationJobStatus() returned error: %v", err)
+		}
+
+		got, _ := storage.GetEvaluationJob("job-1")
+		if got.Status.State != api.StateRunning || got.Status.Message != "started" {
+			t.Errorf("Expected the job to be running, got %+v", got.Status.EvaluationJobState)
+		}
+		if len(got.Status.Benchmarks) != 1 || got.Status.Benchmarks[0].State != api.StateRunning {
+			t.Errorf("Expected one running benchmark, got %+v", got.Status.Benchmarks)
+		}
+	})
+
+	t.Run("list honours the query filters", func(t *testing.T) {
+		storage.CreateEvaluationJob(newJob("job-2", "llama", api.StatePending))
+
+		list, err := storage.GetEvaluationJobs(&abstractions.JobQuery{States: []api.State{api.StateRunning}})
+		if err != nil {
+			t.Fatalf("GetEvaluationJobs() returned error: %v", err)
+		}
+		if list.TotalCount != 1 || list.Items[0].ID != "job-1" {
+			t.Errorf("Expected only job-1, got %+v", list.Items)
+		}
+
+		list, _ = storage.GetEvaluationJobs(&abstractions.JobQuery{})
+		if list.TotalCount != 2 {
+			t.Errorf("Expected 2 jobs, got %d", list.TotalCount)
+		}
+
+		if _, err := storage.GetEvaluationJobs(&abstractions.JobQuery{States: []api.State{"unknown"}}); err == nil {
+			t.Error("Expected an error for an invalid state")
+		}
+	})
+
+	t.Run("other tenants can not see the jobs", func(t *testing.T) {
+		other := storage.WithTenant("other")
+		if _, err := other.GetEvaluationJob("job-1"); !abstractions.IsNotFound(err) {
+			t.Errorf("Expected a not found error, got %v", err)
+		}
+		if err := other.UpdateEvaluationJobStatus("job-1", api.EvaluationJobState{State: api.StateFailed}); !abstractions.IsNotFound(err) {
+			t.Errorf("Expected a not found error, got %v", err)
+		}
+		list, err := other.GetEvaluationJobs(&abstractions.JobQuery{AllTenants: true})
+		if (err != nil) || (list.TotalCount == 0) {
+			t.Errorf("Expected the jobs of all the tenants, got %v", err)
+		}
+	})
+
+	t.Run("delete removes the job", func(t *testing.T) {
+		if err := storage.DeleteEvaluationJob("job-1"); err != nil {
+			t.Fatalf("DeleteEvaluationJob() returned error: %v", err)
+		}
+		if _, err := storage.GetEvaluationJob("job-1"); !abstractions.IsNotFound(err) {
+			t.Errorf("Expected a not found error, got %v", err)
+		}
+	})
+}
+
+func TestBenchmarkResults(t *testing.T) {
+	storage := newTestStorage(t)
+	storage.CreateEvaluationJob(newJob("job-1", "granite", api.StatePending))
+	result := api.EvaluationJobBenchmarkResult{ID: "mmlu", State: api.StateCompleted, AttemptID: "a1", Metrics: map[string]any{"acc": 0.5}}
+
+	job, recorded, err := storage.RecordBenchmarkResult("job-1", result)
+	if err != nil || !recorded {
+		t.Fatalf("Expected the result to be recorded, got %v", err)
+	}
+	got, _ := storage.GetEvaluationJob("job-1")
+	if (got.Status.State != api.StateCompleted) || (got.Results == nil) || (got.Results.CompletedEvaluations != 1) || (got.Results.Benchmarks[0].AttemptID != "a1") {
+		t.Errorf("Expected the result to be persisted with the job, got %+v", got)
+	}
+	if !got.UpdatedAt.Equal(job.UpdatedAt) {
+		t.Errorf("Expected the returned job to be the stored one, got %v and %v", job.UpdatedAt, got.UpdatedAt)
+	}
+
+	if _, recorded, err := storage.RecordBenchmarkResult("job-1", result); err != nil || recorded {
+		t.Errorf("Expected the same attempt not to be recorded again, got %v, %v", recorded, err)
+	}
+	result.AttemptID = "a2"
+	if _, _, err := storage.RecordBenchmarkResult("job-1", result); !abstractions.IsConflict(err) {
+		t.Errorf("Expected a conflict for another attempt, got %v", err)
+	}
+	if _, _, err := storage.WithTenant("other").RecordBenchmarkResult("job-1", result); !abstractions.IsNotFound(err) {
+		t.Errorf("Expected a not found error, got %v", err)
+	}
+}
+
+func TestCollections(t *testing.T) {
+	storage := newTestStorage(t)
+	description := "knowledge"
+
+	err := storage.CreateCollection(&api.CollectionResource{
+		Resource:         api.Resource{ID: "col-1"},
+		CollectionConfig: api.CollectionConfig{Name: "original", Description: &description, Benchmarks: []string{"mmlu"}},
+	})
+	if err != nil {
+		t.Fatalf("CreateCollection() returned error: %v", err)
+	}
+	created, err := storage.GetCollection("col-1")
+	if err != nil {
+		t.Fatalf("GetCollection() returned error: %v", err)
+	}
+
+	updated := &api.CollectionResource{
+		Resource:         api.Resource{ID: "col-1"},
+		CollectionConfig: api.CollectionConfig{Name: "updated", Benchmarks: []string{"mmlu", "arc"}},
+	}
+	if err := storage.UpdateCollection(updated); err != nil {
+		t.Fatalf("UpdateCollection() returned error: %v", err)
+	}
+	if !updated.CreatedAt.Equal(created.CreatedAt) || updated.Tenant != abstractions.DefaultTenant {
+		t.Errorf("Expected the identity to be kept, got %+v", updated.Resource)
+	}
+
+	list, err := storage.GetCollections(abstractions.Query{abstractions.QueryName: "updated"})
+	if err != nil {
+		t.Fatalf("GetCollections() returned error: %v", err)
+	}
+	if len(list.Items) != 1 || len(list.Items[0].Benchmarks) != 2 {
+		t.Errorf("Expected the updated collection, got %+v", list.Items)
+	}
+
+	patched, err := storage.PatchCollection("col-1", func(config *api.CollectionConfig) error {
+		config.Benchmarks = append(config.Benchmarks, "hellaswag")
+		return nil
+	})
+	if (err != nil) || (len(patched.Benchmarks) != 3) {
+		t.Errorf("Expected the patch to be stored, got %+v and error %v", patched, err)
+	}
+	if _, err := storage.PatchCollection("col-1", func(*api.CollectionConfig) error { return fmt.Errorf("invalid") }); err == nil {
+		t.Error("Expected the error of the patch")
+	}
+
+	if err := storage.DeleteCollection("col-1"); err != nil {
+		t.Fatalf("DeleteCollection() returned error: %v", err)
+	}
+	if err := storage.DeleteCollection("col-1"); !abstractions.IsNotFound(err) {
+		t.Errorf("Expected a not found error, got %v", err)
+	}
+}
+
+func TestCallbackDeliveries(t *testing.T) {
+	storage := newTestStorage(t)
+	job := newJob("job-1", "granite", api.StatePending)
+	url := "http://receiver/hook"
+	job.CallbackURL = &url
+	job.Status.Benchmarks = []api.BenchmarkStatus{{Name: "mmlu", State: api.StatePending}}
+	storage.CreateEvaluationJob(job)
+
+	if err := storage.UpdateBenchmarkStatusForJob("job-1", api.BenchmarkStatus{Name: "mmlu", State: api.StateRunning}); err != nil {
+		t.Fatalf("UpdateBenchmarkStatusForJob() returned error: %v", err)
+	}
+	list, err := storage.GetCallbackDeliveries(abstractions.Query{abstractions.QueryJobID: "job-1"})
+	if err != nil {
+		t.Fatalf("GetCallbackDeliveries() returned error: %v", err)
+	}
+	if (len(list.Items) != 1) || (list.Items[0].Payload.Job.Status.State != api.StateRunning) {
+		t.Fatalf("Expected one delivery of the running state, got %+v", list.Items)
+	}
+
+	now := time.Now().UTC()
+	claimed, err := storage.ClaimCallbackDeliveries(now, time.Minute, 10)
+	if (err != nil) || (len(claimed) != 1) {
+		t.Fatalf("Expected to claim the delivery, got %d and error %v", len(claimed), err)
+	}
+	if again, _ := storage.ClaimCallbackDeliveries(now, time.Minute, 10); len(again) != 0 {
+		t.Errorf("Expected a claimed delivery not to be claimed again, got %+v", again)
+	}
+
+	delivery := claimed[0]
+	delivery.State = api.CallbackDeliveryDelivered
+	delivery.NextAttemptAt = nil
+	delivery.Attempts = []api.CallbackAttempt{{AttemptedAt: now, StatusCode: 200}}
+	if err := storage.WithTenant(delivery.Tenant).UpdateCallbackDelivery(&delivery); err != nil {
+		t.Fatalf("UpdateCallbackDelivery() returned error: %v", err)
+	}
+	list, _ = storage.GetCallbackDeliveries(abstractions.Query{abstractions.QueryState: string(api.CallbackDeliveryDelivered)})
+	if (len(list.Items) != 1) || (len(list.Items[0].Attempts) != 1) {
+		t.Errorf("Expected the delivered callback, got %+v", list.Items)
+	}
+
+	storage.DeleteEvaluationJob("job-1")
+	if list, _ = storage.GetCallbackDeliveries(abstractions.Query{}); len(list.Items) != 0 {
+		t.Errorf("Expected the deliveries to be deleted with the job, got %+v", list.Items)
+	}
+}
+
+func TestPagination(t *testing.T) {
+	storage := newTestStorage(t)
+	created := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := range 5 {
+		job := newJob(fmt.Sprintf("job-%d", i), "granite", api.StatePending)
+		job.CreatedAt = created.Add(time.Duration(i) * time.Second)
+		storage.CreateEvaluationJob(job)
+	}
+	ids := func(list *api.EvaluationJobResourceList) string {
+		ids := []string{}
+		for _, job := range list.Items {
+			ids = append(ids, job.ID)
+		}
+		return strings.Join(ids, ",")
+	}
+
+	first, err := storage.GetEvaluationJobs(&abstractions.JobQuery{Page: abstractions.PageQuery{Limit: 2}})
+	if err != nil {
+		t.Fatalf("GetEvaluationJobs() returned error: %v", err)
+	}
+	if (ids(first) != "job-0,job-1") || (first.TotalCount != 5) || (first.Limit != 2) {
+		t.Errorf("Expected the first 2 of 5 jobs, got %s of %d", ids(first), first.TotalCount)
+	}
+
+	after, _ := abstractions.Query{abstractions.QueryAfter: abstractions.PositionAfter(&first.Items[1].Resource)}.Page()
+	next, _ := storage.GetEvaluationJobs(&abstractions.JobQuery{Page: abstractions.PageQuery{Limit: 2, After: after.After}})
+	if ids(next) != "job-2,job-3" {
+		t.Errorf("Expected the jobs after job-1, got %s", ids(next))
+	}
+
+	offset, _ := storage.GetEvaluationJobs(&abstractions.JobQuery{ModelName: "granite", Page: abstractions.PageQuery{Offset: 3}})
+	if (ids(offset) != "job-3,job-4") || (offset.TotalCount != 5) {
+		t.Errorf("Expected the last 2 jobs, got %s", ids(offset))
+	}
+
+	for _, query := range []abstractions.Query{{abstractions.QueryLimit: "0"}, {abstractions.QueryOffset: "x"}, {abstractions.QueryAfter: "job-1"}} {
+		if _, err := storage.GetCollections(query); err == nil {
+			t.Errorf("Expected an error for %v", query)
+		}
+	}
+}
+
+func TestJobQuery(t *testing.T) {
+	storage := newTestStorage(t)
+	created := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	jobs := []struct {
+		id         string
+		model      api.ModelRef
+		benchmarks []string
+		collection string
+		experiment api.ExperimentConfig
+	}{
+		{"job-a", api.ModelRef{Name: "granite", URL: "http://granite"}, []string{"mmlu", "arc"}, "col-1", api.ExperimentConfig{Name: "exp-1", Tags: map[string]string{"team": "nlp"}}},
+		{"job-b", api.ModelRef{Name: "llama", URL: "http://llama"}, []string{"mmlu"}, "", api.ExperimentConfig{Name: "exp-1", Tags: map[string]string{"team": "vision"}}},
+		{"job-c", api.ModelRef{Name: "granite", URL: "http://granite-2"}, []string{"hellaswag"}, "col-1", api.ExperimentConfig{Name: "exp-2", Tags: map[string]string{"priority": "high"}}},
+	}
+	for i, j := range jobs {
+		job := newJob(j.id, j.model.Name, api.StatePending)
+		job.CreatedAt = created.Add(time.Duration(i) * time.Hour)
+		job.Model = j.model
+		job.Benchmarks = nil
+		for _, id := range j.benchmarks {
+			job.Benchmarks = append(job.Benchmarks, api.BenchmarkConfig{Ref: api.Ref{ID: id}})
+		}
+		job.Collection = api.Ref{ID: j.collection}
+		job.Experiment = j.experiment
+		if err := storage.CreateEvaluationJob(job); err != nil {
+			t.Fatalf("CreateEvaluationJob() returned error: %v", err)
+		}
+	}
+	ids := func(list *api.EvaluationJobResourceList) string {
+		ids := []string{}
+		for _, job := range list.Items {
+			ids = append(ids, job.ID)
+		}
+		return strings.Join(ids, ",")
+	}
+	at := func(hours int) *time.Time {
+		value := created.Add(time.Duration(hours) * time.Hour)
+		return &value
+	}
+
+	testCases := []struct {
+		name     string
+		query    *abstractions.JobQuery
+		expected string
+	}{
+		{"model URL", &abstractions.JobQuery{ModelURL: "http://granite"}, "job-a"},
+		{"benchmark", &abstractions.JobQuery{BenchmarkID: "mmlu"}, "job-a,job-b"},
+		{"collection", &abstractions.JobQuery{CollectionID: "col-1"}, "job-a,job-c"},
+		{"experiment name", &abstractions.JobQuery{ExperimentName: "exp-1"}, "job-a,job-b"},
+		{"experiment tag value", &abstractions.JobQuery{ExperimentTags: map[string]string{"team": "nlp"}}, "job-a"},
+		{"experiment tag key", &abstractions.JobQuery{ExperimentTags: map[string]string{"team": ""}}, "job-a,job-b"},
+		{"combined", &abstractions.JobQuery{ModelName: "granite", BenchmarkID: "hellaswag", ExperimentName: "exp-2"}, "job-c"},
+		{"created from", &abstractions.JobQuery{CreatedFrom: at(1)}, "job-b,job-c"},
+		{"created range", &abstractions.JobQuery{CreatedFrom: at(0), CreatedTo: at(1)}, "job-a"},
+		{"updated to", &abstractions.JobQuery{UpdatedTo: at(0)}, ""},
+		{"sort descending", &abstractions.JobQuery{Sort: abstractions.JobSort{Field: abstractions.JobSortCreatedAt, Descending: true}}, "job-c,job-b,job-a"},
+		{"sort by model", &abstractions.JobQuery{Sort: abstractions.JobSort{Field: abstractions.JobSortModelName, Descending: true}}, "job-b,job-c,job-a"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			list, err := storage.GetEvaluationJobs(tc.query)
+			if err != nil {
+				t.Fatalf("GetEvaluationJobs() returned error: %v", err)
+			}
+			if ids(list) != tc.expected {
+				t.Errorf("Expected %q, got %q", tc.expected, ids(list))
+			}
+		})
+	}
+
+	t.Run("sorted pages continue after the position", func(t *testing.T) {
+		sort := abstractions.JobSort{Field: abstractions.JobSortModelName, Descending: true}
+		first, _ := storage.GetEvaluationJobs(&abstractions.JobQuery{Sort: sort, Page: abstractions.PageQuery{Limit: 2}})
+		after, err := abstractions.Query{abstractions.QueryAfter: sort.PositionAfter(&first.Items[1])}.Page()
+		if err != nil {
+			t.Fatalf("Page() returned error: %v", err)
+		}
+		next, err := storage.GetEvaluationJobs(&abstractions.JobQuery{Sort: sort, Page: abstractions.PageQuery{Limit: 2, After: after.After}})
+		if err != nil {
+			t.Fatalf("GetEvaluationJobs() returned error: %v", err)
+		}
+		if (ids(first) != "job-b,job-c") || (ids(next) != "job-a") || (next.TotalCount != 3) {
+			t.Errorf("Expected job-b,job-c then job-a, got %s then %s", ids(first), ids(next))
+		}
+	})
+
+	t.Run("invalid queries are rejected", func(t *testing.T) {
+		for _, query := range []*abstractions.JobQuery{
+			{CreatedFrom: at(1), CreatedTo: at(1)},
+			{Sort: abstractions.JobSort{Field: "name"}},
+			{Sort: abstractions.JobSort{Field: abstractions.JobSortUpdatedAt}, Page: abstractions.PageQuery{After: &abstractions.Position{ID: "job-a", Value: "yesterday"}}},
+		} {
+			if _, err := storage.GetEvaluationJobs(query); err == nil {
+				t.Errorf("Expected an error for %+v", query)
+			}
+		}
+	})
+}
+
+func TestLeaderboard(t *testing.T) {
+	storage := newTestStorage(t)
+	for i, acc := range []float64{0.5, 0.75, 0.25} {
+		id := fmt.Sprintf("job-%d", i)
+		storage.CreateEvaluationJob(newJob(id, []string{"granite", "granite", "llama"}[i], api.StatePending))
+		result := api.EvaluationJobBenchmarkResult{ID: "mmlu", State: api.StateCompleted, AttemptID: "1", Metrics: map[string]any{"acc": acc}}
+		if _, _, err := storage.RecordBenchmarkResult(id, result); err != nil {
+			t.Fatalf("RecordBenchmarkResult() returned error: %v", err)
+		}
+	}
+	other := storage.WithTenant("other")
+	other.CreateEvaluationJob(newJob("job-other", "mistral", api.StatePending))
+	other.RecordBenchmarkResult("job-other", api.EvaluationJobBenchmarkResult{ID: "mmlu", State: api.StateCompleted, AttemptID: "1", Metrics: map[string]any{"acc": 1.0}})
+
+	entries, err := storage.GetLeaderboard(&abstractions.LeaderboardQuery{Benchmarks: []string{"mmlu"}, Metric: "acc", Mode: abstractions.LeaderboardBest, Limit: 10})
+	if err != nil {
+		t.Fatalf("GetLeaderboard() returned error: %v", err)
+	}
+	if (len(entries) != 2) || (entries[0].JobID != "job-1") || (entries[0].Evaluations != 2) || (entries[1].Model.Name != "llama") || (entries[1].Rank != 2) {
+		t.Errorf("Expected the best score of every model of the tenant, got %+v", entries)
+	}
+	if _, err := storage.GetLeaderboard(&abstractions.LeaderboardQuery{Metric: "acc", Limit: 10}); err == nil {
+		t.Error("Expected an error for a query without a benchmark")
+	}
+}
@@ -0,0 +1,34 @@
+package storage
+
+import (
+	"fmt"
+	"log/slog"
+
+	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/abstractions"
+	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/config"
+	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/storage/memory"
+	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/storage/postgres"
+)
+
+// NewStorage creates the storage selected by the driver in the database configuration,
+// the in-memory storage is used when no driver is configured.
+func NewStorage(logger *slog.Logger, databaseConfig *config.DatabaseConfig) (abstractions.Storage, error) {
+	driver := config.DatabaseDriverMemory
+	if (databaseConfig != nil) && (databaseConfig.Driver != "") {
+		driver = databaseConfig.Driver
+	}
+
+	switch driver {
+	case config.DatabaseDriverMemory:
+		logger.Warn("Using the in-memory storage, resources will be lost when the service stops")
+		return memory.NewStorage(), nil
+	case config.DatabaseDriverPostgres:
+		storage, err := postgres.NewStorage(logger, databaseConfig)
+		if err != nil {
+			return nil, err
+		}
+		return storage, nil
+	default:
+		return nil, fmt.Errorf("unsupported database driver %q", driver)
+	}
+}
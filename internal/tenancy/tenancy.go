@@ -0,0 +1,118 @@
+// Package tenancy resolves the tenant of the requests, every storage call of a request is scoped to
+// its tenant so that the resources of the other tenants are not found.
+package tenancy
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/abstractions"
+	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/apierrors"
+	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/auth"
+	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/config"
+	"github.ibm.com/julpayne/eval-hub-backend-svc/pkg/api"
+)
+
+// DefaultHeader holds the tenant when no header is configured
+const DefaultHeader = "X-Tenant"
+
+// tenantPattern restricts the tenants to names that are safe in paths, labels and annotations
+var tenantPattern = regexp.MustCompile(`^[A-Za-z0-9]([A-Za-z0-9._-]{0,61}[A-Za-z0-9])?$`)
+
+type contextKey struct{}
+
+// Validate checks that the tenant is 1 to 63 letters, digits, dots, dashes or underscores starting
+// and ending with a letter or a digit
+func Validate(tenant api.Tenant) error {
+	if !tenantPattern.MatchString(string(tenant)) {
+		return fmt.Errorf("invalid tenant %q, expected 1 to 63 letters, digits, '.', '-' or '_' starting and ending with a letter or a digit", tenant)
+	}
+	return nil
+}
+
+// Resolve returns the tenant of the request. The tenant of an authenticated principal is read from
+// its claims when a claim is configured, a principal without the claim gets the default tenant. The
+// header is read for the anonymous requests, and for the authenticated principals only when no claim
+// is configured and the header is trusted, so that a principal can not choose another tenant with
+// the header. The default tenant is used when no tenant is set unless a tenant is required.
+func Resolve(cfg *config.TenancyConfig, r *http.Request, principal *auth.Principal) (api.Tenant, error) {
+	if cfg == nil {
+		cfg = &config.TenancyConfig{}
+	}
+	value := ""
+	switch {
+	case (principal != nil) && (cfg.Claim != ""):
+		claim, err := claimValue(principal.Claims, cfg.Claim)
+		if err != nil {
+			return "", err
+		}
+		value = claim
+	case (principal == nil) || cfg.TrustHeader:
+		header := cfg.Header
+		if header == "" {
+			header = DefaultHeader
+		}
+		value = strings.TrimSpace(r.Header.Get(header))
+	}
+
+	if value == "" {
+		if cfg.Required {
+			return "", fmt.Errorf("a tenant is required")
+		}
+		return abstractions.DefaultTenant, nil
+	}
+	tenant := api.Tenant(value)
+	if err := Validate(tenant); err != nil {
+		return "", err
+	}
+	return tenant, nil
+}
+
+// Middleware resolves the tenant of the requests from the claims of their principal or their header
+// and stores it in their context, the requests with an invalid or a missing required tenant are
+// rejected with 400
+func Middleware(cfg *config.TenancyConfig, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tenant, err := Resolve(cfg, r, auth.FromContext(r.Context()))
+		if err != nil {
+			apierrors.Write(w, r, apierrors.BadRequest(err.Error()))
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(WithTenant(r.Context(), tenant)))
+	})
+}
+
+// WithTenant returns a copy of the context holding the tenant
+func WithTenant(ctx context.Context, tenant api.Tenant) context.Context {
+	return context.WithValue(ctx, contextKey{}, tenant)
+}
+
+// FromContext returns the tenant stored in the context, the default tenant when there is none
+func FromContext(ctx context.Context) api.Tenant {
+	if tenant, ok := ctx.Value(contextKey{}).(api.Tenant); ok {
+		return tenant
+	}
+	return abstractions.DefaultTenant
+}
+
+// claimValue returns the string claim at the dotted path, an empty string when it is not set
+func claimValue(claims map[string]any, path string) (string, error) {
+	var value any = claims
+	for _, name := range strings.Split(path, ".") {
+		object, ok := value.(map[string]any)
+		if !ok {
+			return "", nil
+		}
+		if value, ok = object[name]; !ok {
+			return "", nil
+		}
+	}
+	tenant, ok := value.(string)
+	if !ok {
+		return "", fmt.Errorf("the %s claim must be a string", path)
+	}
+	return strings.TrimSpace(tenant), nil
+}
@@ -0,0 +1,104 @@
+package tenancy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/abstractions"
+	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/auth"
+	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/config"
+	"github.ibm.com/julpayne/eval-hub-backend-svc/pkg/api"
+)
+
+func TestResolve(t *testing.T) {
+	request := func(headers map[string]string) *http.Request {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/evaluations/jobs", nil)
+		for name, value := range headers {
+			req.Header.Set(name, value)
+		}
+		return req
+	}
+	claims := map[string]any{"org": map[string]any{"tenant": "acme"}, "count": 3.0}
+
+	testCases := []struct {
+		name      string
+		cfg       *config.TenancyConfig
+		headers   map[string]string
+		claims    map[string]any
+		anonymous bool
+		expected  api.Tenant
+		invalid   bool
+	}{
+		{name: "default tenant", cfg: nil, expected: abstractions.DefaultTenant},
+		{name: "default header", cfg: nil, headers: map[string]string{DefaultHeader: "acme"}, anonymous: true, expected: "acme"},
+		{name: "configured header", cfg: &config.TenancyConfig{Header: "X-Org"}, headers: map[string]string{"X-Org": "acme", DefaultHeader: "other"}, anonymous: true, expected: "acme"},
+		{name: "claim", cfg: &config.TenancyConfig{Claim: "org.tenant"}, claims: claims, expected: "acme"},
+		{name: "claim over header", cfg: &config.TenancyConfig{Claim: "org.tenant"}, headers: map[string]string{DefaultHeader: "other"}, claims: claims, expected: "acme"},
+		{name: "header of anonymous requests", cfg: &config.TenancyConfig{Claim: "org.tenant"}, headers: map[string]string{DefaultHeader: "other"}, anonymous: true, expected: "other"},
+		{name: "principal without claims ignores the header", cfg: &config.TenancyConfig{Claim: "org.tenant"}, headers: map[string]string{DefaultHeader: "other"}, expected: abstractions.DefaultTenant},
+		{name: "principal ignores the header by default", cfg: nil, headers: map[string]string{DefaultHeader: "other"}, claims: claims, expected: abstractions.DefaultTenant},
+		{name: "trusted header without a configured claim", cfg: &config.TenancyConfig{TrustHeader: true}, headers: map[string]string{DefaultHeader: "other"}, claims: claims, expected: "other"},
+		{name: "claim over trusted header", cfg: &config.TenancyConfig{Claim: "org.tenant", TrustHeader: true}, headers: map[string]string{DefaultHeader: "other"}, claims: claims, expected: "acme"},
+		{name: "missing claim ignores the header", cfg: &config.TenancyConfig{Claim: "tenant"}, headers: map[string]string{DefaultHeader: "other"}, claims: claims, expected: abstractions.DefaultTenant},
+		{name: "claim that is not a string", cfg: &config.TenancyConfig{Claim: "count"}, claims: claims, invalid: true},
+		{name: "required tenant", cfg: &config.TenancyConfig{Required: true}, invalid: true},
+		{name: "invalid tenant", cfg: nil, headers: map[string]string{DefaultHeader: "../acme"}, anonymous: true, invalid: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var principal *auth.Principal
+			if !tc.anonymous {
+				principal = &auth.Principal{Subject: "alice", Method: auth.MethodJWT, Claims: tc.claims}
+			}
+			tenant, err := Resolve(tc.cfg, request(tc.headers), principal)
+			if tc.invalid {
+				if err == nil {
+					t.Errorf("Expected an error, got tenant %q", tenant)
+				}
+				return
+			}
+			if (err != nil) || (tenant != tc.expected) {
+				t.Errorf("Expected tenant %q, got %q, %v", tc.expected, tenant, err)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	for _, tenant := range []api.Tenant{"a", "acme", "team-1.prod_eu", "A1"} {
+		if err := Validate(tenant); err != nil {
+			t.Errorf("Expected %q to be valid, got %v", tenant, err)
+		}
+	}
+	long := api.Tenant(strings.Repeat("a", 64))
+	for _, tenant := range []api.Tenant{"", "-acme", "acme.", "ac me", "a/b", long} {
+		if err := Validate(tenant); err == nil {
+			t.Errorf("Expected %q to be invalid", tenant)
+		}
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	var resolved api.Tenant
+	handler := Middleware(&config.TenancyConfig{Required: true}, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		resolved = FromContext(r.Context())
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/evaluations/jobs", nil)
+	req.Header.Set(DefaultHeader, "acme")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	if resolved != "acme" {
+		t.Errorf("Expected the tenant in the request context, got %q", resolved)
+	}
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/evaluations/jobs", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status code %d without a required tenant, got %d", http.StatusBadRequest, w.Code)
+	}
+	if tenant := FromContext(req.Context()); tenant != abstractions.DefaultTenant {
+		t.Errorf("Expected the default tenant without a resolved tenant, got %q", tenant)
+	}
+}
@@ -0,0 +1,275 @@
+package validation
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/apierrors"
+	"github.ibm.com/julpayne/eval-hub-backend-svc/pkg/api"
+)
+
+// schemaValidator checks the values against the JSON schemas of the document. It supports the
+// keywords the document uses: $ref, type, enum, const, anyOf, oneOf, allOf, properties, required,
+// additionalProperties, items, the length, size and range limits, pattern, uniqueItems and the
+// date-time format. The other formats are annotations, as in JSON Schema 2020-12.
+type schemaValidator struct {
+	document map[string]any
+	lock     sync.Mutex
+	patterns map[string]*regexp.Regexp
+}
+
+func newSchemaValidator(document map[string]any) *schemaValidator {
+	return &schemaValidator{document: document, patterns: map[string]*regexp.Regexp{}}
+}
+
+// validate returns the errors of the value, loc is the location of the value in the request
+func (v *schemaValidator) validate(schema any, value any, loc []any) []api.ValidationError {
+	errs := []api.ValidationError{}
+	v.check(schema, value, loc, &errs)
+	return errs
+}
+
+func (v *schemaValidator) check(schema any, value any, loc []any, errs *[]api.ValidationError) {
+	s, ok := v.resolve(schema).(map[string]any)
+	if !ok {
+		return
+	}
+	report := func(kind string, format string, args ...any) {
+		*errs = append(*errs, api.ValidationError{Loc: slices.Clone(loc), Msg: fmt.Sprintf(format, args...), Type: kind})
+	}
+
+	for _, branch := range asList(s["allOf"]) {
+		v.check(branch, value, loc, errs)
+	}
+	if branches := asList(s["anyOf"]); len(branches) > 0 {
+		v.checkBranches(branches, value, loc, errs, false)
+	}
+	if branches := asList(s["oneOf"]); len(branches) > 0 {
+		v.checkBranches(branches, value, loc, errs, true)
+	}
+
+	if types := schemaTypes(s); (len(types) > 0) && !slices.ContainsFunc(types, func(t string) bool { return hasType(value, t) }) {
+		report(apierrors.TypeType, "must be of type %s", strings.Join(types, " or "))
+		return
+	}
+	if enum := asList(s["enum"]); (enum != nil) && !slices.ContainsFunc(enum, func(allowed any) bool { return reflect.DeepEqual(allowed, value) }) {
+		report(apierrors.TypeEnum, "must be one of %s", formatValues(enum))
+		return
+	}
+	if constant, found := s["const"]; found && !reflect.DeepEqual(constant, value) {
+		report(apierrors.TypeEnum, "must be %s", formatValues([]any{constant}))
+		return
+	}
+
+	switch value := value.(type) {
+	case map[string]any:
+		v.checkObject(s, value, loc, errs)
+	case []any:
+		if limit, found := number(s["minItems"]); found && (float64(len(value)) < limit) {
+			report(apierrors.TypeInvalid, "must have at least %v items", limit)
+		}
+		if limit, found := number(s["maxItems"]); found && (float64(len(value)) > limit) {
+			report(apierrors.TypeInvalid, "must have at most %v items", limit)
+		}
+		if unique, _ := s["uniqueItems"].(bool); unique {
+			for i := range value {
+				if slices.ContainsFunc(value[:i], func(item any) bool { return reflect.DeepEqual(item, value[i]) }) {
+					report(apierrors.TypeInvalid, "must not list the item %d more than once", i)
+					break
+				}
+			}
+		}
+		if items, found := s["items"]; found {
+			for i, item := range value {
+				v.check(items, item, append(loc, i), errs)
+			}
+		}
+	case string:
+		if limit, found := number(s["minLength"]); found && (float64(utf8.RuneCountInString(value)) < limit) {
+			report(apierrors.TypeInvalid, "must have at least %v characters", limit)
+		}
+		if limit, found := number(s["maxLength"]); found && (float64(utf8.RuneCountInString(value)) > limit) {
+			report(apierrors.TypeInvalid, "must have at most %v characters", limit)
+		}
+		if pattern, found := s["pattern"].(string); found {
+			if re := v.pattern(pattern); (re != nil) && !re.MatchString(value) {
+				report(apierrors.TypeInvalid, "must match %s", pattern)
+			}
+		}
+		if format, _ := s["format"].(string); format == "date-time" {
+			if _, err := time.Parse(time.RFC3339, value); err != nil {
+				report(apierrors.TypeInvalid, "must be an RFC 3339 date-time")
+			}
+		}
+	case float64:
+		if limit, found := number(s["minimum"]); found && (value < limit) {
+			report(apierrors.TypeInvalid, "must be at least %v", limit)
+		}
+		if limit, found := number(s["maximum"]); found && (value > limit) {
+			report(apierrors.TypeInvalid, "must be at most %v", limit)
+		}
+		if limit, found := number(s["exclusiveMinimum"]); found && (value <= limit) {
+			report(apierrors.TypeInvalid, "must be greater than %v", limit)
+		}
+		if limit, found := number(s["exclusiveMaximum"]); found && (value >= limit) {
+			report(apierrors.TypeInvalid, "must be less than %v", limit)
+		}
+	}
+}
+
+func (v *schemaValidator) checkObject(s map[string]any, value map[string]any, loc []any, errs *[]api.ValidationError) {
+	properties, _ := s["properties"].(map[string]any)
+	for _, name := range asList(s["required"]) {
+		if name, ok := name.(string); ok {
+			if _, found := value[name]; !found {
+				*errs = append(*errs, api.ValidationError{Loc: append(slices.Clone(loc), name), Msg: "field required", Type: apierrors.TypeMissing})
+			}
+		}
+	}
+	names := make([]string, 0, len(value))
+	for name := range value {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		if property, found := properties[name]; found {
+			v.check(property, value[name], append(loc, name), errs)
+			continue
+		}
+		switch additional := s["additionalProperties"].(type) {
+		case bool:
+			if !additional {
+				*errs = append(*errs, api.ValidationError{Loc: append(slices.Clone(loc), name), Msg: "extra fields not permitted", Type: apierrors.TypeExtra})
+			}
+		case map[string]any:
+			v.check(additional, value[name], append(loc, name), errs)
+		}
+	}
+}
+
+// checkBranches reports the errors of the branch that is the closest match when no branch matches,
+// or when several branches match and exactly one is expected
+func (v *schemaValidator) checkBranches(branches []any, value any, loc []any, errs *[]api.ValidationError, exactlyOne bool) {
+	var closest []api.ValidationError
+	matches := 0
+	for _, branch := range branches {
+		branchErrs := v.validate(branch, value, loc)
+		if len(branchErrs) == 0 {
+			matches++
+		} else if (closest == nil) || (len(branchErrs) < len(closest)) {
+			closest = branchErrs
+		}
+	}
+	switch {
+	case matches == 0:
+		*errs = append(*errs, closest...)
+	case exactlyOne && (matches > 1):
+		*errs = append(*errs, api.ValidationError{Loc: slices.Clone(loc), Msg: "must match exactly one of the allowed schemas", Type: apierrors.TypeInvalid})
+	}
+}
+
+// resolve follows the $ref of the schema, the references are JSON pointers within the document
+func (v *schemaValidator) resolve(schema any) any {
+	for range 32 {
+		s, ok := schema.(map[string]any)
+		if !ok {
+			return schema
+		}
+		ref, found := s["$ref"].(string)
+		if !found {
+			return schema
+		}
+		schema = v.pointer(ref)
+	}
+	return nil
+}
+
+// pointer returns the value at the #/... reference of the document, nil when it does not exist
+func (v *schemaValidator) pointer(ref string) any {
+	path, found := strings.CutPrefix(ref, "#/")
+	if !found {
+		return nil
+	}
+	var value any = v.document
+	for _, name := range strings.Split(path, "/") {
+		object, ok := value.(map[string]any)
+		if !ok {
+			return nil
+		}
+		value = object[strings.ReplaceAll(strings.ReplaceAll(name, "~1", "/"), "~0", "~")]
+	}
+	return value
+}
+
+func (v *schemaValidator) pattern(pattern string) *regexp.Regexp {
+	v.lock.Lock()
+	defer v.lock.Unlock()
+	if re, found := v.patterns[pattern]; found {
+		return re
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		re = nil
+	}
+	v.patterns[pattern] = re
+	return re
+}
+
+// schemaTypes returns the types allowed by the schema, the type is a name or a list of names
+func schemaTypes(s map[string]any) []string {
+	switch t := s["type"].(type) {
+	case string:
+		return []string{t}
+	case []any:
+		types := []string{}
+		for _, name := range t {
+			if name, ok := name.(string); ok {
+				types = append(types, name)
+			}
+		}
+		return types
+	}
+	return nil
+}
+
+// hasType reports whether the decoded JSON value is of the JSON schema type
+func hasType(value any, t string) bool {
+	switch value := value.(type) {
+	case nil:
+		return t == "null"
+	case bool:
+		return t == "boolean"
+	case string:
+		return t == "string"
+	case float64:
+		return (t == "number") || ((t == "integer") && (value == math.Trunc(value)))
+	case []any:
+		return t == "array"
+	case map[string]any:
+		return t == "object"
+	}
+	return false
+}
+
+func asList(value any) []any {
+	list, _ := value.([]any)
+	return list
+}
+
+func number(value any) (float64, bool) {
+	n, ok := value.(float64)
+	return n, ok
+}
+
+func formatValues(values []any) string {
+	data, _ := json.Marshal(values)
+	return strings.Trim(string(data), "[]")
+}
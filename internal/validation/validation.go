@@ -0,0 +1,368 @@
+// Package validation validates the requests against the OpenAPI document of the service. The
+// document is loaded once at startup, the parameters and the JSON body of every request that
+// matches one of its operations are checked against their schemas and the invalid requests are
+// rejected with a 422 HTTPValidationError. In strict mode the JSON responses are validated too, so
+// that the tests catch the drift between the API structures and the document.
+package validation
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/apierrors"
+	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/config"
+	"github.ibm.com/julpayne/eval-hub-backend-svc/pkg/api"
+
+	"sigs.k8s.io/yaml"
+)
+
+// DefaultSpecFile is the OpenAPI document when no file is configured
+const DefaultSpecFile = "api/openapi.yaml"
+
+// maxBodyBytes limits the size of the bodies that are validated, larger bodies are left to the
+// handlers that reject them
+const maxBodyBytes = 1 << 20
+
+// operation is an operation of the document with the segments of its path template
+type operation struct {
+	method   string
+	template string
+	segments []string
+	spec     map[string]any
+}
+
+// Validator validates the requests, and the responses in strict mode, against the operations of
+// the document
+type Validator struct {
+	logger     *slog.Logger
+	strict     bool
+	schemas    *schemaValidator
+	operations []*operation
+}
+
+// NewValidator loads the OpenAPI document of the configuration, it returns nil when the validation
+// is not enabled
+func NewValidator(logger *slog.Logger, validationConfig *config.ValidationConfig) (*Validator, error) {
+	if (validationConfig == nil) || !validationConfig.Enabled {
+		return nil, nil
+	}
+	specFile := validationConfig.SpecFile
+	if specFile == "" {
+		specFile = DefaultSpecFile
+	}
+	data, err := readSpec(specFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the OpenAPI document: %w", err)
+	}
+	v, err := Parse(data)
+	if err != nil {
+		return nil, err
+	}
+	v.logger, v.strict = logger, validationConfig.Strict
+	logger.Info("Loaded the OpenAPI document", "file", specFile, "operations", len(v.operations), "strict", v.strict)
+	return v, nil
+}
+
+// Parse returns the validator of the YAML or JSON OpenAPI document
+func Parse(data []byte) (*Validator, error) {
+	document := map[string]any{}
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		return nil, fmt.Errorf("invalid OpenAPI document: %w", err)
+	}
+	paths, ok := document["paths"].(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("invalid OpenAPI document: no paths")
+	}
+	v := &Validator{logger: slog.New(slog.DiscardHandler), schemas: newSchemaValidator(document)}
+	for template, item := range paths {
+		methods, ok := item.(map[string]any)
+		if !ok {
+			continue
+		}
+		for method, spec := range methods {
+			spec, ok := spec.(map[string]any)
+			if !ok || !slices.Contains(httpMethods, strings.ToUpper(method)) {
+				continue
+			}
+			// the parameters of the path item apply to all its operations
+			if shared := asList(methods["parameters"]); len(shared) > 0 {
+				spec["parameters"] = append(slices.Clone(shared), asList(spec["parameters"])...)
+			}
+			v.operations = append(v.operations, &operation{
+				method:   strings.ToUpper(method),
+				template: template,
+				segments: strings.Split(strings.Trim(template, "/"), "/"),
+				spec:     spec,
+			})
+		}
+	}
+	// the templates with more literal segments take precedence over those with parameters
+	slices.SortFunc(v.operations, func(a, b *operation) int {
+		return literalSegments(b.segments) - literalSegments(a.segments)
+	})
+	return v, nil
+}
+
+var httpMethods = []string{http.MethodGet, http.MethodPut, http.MethodPost, http.MethodDelete, http.MethodPatch, http.MethodHead, http.MethodOptions}
+
+// Middleware rejects the requests that do not match their operation with 422, the requests that
+// match no operation are left to the router. A nil validator lets every request through.
+func (v *Validator) Middleware(next http.Handler) http.Handler {
+	if v == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		op, params := v.match(r.Method, r.URL.Path)
+		if op == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if errs := v.validateRequest(r, op, params); len(errs) > 0 {
+			apierrors.Write(w, r, apierrors.Validation(errs...))
+			return
+		}
+		if !v.strict {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		recorder := &responseRecorder{header: http.Header{}, status: http.StatusOK}
+		next.ServeHTTP(recorder, r)
+		if errs := v.validateResponse(op, recorder.status, recorder.header.Get("Content-Type"), recorder.body.Bytes()); len(errs) > 0 {
+			v.logger.Error("The response does not match the OpenAPI document", "method", r.Method, "path", op.template, "code", recorder.status, "errors", fmt.Sprint(errs))
+			apierrors.Write(w, r, apierrors.Internal(fmt.Errorf("the %d response of %s %s does not match the OpenAPI document: %s", recorder.status, op.method, op.template, formatErrors(errs))))
+			return
+		}
+		recorder.writeTo(w)
+	})
+}
+
+// match returns the operation of the method and the path with the values of its path parameters
+func (v *Validator) match(method string, path string) (*operation, map[string]string) {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	for _, op := range v.operations {
+		if (op.method != method) || (len(op.segments) != len(segments)) {
+			continue
+		}
+		params := map[string]string{}
+		matched := true
+		for i, segment := range op.segments {
+			if name, found := strings.CutPrefix(segment, "{"); found && strings.HasSuffix(name, "}") {
+				params[strings.TrimSuffix(name, "}")] = segments[i]
+			} else if segment != segments[i] {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return op, params
+		}
+	}
+	return nil, nil
+}
+
+// validateRequest returns the errors of the path and the query parameters and of the JSON body of
+// the request. A missing or malformed body is left to the handler, which rejects it with 400.
+func (v *Validator) validateRequest(r *http.Request, op *operation, params map[string]string) []api.ValidationError {
+	errs := []api.ValidationError{}
+	query := r.URL.Query()
+	for _, parameter := range asList(op.spec["parameters"]) {
+		parameter, ok := v.schemas.resolve(parameter).(map[string]any)
+		if !ok {
+			continue
+		}
+		name, _ := parameter["name"].(string)
+		in, _ := parameter["in"].(string)
+		required, _ := parameter["required"].(bool)
+		schema := parameter["schema"]
+		switch in {
+		case "path":
+			errs = append(errs, v.schemas.validate(schema, v.coerce(schema, []string{params[name]}), []any{"path", name})...)
+		case "query":
+			values, found := query[name]
+			if !found {
+				if required {
+					errs = append(errs, api.ValidationError{Loc: []any{"query", name}, Msg: "field required", Type: apierrors.TypeMissing})
+				}
+				continue
+			}
+			errs = append(errs, v.schemas.validate(schema, v.coerce(schema, values), []any{"query", name})...)
+		}
+	}
+
+	body, err := v.readBody(r)
+	if (err != nil) || (len(bytes.TrimSpace(body)) == 0) {
+		return errs
+	}
+	schema, found := contentSchema(op.spec["requestBody"], r.Header.Get("Content-Type"))
+	if !found {
+		return errs
+	}
+	var value any
+	if err := json.Unmarshal(body, &value); err != nil {
+		return errs
+	}
+	return append(errs, v.schemas.validate(schema, value, []any{"body"})...)
+}
+
+// validateResponse returns the errors of the JSON body of the response, the responses without a
+// documented status or schema are not validated
+func (v *Validator) validateResponse(op *operation, status int, contentType string, body []byte) []api.ValidationError {
+	responses, _ := op.spec["responses"].(map[string]any)
+	response, found := responses[strconv.Itoa(status)]
+	if !found {
+		response = responses["default"]
+	}
+	schema, found := contentSchema(response, contentType)
+	if !found || (len(bytes.TrimSpace(body)) == 0) {
+		return nil
+	}
+	var value any
+	if err := json.Unmarshal(body, &value); err != nil {
+		return []api.ValidationError{{Loc: []any{"response"}, Msg: "is not a JSON document", Type: apierrors.TypeInvalid}}
+	}
+	return v.schemas.validate(schema, value, []any{"response"})
+}
+
+// readBody returns the body of the request and restores it for the handler
+func (v *Validator) readBody(r *http.Request) ([]byte, error) {
+	if r.Body == nil {
+		return nil, nil
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxBodyBytes+1))
+	r.Body = io.NopCloser(io.MultiReader(bytes.NewReader(body), r.Body))
+	if (err != nil) || (len(body) > maxBodyBytes) {
+		return nil, fmt.Errorf("the body can not be validated")
+	}
+	return body, nil
+}
+
+// coerce converts the string values of a parameter to the type of its schema, the values that can
+// not be converted are kept as strings and fail the validation
+func (v *Validator) coerce(schema any, values []string) any {
+	s, _ := v.schemas.resolve(schema).(map[string]any)
+	types := schemaTypes(s)
+	if slices.Contains(types, "array") {
+		items := []any{}
+		for _, value := range values {
+			items = append(items, v.coerce(s["items"], []string{value}))
+		}
+		return items
+	}
+	value := values[0]
+	if len(types) == 0 {
+		// the first branch that is not null selects the type of anyOf [string, null] and the like
+		for _, branch := range append(asList(s["anyOf"]), asList(s["oneOf"])...) {
+			if branch, ok := v.schemas.resolve(branch).(map[string]any); ok && !slices.Contains(schemaTypes(branch), "null") {
+				return v.coerce(branch, values)
+			}
+		}
+		return value
+	}
+	switch {
+	case slices.Contains(types, "integer"), slices.Contains(types, "number"):
+		if n, err := strconv.ParseFloat(value, 64); err == nil {
+			return n
+		}
+	case slices.Contains(types, "boolean"):
+		if b, err := strconv.ParseBool(value); err == nil {
+			return b
+		}
+	}
+	return value
+}
+
+// contentSchema returns the schema of the JSON content of the request body or the response for the
+// media type, the first JSON content is used when no media type is given
+func contentSchema(object any, contentType string) (any, bool) {
+	o, _ := object.(map[string]any)
+	content, _ := o["content"].(map[string]any)
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if (err != nil) || (mediaType == "") {
+		mediaType = "application/json"
+	}
+	if !strings.HasSuffix(mediaType, "json") {
+		return nil, false
+	}
+	media, found := content[mediaType].(map[string]any)
+	if !found {
+		return nil, false
+	}
+	schema, found := media["schema"]
+	return schema, found && (schema != nil)
+}
+
+func literalSegments(segments []string) int {
+	count := 0
+	for _, segment := range segments {
+		if !strings.HasPrefix(segment, "{") {
+			count++
+		}
+	}
+	return count
+}
+
+func formatErrors(errs []api.ValidationError) string {
+	messages := []string{}
+	for _, err := range errs {
+		loc := []string{}
+		for _, item := range err.Loc {
+			loc = append(loc, fmt.Sprint(item))
+		}
+		messages = append(messages, strings.Join(loc, ".")+" "+err.Msg)
+	}
+	return strings.Join(messages, "; ")
+}
+
+// readSpec reads the document, a relative path that is not found is also looked up next to the
+// executable
+func readSpec(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if (err == nil) || filepath.IsAbs(path) {
+		return data, err
+	}
+	if executable, exeErr := os.Executable(); exeErr == nil {
+		if data, exeErr := os.ReadFile(filepath.Join(filepath.Dir(executable), path)); exeErr == nil {
+			return data, nil
+		}
+	}
+	return nil, err
+}
+
+// responseRecorder buffers the response so that it can be replaced when it does not match the
+// document
+type responseRecorder struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (r *responseRecorder) Header() http.Header {
+	return r.header
+}
+
+func (r *responseRecorder) Write(data []byte) (int, error) {
+	return r.body.Write(data)
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	r.status = status
+}
+
+func (r *responseRecorder) writeTo(w http.ResponseWriter) {
+	for name, values := range r.header {
+		w.Header()[name] = values
+	}
+	w.WriteHeader(r.status)
+	w.Write(r.body.Bytes())
+}
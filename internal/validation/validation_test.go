@@ -0,0 +1,288 @@
+package validation
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/apierrors"
+	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/config"
+	"github.ibm.com/julpayne/eval-hub-backend-svc/pkg/api"
+)
+
+const testSpec = `
+openapi: 3.1.0
+paths:
+  /jobs:
+    get:
+      parameters:
+      - name: limit
+        in: query
+        schema:
+          type: integer
+          minimum: 1
+      - name: summary
+        in: query
+        schema:
+          type: boolean
+      - name: tag
+        in: query
+        schema:
+          type: array
+          items:
+            type: string
+            pattern: '^[a-z]+$'
+      responses:
+        '200':
+          content:
+            application/json:
+              schema:
+                $ref: '#/components/schemas/Job'
+    post:
+      requestBody:
+        content:
+          application/json:
+            schema:
+              $ref: '#/components/schemas/JobRequest'
+      responses:
+        '202':
+          content:
+            application/json:
+              schema:
+                $ref: '#/components/schemas/Job'
+  /jobs/{id}:
+    parameters:
+    - name: id
+      in: path
+      required: true
+      schema:
+        type: string
+        maxLength: 8
+    get:
+      responses:
+        '200':
+          content:
+            application/json:
+              schema:
+                $ref: '#/components/schemas/Job'
+  /jobs/latest:
+    get:
+      responses:
+        '200':
+          description: The latest job
+components:
+  schemas:
+    JobRequest:
+      type: object
+      additionalProperties: false
+      required:
+      - name
+      properties:
+        name:
+          type: string
+          minLength: 1
+        state:
+          type: string
+          enum: [pending, running]
+        benchmarks:
+          type: array
+          items:
+            type: object
+            required: [id]
+            properties:
+              id:
+                type: string
+              limit:
+                type: integer
+                minimum: 1
+        callback_url:
+          anyOf:
+          - type: string
+          - type: 'null'
+        created_at:
+          type: string
+          format: date-time
+    Job:
+      type: object
+      required: [id]
+      properties:
+        id:
+          type: string
+`
+
+func newTestValidator(t *testing.T, strict bool) *Validator {
+	t.Helper()
+	v, err := Parse([]byte(testSpec))
+	if err != nil {
+		t.Fatalf("Parse() returned error: %v", err)
+	}
+	v.strict = strict
+	return v
+}
+
+func TestNewValidator(t *testing.T) {
+	if v, err := NewValidator(nil, &config.ValidationConfig{}); (v != nil) || (err != nil) {
+		t.Errorf("Expected no validator when the validation is not enabled, got %v, %v", v, err)
+	}
+	if _, err := NewValidator(nil, &config.ValidationConfig{Enabled: true, SpecFile: "missing.yaml"}); err == nil {
+		t.Error("Expected an error for a missing document")
+	}
+	if _, err := Parse([]byte("openapi: 3.1.0")); err == nil {
+		t.Error("Expected an error for a document without paths")
+	}
+}
+
+func TestMatch(t *testing.T) {
+	v := newTestValidator(t, false)
+	testCases := []struct {
+		method   string
+		path     string
+		template string
+		id       string
+	}{
+		{http.MethodGet, "/jobs", "/jobs", ""},
+		{http.MethodGet, "/jobs/", "/jobs", ""},
+		{http.MethodGet, "/jobs/j1", "/jobs/{id}", "j1"},
+		// the literal segments take precedence over the parameters
+		{http.MethodGet, "/jobs/latest", "/jobs/latest", ""},
+		{http.MethodDelete, "/jobs/j1", "", ""},
+		{http.MethodGet, "/jobs/j1/results", "", ""},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.method+" "+tc.path, func(t *testing.T) {
+			op, params := v.match(tc.method, tc.path)
+			template := ""
+			if op != nil {
+				template = op.template
+			}
+			if (template != tc.template) || (params["id"] != tc.id) {
+				t.Errorf("Expected %q with the ID %q, got %q with %v", tc.template, tc.id, template, params)
+			}
+		})
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	v := newTestValidator(t, false)
+	var received string
+	handler := v.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received = string(body)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+
+	testCases := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		errors []string
+	}{
+		{"valid body", http.MethodPost, "/jobs", `{"name": "a", "state": "pending", "benchmarks": [{"id": "mmlu", "limit": 5}], "callback_url": null, "created_at": "2025-01-01T00:00:00Z"}`, nil},
+		{"missing field", http.MethodPost, "/jobs", `{}`, []string{"[body name] missing"}},
+		{"extra field", http.MethodPost, "/jobs", `{"name": "a", "custom": 1}`, []string{"[body custom] extra_forbidden"}},
+		{"wrong type", http.MethodPost, "/jobs", `{"name": 1}`, []string{"[body name] type_error"}},
+		{"enum", http.MethodPost, "/jobs", `{"name": "a", "state": "done"}`, []string{"[body state] enum"}},
+		{"nested items", http.MethodPost, "/jobs", `{"name": "a", "benchmarks": [{"id": "mmlu"}, {"limit": 0.5}]}`,
+			[]string{"[body benchmarks 1 id] missing", "[body benchmarks 1 limit] type_error"}},
+		{"anyOf", http.MethodPost, "/jobs", `{"name": "a", "callback_url": 1}`, []string{"[body callback_url] type_error"}},
+		{"date-time", http.MethodPost, "/jobs", `{"name": "a", "created_at": "yesterday"}`, []string{"[body created_at] value_error"}},
+		{"several errors", http.MethodPost, "/jobs", `{"name": "", "state": "done"}`, []string{"[body name] value_error", "[body state] enum"}},
+		{"query parameters", http.MethodGet, "/jobs?limit=10&summary=true&tag=a&tag=b", "", nil},
+		{"query minimum", http.MethodGet, "/jobs?limit=0", "", []string{"[query limit] value_error"}},
+		{"query type", http.MethodGet, "/jobs?limit=ten&summary=maybe", "", []string{"[query limit] type_error", "[query summary] type_error"}},
+		{"query items", http.MethodGet, "/jobs?tag=a&tag=B", "", []string{"[query tag 1] value_error"}},
+		{"path parameter", http.MethodGet, "/jobs/123456789", "", []string{"[path id] value_error"}},
+		// the malformed and the empty bodies are left to the handlers
+		{"malformed body", http.MethodPost, "/jobs", `{"name":`, nil},
+		{"empty body", http.MethodPost, "/jobs", "", nil},
+		{"unknown operation", http.MethodPut, "/jobs", `{"name": 1}`, nil},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			received = ""
+			req := httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			if tc.errors == nil {
+				if (w.Code != http.StatusAccepted) || (received != tc.body) {
+					t.Errorf("Expected the request to reach the handler with its body, got %d: %s", w.Code, w.Body.String())
+				}
+				return
+			}
+			var response api.HTTPValidationError
+			if err := json.Unmarshal(w.Body.Bytes(), &response); (err != nil) || (w.Code != http.StatusUnprocessableEntity) {
+				t.Fatalf("Expected a validation error, got %d: %s", w.Code, w.Body.String())
+			}
+			errors := []string{}
+			for _, detail := range response.Detail {
+				errors = append(errors, fmt.Sprintf("%v %s", detail.Loc, detail.Type))
+			}
+			if fmt.Sprint(errors) != fmt.Sprint(tc.errors) {
+				t.Errorf("Expected the errors %v, got %v", tc.errors, errors)
+			}
+			if response.Code != string(apierrors.CodeValidation) {
+				t.Errorf("Expected the code %s, got %s", apierrors.CodeValidation, response.Code)
+			}
+		})
+	}
+
+	t.Run("a nil validator lets every request through", func(t *testing.T) {
+		var nilValidator *Validator
+		w := httptest.NewRecorder()
+		nilValidator.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusAccepted)
+		})).ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/jobs", strings.NewReader(`{}`)))
+		if w.Code != http.StatusAccepted {
+			t.Errorf("Expected status %d, got %d", http.StatusAccepted, w.Code)
+		}
+	})
+}
+
+func TestStrictMode(t *testing.T) {
+	respond := func(v *Validator, status int, body string) *httptest.ResponseRecorder {
+		handler := v.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("Location", "/jobs/j1")
+			w.WriteHeader(status)
+			w.Write([]byte(body))
+		}))
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/jobs", strings.NewReader(`{"name": "a"}`)))
+		return w
+	}
+
+	t.Run("valid responses are written unchanged", func(t *testing.T) {
+		w := respond(newTestValidator(t, true), http.StatusAccepted, `{"id": "j1"}`)
+		if (w.Code != http.StatusAccepted) || (w.Body.String() != `{"id": "j1"}`) || (w.Header().Get("Location") != "/jobs/j1") {
+			t.Errorf("Expected the response of the handler, got %d: %s", w.Code, w.Body.String())
+		}
+	})
+
+	t.Run("responses that do not match the document fail", func(t *testing.T) {
+		w := respond(newTestValidator(t, true), http.StatusAccepted, `{"name": "a"}`)
+		var response api.Error
+		json.Unmarshal(w.Body.Bytes(), &response)
+		if (w.Code != http.StatusInternalServerError) || !strings.Contains(response.Detail, "response.id field required") {
+			t.Errorf("Expected the drift to be reported, got %d: %s", w.Code, w.Body.String())
+		}
+	})
+
+	t.Run("undocumented statuses are not validated", func(t *testing.T) {
+		if w := respond(newTestValidator(t, true), http.StatusConflict, `{"detail": "conflict"}`); w.Code != http.StatusConflict {
+			t.Errorf("Expected status %d, got %d", http.StatusConflict, w.Code)
+		}
+	})
+
+	t.Run("responses are not validated without strict mode", func(t *testing.T) {
+		if w := respond(newTestValidator(t, false), http.StatusAccepted, `{"name": "a"}`); w.Code != http.StatusAccepted {
+			t.Errorf("Expected status %d, got %d", http.StatusAccepted, w.Code)
+		}
+	})
+}
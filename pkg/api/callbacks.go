@@ -0,0 +1,54 @@
+package api
+
+import "time"
+
+// CallbackEvent represents the type of event posted to the callback URL of an evaluation job
+type CallbackEvent string
+
+const (
+	CallbackEventStateChanged CallbackEvent = "evaluation_job.state_changed"
+	CallbackEventCompleted    CallbackEvent = "evaluation_job.completed"
+)
+
+// CallbackDeliveryState represents the delivery state enum
+type CallbackDeliveryState string
+
+const (
+	CallbackDeliveryPending    CallbackDeliveryState = "pending"
+	CallbackDeliveryDelivered  CallbackDeliveryState = "delivered"
+	CallbackDeliveryDeadLetter CallbackDeliveryState = "dead_letter"
+)
+
+// CallbackPayload represents the JSON document posted to the callback URL
+type CallbackPayload struct {
+	DeliveryID    string                `json:"delivery_id"`
+	Event         CallbackEvent         `json:"event"`
+	Timestamp     time.Time             `json:"timestamp"`
+	PreviousState State                 `json:"previous_state"`
+	Job           EvaluationJobResource `json:"job"`
+}
+
+// CallbackAttempt represents a single attempt to deliver a callback
+type CallbackAttempt struct {
+	AttemptedAt time.Time `json:"attempted_at"`
+	StatusCode  int       `json:"status_code,omitempty"`
+	Error       string    `json:"error,omitempty"`
+}
+
+// CallbackDeliveryResource represents the delivery of an event to the callback URL of an evaluation job
+type CallbackDeliveryResource struct {
+	Resource
+	JobID         string                `json:"job_id"`
+	Event         CallbackEvent         `json:"event"`
+	URL           string                `json:"url"`
+	State         CallbackDeliveryState `json:"state"`
+	Attempts      []CallbackAttempt     `json:"attempts,omitempty"`
+	NextAttemptAt *time.Time            `json:"next_attempt_at,omitempty"`
+	Payload       CallbackPayload       `json:"payload"`
+}
+
+// CallbackDeliveryResourceList represents list of callback delivery resources with pagination
+type CallbackDeliveryResourceList struct {
+	Page
+	Items []CallbackDeliveryResource `json:"items"`
+}
@@ -0,0 +1,36 @@
+package api
+
+import "time"
+
+// ComparedJob represents an evaluation job of a comparison
+type ComparedJob struct {
+	ID        string    `json:"id"`
+	Model     ModelRef  `json:"model"`
+	State     State     `json:"state"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// MetricComparison represents a metric of a benchmark across the compared jobs
+type MetricComparison struct {
+	Name string `json:"name"`
+	// Values holds the value of the metric by job ID, the jobs that did not report it are omitted
+	Values map[string]float64 `json:"values"`
+	// Deltas holds the difference with the value of the baseline by job ID, it is omitted when the
+	// baseline did not report the metric
+	Deltas map[string]float64 `json:"deltas,omitempty"`
+}
+
+// BenchmarkComparison represents a benchmark across the compared jobs
+type BenchmarkComparison struct {
+	ID string `json:"id"`
+	// MissingFrom lists the jobs that have no completed result for the benchmark
+	MissingFrom []string           `json:"missing_from,omitempty"`
+	Metrics     []MetricComparison `json:"metrics"`
+}
+
+// EvaluationComparison represents the metrics of evaluation jobs aligned by benchmark and metric
+type EvaluationComparison struct {
+	Baseline   string                `json:"baseline"`
+	Jobs       []ComparedJob         `json:"jobs"`
+	Benchmarks []BenchmarkComparison `json:"benchmarks"`
+}
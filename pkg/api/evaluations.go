@@ -55,13 +55,27 @@ type EvaluationJobState struct {
 // EvaluationStatus represents evaluation status
 type EvaluationJobStatus struct {
 	EvaluationJobState
-	Benchmarks []BenchmarkStatus `json:"benchmarks,omitempty"`
+	StartedAt   *time.Time        `json:"started_at,omitempty"`
+	CompletedAt *time.Time        `json:"completed_at,omitempty"`
+	Benchmarks  []BenchmarkStatus `json:"benchmarks,omitempty"`
 }
 
 // EvaluationJobBenchmarkResult represents benchmark result in evaluation job
 type EvaluationJobBenchmarkResult struct {
-	ID          string         `json:"id"`
-	Name        string         `json:"name"`
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	State State  `json:"state"`
+	// AttemptID identifies the run that reported the result, a result is only recorded once per attempt
+	AttemptID   string         `json:"attempt_id,omitempty"`
+	StartedAt   *time.Time     `json:"started_at,omitempty"`
+	CompletedAt *time.Time     `json:"completed_at,omitempty"`
+	Metrics     map[string]any `json:"metrics,omitempty"`
+	Error       *string        `json:"error,omitempty"`
+}
+
+// BenchmarkResultRequest represents the result of a benchmark reported by a runtime or an external runner
+type BenchmarkResultRequest struct {
+	AttemptID   string         `json:"attempt_id"`
 	State       State          `json:"state"`
 	StartedAt   *time.Time     `json:"started_at,omitempty"`
 	CompletedAt *time.Time     `json:"completed_at,omitempty"`
@@ -94,6 +108,9 @@ type EvaluationJobConfig struct {
 type EvaluationJobResource struct {
 	Resource
 	EvaluationJobConfig
+	// Owner is the subject of the principal that submitted the job, it is empty when the job was
+	// submitted without authentication
+	Owner   string                `json:"owner,omitempty"`
 	Status  EvaluationJobStatus   `json:"status"`
 	Results *EvaluationJobResults `json:"results,omitempty"`
 }
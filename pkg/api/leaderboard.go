@@ -0,0 +1,27 @@
+package api
+
+import "time"
+
+// LeaderboardEntry represents the rank of a model on a leaderboard
+type LeaderboardEntry struct {
+	Rank  int      `json:"rank"`
+	Model ModelRef `json:"model"`
+	Score float64  `json:"score"`
+	// JobID is the evaluation job the score comes from
+	JobID string `json:"job_id"`
+	// CompletedAt is when the last of the scored benchmarks of the job completed
+	CompletedAt time.Time `json:"completed_at"`
+	// Completeness is the fraction of the benchmarks of the job that completed
+	Completeness float64 `json:"completeness"`
+	// Evaluations is the number of evaluation jobs of the model that were scored
+	Evaluations int `json:"evaluations"`
+}
+
+// Leaderboard represents models ranked by a metric of their completed evaluations
+type Leaderboard struct {
+	Benchmark  string             `json:"benchmark,omitempty"`
+	Collection string             `json:"collection,omitempty"`
+	Metric     string             `json:"metric"`
+	Mode       string             `json:"mode"`
+	Items      []LeaderboardEntry `json:"items"`
+}
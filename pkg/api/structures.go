@@ -35,6 +35,36 @@ type HRef struct {
 // Error represents an error response
 type Error struct {
 	Detail string `json:"detail"`
+	// Code is the stable code of the error, for example not_found
+	Code      string `json:"code,omitempty"`
+	RequestID string `json:"request_id,omitempty"`
+}
+
+// ValidationError represents the error of a single field, loc is the path of the field starting
+// with body, path or query
+type ValidationError struct {
+	Loc  []any  `json:"loc"`
+	Msg  string `json:"msg"`
+	Type string `json:"type"`
+}
+
+// HTTPValidationError represents the error response of a request that fails the validation
+type HTTPValidationError struct {
+	Detail    []ValidationError `json:"detail"`
+	Code      string            `json:"code,omitempty"`
+	RequestID string            `json:"request_id,omitempty"`
+}
+
+// ProblemError represents an RFC 7807 problem details error response
+type ProblemError struct {
+	Type      string            `json:"type"`
+	Title     string            `json:"title"`
+	Status    int               `json:"status"`
+	Detail    string            `json:"detail,omitempty"`
+	Instance  string            `json:"instance,omitempty"`
+	Code      string            `json:"code"`
+	RequestID string            `json:"request_id,omitempty"`
+	Errors    []ValidationError `json:"errors,omitempty"`
 }
 
 // PatchOperation represents a single patch operation
@@ -0,0 +1,47 @@
+package api
+
+import "time"
+
+// MetricAggregate represents the aggregate of a metric across the completed benchmarks of a job
+type MetricAggregate struct {
+	Mean float64 `json:"mean"`
+	Min  float64 `json:"min"`
+	Max  float64 `json:"max"`
+	// Benchmarks is the number of benchmarks that reported the metric
+	Benchmarks int `json:"benchmarks"`
+	// Samples is the number of samples the mean is weighted by, it is omitted when the mean is not weighted
+	Samples float64 `json:"samples,omitempty"`
+}
+
+// BenchmarkSummary represents the summary of a benchmark of an evaluation job
+type BenchmarkSummary struct {
+	ID              string         `json:"id"`
+	State           State          `json:"state"`
+	StartedAt       *time.Time     `json:"started_at,omitempty"`
+	CompletedAt     *time.Time     `json:"completed_at,omitempty"`
+	DurationSeconds *float64       `json:"duration_seconds,omitempty"`
+	Metrics         map[string]any `json:"metrics,omitempty"`
+	Error           *string        `json:"error,omitempty"`
+}
+
+// EvaluationJobSummary represents the summary of an evaluation job
+type EvaluationJobSummary struct {
+	ID                   string                     `json:"id"`
+	Model                ModelRef                   `json:"model"`
+	State                State                      `json:"state"`
+	CreatedAt            time.Time                  `json:"created_at"`
+	StartedAt            *time.Time                 `json:"started_at,omitempty"`
+	CompletedAt          *time.Time                 `json:"completed_at,omitempty"`
+	DurationSeconds      *float64                   `json:"duration_seconds,omitempty"`
+	TotalEvaluations     int                        `json:"total_evaluations"`
+	CompletedEvaluations int                        `json:"completed_evaluations"`
+	FailedEvaluations    int                        `json:"failed_evaluations"`
+	Benchmarks           []BenchmarkSummary         `json:"benchmarks,omitempty"`
+	AggregatedMetrics    map[string]MetricAggregate `json:"aggregated_metrics"`
+}
+
+// EvaluationJobSummaryList represents list of evaluation job summaries with pagination
+type EvaluationJobSummaryList struct {
+	Page
+	Items []EvaluationJobSummary `json:"items"`
+}
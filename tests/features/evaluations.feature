@@ -0,0 +1,52 @@
+Feature: Evaluation Jobs
+  As a service consumer
+  I want to submit evaluation jobs
+  So that my models are evaluated against benchmarks
+
+  Scenario: Create an evaluation job
+    Given the service is running
+    When I send a POST request to "/api/v1/evaluations/jobs" with body:
+      """
+      {
+        "model": {"url": "http://model:8000/v1", "name": "granite"},
+        "benchmarks": [{"id": "mmlu"}],
+        "experiment": {"name": "granite-mmlu"}
+      }
+      """
+    Then the response status should be 202
+    And the response should be JSON
+    And the response should contain "id"
+    When I send a GET request to the returned location
+    Then the response status should be 200
+    And the response should contain "status"
+
+  Scenario: Reject an invalid evaluation job
+    Given the service is running
+    When I send a POST request to "/api/v1/evaluations/jobs" with body:
+      """
+      {"model": {"url": "http://model:8000/v1", "name": "granite"}}
+      """
+    Then the response status should be 422
+    And the response should contain "detail"
+
+  Scenario: Get a missing evaluation job
+    Given the service is running
+    When I send a GET request to "/api/v1/evaluations/jobs/missing"
+    Then the response status should be 404
+
+  Scenario: Cancel and then delete an evaluation job
+    Given the service is running
+    When I send a POST request to "/api/v1/evaluations/jobs" with body:
+      """
+      {"model": {"url": "http://model:8000/v1", "name": "granite"}, "benchmarks": [{"id": "mmlu"}]}
+      """
+    Then the response status should be 202
+    When I send a DELETE request to the returned location
+    Then the response status should be 200
+    And the response should contain "status"
+    When I send a DELETE request to the returned location
+    Then the response status should be 409
+    When I send a DELETE request to the returned location with "hard=true"
+    Then the response status should be 204
+    When I send a GET request to the returned location
+    Then the response status should be 404
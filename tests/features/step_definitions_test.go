@@ -13,6 +13,7 @@ import (
 	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/config"
 	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/logging"
 	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/server"
+	"github.ibm.com/julpayne/eval-hub-backend-svc/internal/storage/memory"
 
 	"github.com/cucumber/godog"
 )
@@ -24,6 +25,8 @@ type apiFeature struct {
 	response   *http.Response
 	body       []byte
 	baseURL    string
+	// location is the Location header of the last response that had one
+	location string
 }
 
 func createServer(port int) (*server.Server, error) {
@@ -31,7 +34,7 @@ func createServer(port int) (*server.Server, error) {
 	if err != nil {
 		return nil, err
 	}
-	return server.NewServer(logger, &config.Config{Service: &config.ServiceConfig{Port: port}})
+	return server.NewServer(logger, &config.Config{Service: &config.ServiceConfig{Port: port}}, memory.NewStorage(), nil, nil)
 }
 
 func (a *apiFeature) theServiceIsRunning(ctx context.Context) error {
@@ -73,11 +76,36 @@ func (a *apiFeature) theServiceIsRunning(ctx context.Context) error {
 }
 
 func (a *apiFeature) iSendARequestTo(method, path string) error {
+	return a.sendRequest(method, path, nil)
+}
+
+func (a *apiFeature) iSendARequestToWithBody(method, path string, body *godog.DocString) error {
+	return a.sendRequest(method, path, strings.NewReader(body.Content))
+}
+
+func (a *apiFeature) iSendARequestToTheLocation(method string) error {
+	if a.location == "" {
+		return fmt.Errorf("no response has had a Location header")
+	}
+	return a.sendRequest(method, a.location, nil)
+}
+
+func (a *apiFeature) iSendARequestToTheLocationWith(method, query string) error {
+	if a.location == "" {
+		return fmt.Errorf("no response has had a Location header")
+	}
+	return a.sendRequest(method, a.location+"?"+query, nil)
+}
+
+func (a *apiFeature) sendRequest(method, path string, body io.Reader) error {
 	url := fmt.Sprintf("%s%s", a.baseURL, path)
-	req, err := http.NewRequest(method, url, nil)
+	req, err := http.NewRequest(method, url, body)
 	if err != nil {
 		return err
 	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
 
 	a.response, err = a.client.Do(req)
 	if err != nil {
@@ -89,6 +117,9 @@ func (a *apiFeature) iSendARequestTo(method, path string) error {
 		return err
 	}
 	a.response.Body.Close()
+	if location := a.response.Header.Get("Location"); location != "" {
+		a.location = location
+	}
 
 	return nil
 }
@@ -167,6 +198,7 @@ func (a *apiFeature) theMetricsShouldShowRequestCountFor(path string) error {
 
 func (a *apiFeature) resetResponse(ctx context.Context, _ *godog.Scenario) (context.Context, error) {
 	a.response = nil
+	a.location = ""
 	a.body = nil
 	return ctx, nil
 }
@@ -192,7 +224,10 @@ func InitializeScenario(ctx *godog.ScenarioContext) {
 	})
 
 	ctx.Step(`^the service is running$`, api.theServiceIsRunning)
-	ctx.Step(`^I send a (GET|POST|PUT|DELETE) request to "([^"]*)"$`, api.iSendARequestTo)
+	ctx.Step(`^I send a (GET|POST|PUT|PATCH|DELETE) request to "([^"]*)"$`, api.iSendARequestTo)
+	ctx.Step(`^I send a (POST|PUT|PATCH) request to "([^"]*)" with body:$`, api.iSendARequestToWithBody)
+	ctx.Step(`^I send a (GET|DELETE) request to the returned location$`, api.iSendARequestToTheLocation)
+	ctx.Step(`^I send a (GET|DELETE) request to the returned location with "([^"]*)"$`, api.iSendARequestToTheLocationWith)
 	ctx.Step(`^the response status should be (\d+)$`, api.theResponseStatusShouldBe)
 	ctx.Step(`^the response should be JSON$`, api.theResponseShouldBeJSON)
 	ctx.Step(`^the response should contain "([^"]*)" with value "([^"]*)"$`, api.theResponseShouldContainWithValue)